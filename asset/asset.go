@@ -0,0 +1,114 @@
+package asset
+
+import (
+	"cmp"
+	"encoding/base64"
+	"errors"
+	"path"
+	"slices"
+	"strings"
+)
+
+// EmbeddedScheme is the URI scheme of the files embedded in a CHARX archive (spelled as in the V3 specification)
+const EmbeddedScheme = "embeded://"
+
+// Type is the V3 asset type of the images embedded from the card text
+const Type = "other"
+
+// Asset errors (the reasons the referenced images are skipped)
+var (
+	ErrHostNotAllowed = errors.New("asset host not allowed")
+	ErrTooLarge       = errors.New("asset too large")
+	ErrMediaType      = errors.New("asset media type not allowed")
+	ErrTooMany        = errors.New("too many assets")
+	ErrStatus         = errors.New("asset download failed")
+)
+
+// extensions are the file extensions of the embedded media types
+var extensions = map[string]string{
+	"image/png":  "png",
+	"image/jpeg": "jpg",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+}
+
+// Asset represents an image downloaded from the card text
+type Asset struct {
+	// Name is the name of the asset (derived from the content, identical images share a name)
+	Name string
+	// Ext is the file extension of the asset (without dot)
+	Ext string
+	// MediaType is the sniffed media type of the asset
+	MediaType string
+	// SourceURL is the URL the asset was downloaded from
+	SourceURL string
+	// URI is the URI replacing the references to the source URL (embedded URI or data URI, see Storage)
+	URI string
+	// Data is the content of the asset
+	Data []byte
+}
+
+// Path returns the path of the asset inside a CHARX archive
+func (a *Asset) Path() string {
+	return path.Join("assets", Type, "image", a.Name+"."+a.Ext)
+}
+
+// EmbeddedURI returns the URI of the asset embedded in a CHARX archive
+func (a *Asset) EmbeddedURI() string {
+	return EmbeddedScheme + a.Path()
+}
+
+// DataURI returns the data URI of the asset (self-contained, for V3 cards without archive)
+func (a *Asset) DataURI() string {
+	return "data:" + a.MediaType + ";base64," + base64.StdEncoding.EncodeToString(a.Data)
+}
+
+// Skipped represents a referenced image that was not embedded
+type Skipped struct {
+	URL string
+	Err error
+}
+
+// Bundle represents the assets embedded from the card text
+type Bundle struct {
+	// Assets are the embedded assets (in order of first reference, without duplicates)
+	Assets []Asset
+	// Skipped are the referenced images that were not embedded (the references are kept)
+	Skipped []Skipped
+}
+
+// IsEmpty checks if no asset was embedded
+func (b *Bundle) IsEmpty() bool {
+	return b == nil || len(b.Assets) == 0
+}
+
+// Rewrite replaces the references of the text to the source URLs of the assets with their URIs
+func (b *Bundle) Rewrite(text string) string {
+	if b.IsEmpty() {
+		return text
+	}
+	// Replace the longest URLs first (a URL can be the prefix of another one)
+	imageURLs := FindImageURLs(text)
+	slices.SortStableFunc(imageURLs, func(a, b string) int { return cmp.Compare(len(b), len(a)) })
+	for _, imageURL := range imageURLs {
+		if asset := b.find(imageURL); asset != nil {
+			text = strings.ReplaceAll(text, imageURL, asset.URI)
+		}
+	}
+	return text
+}
+
+// find returns the asset downloaded from the URL (nil if none)
+func (b *Bundle) find(sourceURL string) *Asset {
+	for index := range b.Assets {
+		if b.Assets[index].SourceURL == sourceURL {
+			return &b.Assets[index]
+		}
+	}
+	return nil
+}
+
+// extension returns the file extension of the media type (empty if not an embeddable media type)
+func extension(mediaType string) string {
+	return extensions[strings.ToLower(mediaType)]
+}
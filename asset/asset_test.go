@@ -0,0 +1,265 @@
+package asset
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/card-parser/property"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pngImage returns a PNG image of the size (different sizes have different contents)
+func pngImage(t *testing.T, width int) []byte {
+	var buffer bytes.Buffer
+	require.NoError(t, png.Encode(&buffer, image.NewRGBA(image.Rect(0, 0, width, 1))))
+	return buffer.Bytes()
+}
+
+// newServer serves the images by path (unknown paths return 404)
+func newServer(t *testing.T, files map[string][]byte) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newEmbedder creates an embedder downloading from the test server
+func newEmbedder(server *httptest.Server, policy Policy) *Embedder {
+	embedder := NewEmbedder(policy)
+	embedder.Client = server.Client()
+	return embedder
+}
+
+func TestFindImageURLs(t *testing.T) {
+	text := `Intro ![gallery](https://avatars.charhub.io/gallery/a.png "title")
+<img class="wide" src='https://files.catbox.moe/b.jpg'>
+Bare https://files.catbox.moe/c.webp?x=1 and again https://files.catbox.moe/b.jpg
+Page https://chub.ai/characters/someone/card is not an image`
+
+	assert.Equal(t, []string{
+		"https://avatars.charhub.io/gallery/a.png",
+		"https://files.catbox.moe/b.jpg",
+		"https://files.catbox.moe/c.webp?x=1",
+	}, FindImageURLs(text))
+	assert.Empty(t, FindImageURLs("No images here"))
+}
+
+func TestPolicy(t *testing.T) {
+	t.Run("should allow any HTTP host without allowed hosts", func(t *testing.T) {
+		policy := Policy{}
+
+		assert.True(t, policy.AllowsURL("https://files.catbox.moe/a.png"))
+		assert.True(t, policy.AllowsURL("http://example.com/a.png"))
+		assert.False(t, policy.AllowsURL("ftp://example.com/a.png"))
+		assert.False(t, policy.AllowsURL("data:image/png;base64,AA=="))
+	})
+
+	t.Run("should match the hosts and their subdomains", func(t *testing.T) {
+		policy := Policy{AllowedHosts: []string{"catbox.moe", ".charhub.io"}, DeniedHosts: []string{"litter.catbox.moe"}}
+
+		assert.True(t, policy.AllowsURL("https://files.catbox.moe/a.png"))
+		assert.True(t, policy.AllowsURL("https://CATBOX.MOE/a.png"))
+		assert.True(t, policy.AllowsURL("https://avatars.charhub.io/a.png"))
+		assert.False(t, policy.AllowsURL("https://litter.catbox.moe/a.png"))
+		assert.False(t, policy.AllowsURL("https://notcatbox.moe/a.png"))
+		assert.False(t, policy.AllowsURL("https://example.com/a.png"))
+	})
+
+	t.Run("should allow the image media types", func(t *testing.T) {
+		policy := Policy{}
+
+		assert.True(t, policy.AllowsMediaType("image/png"))
+		assert.True(t, policy.AllowsMediaType("image/webp"))
+		assert.False(t, policy.AllowsMediaType("text/html; charset=utf-8"))
+		assert.False(t, (&Policy{MediaTypes: []string{"image/png"}}).AllowsMediaType("image/jpeg"))
+	})
+}
+
+func TestEmbedder_Embed(t *testing.T) {
+	first, second := pngImage(t, 1), pngImage(t, 2)
+	server := newServer(t, map[string][]byte{
+		"/first.png":  first,
+		"/second.png": second,
+		"/copy.png":   first,
+		"/page.png":   []byte("<html><body>Not an image</body></html>"),
+		"/large.png":  append(pngImage(t, 3), make([]byte, 1024)...),
+	})
+
+	t.Run("should embed the images and keep the references for CHARX archives", func(t *testing.T) {
+		sheet := character.DefaultSheet(character.RevisionV3)
+		notes := "Gallery ![a](" + server.URL + "/first.png)\n<img src=\"" + server.URL + "/second.png\">"
+		sheet.CreatorNotes = property.String(notes)
+		sheet.AlternateGreetings = []string{"Look " + server.URL + "/first.png"}
+
+		bundle := newEmbedder(server, Policy{}).Embed(sheet)
+
+		require.Len(t, bundle.Assets, 2)
+		assert.Empty(t, bundle.Skipped)
+		assert.Equal(t, server.URL+"/first.png", bundle.Assets[0].SourceURL)
+		assert.Equal(t, "png", bundle.Assets[0].Ext)
+		assert.Equal(t, "image/png", bundle.Assets[0].MediaType)
+		assert.Equal(t, first, bundle.Assets[0].Data)
+		assert.True(t, strings.HasPrefix(bundle.Assets[0].URI, EmbeddedScheme+"assets/other/image/"))
+		assert.Equal(t, notes, string(sheet.CreatorNotes), "the PNG and JSON cards keep the source URLs")
+		assert.Equal(t, "Gallery ![a]("+bundle.Assets[0].URI+")\n<img src=\""+bundle.Assets[1].URI+"\">", bundle.Rewrite(notes))
+		assert.Equal(t, "Look "+bundle.Assets[0].URI, bundle.Rewrite(sheet.AlternateGreetings[0]))
+	})
+
+	t.Run("should name identical images alike", func(t *testing.T) {
+		sheet := character.DefaultSheet(character.RevisionV3)
+		sheet.Description = property.String(server.URL + "/first.png " + server.URL + "/copy.png")
+
+		bundle := newEmbedder(server, Policy{}).Embed(sheet)
+
+		require.Len(t, bundle.Assets, 2)
+		assert.Equal(t, bundle.Assets[0].URI, bundle.Assets[1].URI)
+		assert.Equal(t, bundle.Assets[0].URI+" "+bundle.Assets[0].URI, bundle.Rewrite(string(sheet.Description)))
+	})
+
+	t.Run("should skip the images breaking the policy", func(t *testing.T) {
+		sheet := character.DefaultSheet(character.RevisionV3)
+		text := server.URL + "/page.png " + server.URL + "/large.png " + server.URL + "/missing.png https://example.com/denied.png"
+		sheet.CreatorNotes = property.String(text)
+
+		bundle := newEmbedder(server, Policy{DeniedHosts: []string{"example.com"}, MaxSize: 512}).Embed(sheet)
+
+		assert.True(t, bundle.IsEmpty())
+		require.Len(t, bundle.Skipped, 4)
+		assert.ErrorIs(t, bundle.Skipped[0].Err, ErrMediaType)
+		assert.ErrorIs(t, bundle.Skipped[1].Err, ErrTooLarge)
+		assert.ErrorIs(t, bundle.Skipped[2].Err, ErrStatus)
+		assert.ErrorIs(t, bundle.Skipped[3].Err, ErrHostNotAllowed)
+		assert.Equal(t, text, string(sheet.CreatorNotes))
+	})
+
+	t.Run("should limit the number of assets", func(t *testing.T) {
+		sheet := character.DefaultSheet(character.RevisionV3)
+		sheet.CreatorNotes = property.String(server.URL + "/first.png " + server.URL + "/second.png")
+
+		bundle := newEmbedder(server, Policy{MaxCount: 1}).Embed(sheet)
+
+		require.Len(t, bundle.Assets, 1)
+		require.Len(t, bundle.Skipped, 1)
+		assert.ErrorIs(t, bundle.Skipped[0].Err, ErrTooMany)
+		assert.Equal(t, bundle.Assets[0].URI+" "+server.URL+"/second.png", bundle.Rewrite(string(sheet.CreatorNotes)))
+	})
+
+	t.Run("should skip the images redirected to hosts not allowed", func(t *testing.T) {
+		redirectServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, strings.Replace(server.URL, "127.0.0.1", "localhost", 1)+"/first.png", http.StatusFound)
+		}))
+		t.Cleanup(redirectServer.Close)
+		sheet := character.DefaultSheet(character.RevisionV3)
+		sheet.CreatorNotes = property.String(redirectServer.URL + "/redirect.png")
+
+		bundle := newEmbedder(server, Policy{DeniedHosts: []string{"localhost"}}).Embed(sheet)
+
+		assert.True(t, bundle.IsEmpty())
+		require.Len(t, bundle.Skipped, 1)
+		assert.ErrorIs(t, bundle.Skipped[0].Err, ErrHostNotAllowed)
+	})
+
+	t.Run("should reference the images with data URIs", func(t *testing.T) {
+		sheet := character.DefaultSheet(character.RevisionV3)
+		sheet.FirstMessage = property.String("![a](" + server.URL + "/first.png)")
+		embedder := newEmbedder(server, Policy{})
+		embedder.Storage = StorageDataURI
+
+		bundle := embedder.Embed(sheet)
+
+		require.Len(t, bundle.Assets, 1)
+		assert.True(t, strings.HasPrefix(bundle.Assets[0].URI, "data:image/png;base64,"))
+		assert.Equal(t, "![a]("+bundle.Assets[0].URI+")", string(sheet.FirstMessage))
+	})
+
+	t.Run("should embed nothing without an embedder", func(t *testing.T) {
+		var embedder *Embedder
+		sheet := character.DefaultSheet(character.RevisionV3)
+		sheet.CreatorNotes = property.String(server.URL + "/first.png")
+
+		assert.True(t, embedder.Embed(sheet).IsEmpty())
+		assert.Equal(t, server.URL+"/first.png", string(sheet.CreatorNotes))
+	})
+}
+
+func TestEmbedder_CheckRedirect(t *testing.T) {
+	embedder := NewEmbedder(Policy{AllowedHosts: []string{"example.com"}})
+
+	allowed, err := http.NewRequest(http.MethodGet, "https://cdn.example.com/a.png", nil)
+	require.NoError(t, err)
+	denied, err := http.NewRequest(http.MethodGet, "http://169.254.169.254/a.png", nil)
+	require.NoError(t, err)
+
+	assert.NoError(t, embedder.checkRedirect(allowed, nil))
+	assert.ErrorIs(t, embedder.checkRedirect(denied, nil), ErrHostNotAllowed)
+}
+
+func TestBundle_InjectAssets(t *testing.T) {
+	bundle := &Bundle{Assets: []Asset{
+		{Name: "a", Ext: "png", URI: EmbeddedScheme + "assets/other/image/a.png"},
+		{Name: "a", Ext: "png", URI: EmbeddedScheme + "assets/other/image/a.png"},
+	}}
+	cardJSON := []byte(`{"spec":"chara_card_v3","data":{"name":"Card","assets":[{"type":"icon","uri":"ccdefault:","name":"main","ext":"png"}]}}`)
+
+	injected, err := bundle.InjectAssets(cardJSON)
+	require.NoError(t, err)
+
+	var card struct {
+		Data struct {
+			Name   string              `json:"name"`
+			Assets []map[string]string `json:"assets"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(injected, &card))
+	assert.Equal(t, "Card", card.Data.Name)
+	assert.Equal(t, []map[string]string{
+		{"type": "icon", "uri": "ccdefault:", "name": "main", "ext": "png"},
+		{"type": Type, "uri": EmbeddedScheme + "assets/other/image/a.png", "name": "a", "ext": "png"},
+	}, card.Data.Assets)
+
+	_, err = bundle.InjectAssets([]byte(`{"spec":"chara_card_v3"}`))
+	assert.ErrorIs(t, err, ErrMalformedCard)
+}
+
+func TestBundle_WriteCHARX(t *testing.T) {
+	first := pngImage(t, 1)
+	bundle := &Bundle{Assets: []Asset{{Name: "a", Ext: "png", SourceURL: "https://example.com/a.png", Data: first}}}
+	bundle.Assets[0].URI = bundle.Assets[0].EmbeddedURI()
+	cardJSON := []byte(`{"spec":"chara_card_v3","data":{"name":"Card","alternate_greetings":["![a](https://example.com/a.png)"]}}`)
+
+	var buffer bytes.Buffer
+	require.NoError(t, bundle.WriteCHARX(&buffer, cardJSON))
+
+	archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	require.NoError(t, err)
+	files := map[string][]byte{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		require.NoError(t, err)
+		files[file.Name], err = io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, reader.Close())
+	}
+
+	require.Len(t, files, 2)
+	assert.Equal(t, first, files["assets/other/image/a.png"])
+	assert.Contains(t, string(files[CardFileName]), `"uri":"embeded://assets/other/image/a.png"`)
+	assert.Contains(t, string(files[CardFileName]), `"![a](embeded://assets/other/image/a.png)"`)
+	assert.NotContains(t, string(files[CardFileName]), "https://example.com/a.png")
+}
@@ -0,0 +1,150 @@
+package asset
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"slices"
+
+	"github.com/r3dpixel/toolkit/sonicx"
+)
+
+// CardFileName is the name of the card JSON file inside a CHARX archive
+const CardFileName = "card.json"
+
+// ErrMalformedCard is returned when the card JSON has no data object
+var ErrMalformedCard = errors.New("card JSON has no data object")
+
+// InjectAssets adds the V3 asset entries of the bundle to the card JSON (data.assets, existing entries are kept)
+func (b *Bundle) InjectAssets(cardJSON []byte) ([]byte, error) {
+	// Nothing to inject
+	if b.IsEmpty() {
+		return cardJSON, nil
+	}
+
+	// Decode the card
+	var card map[string]any
+	if err := sonicx.Config.Unmarshal(cardJSON, &card); err != nil {
+		return nil, err
+	}
+	data, ok := card["data"].(map[string]any)
+	if !ok {
+		return nil, ErrMalformedCard
+	}
+
+	// Append the entries of the assets (once per URI)
+	entries, _ := data["assets"].([]any)
+	var uris []string
+	for _, entry := range entries {
+		if fields, ok := entry.(map[string]any); ok {
+			if uri, ok := fields["uri"].(string); ok {
+				uris = append(uris, uri)
+			}
+		}
+	}
+	for _, asset := range b.Assets {
+		if slices.Contains(uris, asset.URI) {
+			continue
+		}
+		uris = append(uris, asset.URI)
+		entries = append(entries, map[string]any{
+			"type": Type,
+			"uri":  asset.URI,
+			"name": asset.Name,
+			"ext":  asset.Ext,
+		})
+	}
+	data["assets"] = entries
+
+	// Encode the card
+	return sonicx.Config.Marshal(card)
+}
+
+// WriteCHARX writes the card JSON and the embedded assets as a CHARX archive (card.json with the asset entries, and the asset files)
+// The assets must be referenced with embedded URIs (StorageCHARX, the source URLs of the card are rewritten to them),
+// and the card should be a V3 card (see Sheet.ToBytes)
+func (b *Bundle) WriteCHARX(w io.Writer, cardJSON []byte) error {
+	// Rewrite the references of the card to the embedded assets
+	cardJSON, err := b.rewriteCard(cardJSON)
+	if err != nil {
+		return err
+	}
+
+	// Add the asset entries to the card
+	cardJSON, err = b.InjectAssets(cardJSON)
+	if err != nil {
+		return err
+	}
+
+	// Write the card
+	archive := zip.NewWriter(w)
+	file, err := archive.Create(CardFileName)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(cardJSON); err != nil {
+		return err
+	}
+
+	// Write the asset files (identical images share a file)
+	var written []string
+	if b != nil {
+		for _, asset := range b.Assets {
+			if slices.Contains(written, asset.Path()) {
+				continue
+			}
+			written = append(written, asset.Path())
+			file, err := archive.Create(asset.Path())
+			if err != nil {
+				return err
+			}
+			if _, err := file.Write(asset.Data); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Close the archive (writes the central directory)
+	return archive.Close()
+}
+
+// rewriteCard rewrites the references of the text fields of the card JSON to the embedded assets
+func (b *Bundle) rewriteCard(cardJSON []byte) ([]byte, error) {
+	// Nothing to rewrite
+	if b.IsEmpty() {
+		return cardJSON, nil
+	}
+
+	// Decode the card
+	var card map[string]any
+	if err := sonicx.Config.Unmarshal(cardJSON, &card); err != nil {
+		return nil, err
+	}
+	data, ok := card["data"].(map[string]any)
+	if !ok {
+		return nil, ErrMalformedCard
+	}
+
+	// Rewrite the strings of the data (the nested greetings and book entries included)
+	card["data"] = b.rewriteValue(data)
+
+	// Encode the card
+	return sonicx.Config.Marshal(card)
+}
+
+// rewriteValue rewrites the references of the strings of the JSON value
+func (b *Bundle) rewriteValue(value any) any {
+	switch typed := value.(type) {
+	case string:
+		return b.Rewrite(typed)
+	case []any:
+		for index := range typed {
+			typed[index] = b.rewriteValue(typed[index])
+		}
+	case map[string]any:
+		for key := range typed {
+			typed[key] = b.rewriteValue(typed[key])
+		}
+	}
+	return value
+}
@@ -0,0 +1,245 @@
+package asset
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/imroc/req/v3"
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/card-parser/property"
+	"github.com/r3dpixel/toolkit/reqx"
+)
+
+// maxRedirects is the maximum number of redirects followed by the embedder client
+const maxRedirects = 10
+
+// Storage is the way the embedded assets are referenced from the card text
+type Storage string
+
+// Storages
+const (
+	// StorageCHARX references the assets with embedded URIs (files of a CHARX archive, the references are rewritten by Bundle.WriteCHARX)
+	StorageCHARX Storage = "charx"
+	// StorageDataURI references the assets with data URIs (self-contained V3 assets, the references are rewritten in the sheet)
+	StorageDataURI Storage = "data_uri"
+)
+
+// imageURLRegExps match the image URLs of a text (Markdown images, HTML images, and bare image links)
+var imageURLRegExps = []*regexp.Regexp{
+	regexp.MustCompile(`!\[[^\]]*\]\(\s*<?(https?://[^\s)>]+)>?(?:\s+"[^"]*")?\s*\)`),
+	regexp.MustCompile(`(?i)<img\s[^>]*?src\s*=\s*["'](https?://[^"']+)["']`),
+	regexp.MustCompile(`(?i)https?://[^\s<>"'()\[\]]+\.(?:png|jpe?g|gif|webp)(?:\?[^\s<>"'()\[\]]*)?`),
+}
+
+// Getter downloads the assets (satisfied by *http.Client and ClientGetter)
+type Getter interface {
+	Get(url string) (*http.Response, error)
+}
+
+// ClientGetter downloads the assets with a reqx client (the redirects are checked with CheckRedirect)
+type ClientGetter struct {
+	// Client downloads the assets (its redirect policy is replaced on first use)
+	Client *reqx.Client
+	// CheckRedirect rejects the redirects (optional)
+	CheckRedirect func(request *http.Request, via []*http.Request) error
+
+	redirectOnce sync.Once
+}
+
+// Get downloads the asset (the body is streamed, not read in advance)
+func (g *ClientGetter) Get(url string) (*http.Response, error) {
+	request := g.Client.R()
+	g.redirectOnce.Do(func() {
+		if g.CheckRedirect != nil {
+			request.GetClient().SetRedirectPolicy(req.MaxRedirectPolicy(maxRedirects), g.CheckRedirect)
+		}
+	})
+	response, err := request.DisableAutoReadResponse().Get(url)
+	if err != nil {
+		return nil, err
+	}
+	return response.Response, nil
+}
+
+// Embedder downloads the images referenced in the card text and rewrites the references to the embedded assets
+type Embedder struct {
+	// Client downloads the assets
+	Client Getter
+	// Policy restricts the downloaded assets
+	Policy Policy
+	// Storage is the way the embedded assets are referenced (defaults to StorageCHARX)
+	Storage Storage
+}
+
+// NewEmbedder creates an embedder with the policy (using a dedicated client with a timeout, the redirects are checked against the policy)
+func NewEmbedder(policy Policy) *Embedder {
+	embedder := &Embedder{Policy: policy, Storage: StorageCHARX}
+	embedder.Client = &ClientGetter{
+		Client:        reqx.NewClient(reqx.Options{Timeout: 30 * time.Second}),
+		CheckRedirect: embedder.checkRedirect,
+	}
+	return embedder
+}
+
+// checkRedirect rejects the redirects to the URLs not allowed by the policy
+func (e *Embedder) checkRedirect(request *http.Request, _ []*http.Request) error {
+	if !e.Policy.AllowsURL(request.URL.String()) {
+		return fmt.Errorf("%w: redirect to %s", ErrHostNotAllowed, request.URL.Hostname())
+	}
+	return nil
+}
+
+// FindImageURLs returns the image URLs referenced in the text (in order of appearance, without duplicates)
+func FindImageURLs(text string) []string {
+	type match struct {
+		start int
+		url   string
+	}
+	var matches []match
+	for _, expression := range imageURLRegExps {
+		for _, indexes := range expression.FindAllStringSubmatchIndex(text, -1) {
+			// Use the captured URL if any (the whole match otherwise)
+			start, end := indexes[0], indexes[1]
+			if len(indexes) > 2 && indexes[2] >= 0 {
+				start, end = indexes[2], indexes[3]
+			}
+			matches = append(matches, match{start: start, url: text[start:end]})
+		}
+	}
+
+	// Sort the URLs by position and remove the duplicates
+	slices.SortStableFunc(matches, func(a, b match) int { return cmp.Compare(a.start, b.start) })
+	var urls []string
+	for _, match := range matches {
+		if !slices.Contains(urls, match.url) {
+			urls = append(urls, match.url)
+		}
+	}
+	return urls
+}
+
+// Embed downloads the images referenced in the text fields of the sheet (nil embedder embeds nothing)
+// The references are rewritten to data URIs with StorageDataURI; with StorageCHARX the sheet keeps the source URLs
+// (valid in PNG and JSON cards), and the references are rewritten when the archive is written (see Bundle.WriteCHARX)
+func (e *Embedder) Embed(sheet *character.Sheet) *Bundle {
+	bundle := &Bundle{}
+	if e == nil {
+		return bundle
+	}
+
+	// Collect the text fields of the sheet
+	fields := []*property.String{
+		&sheet.Description, &sheet.Personality, &sheet.Scenario, &sheet.FirstMessage,
+		&sheet.MessageExamples, &sheet.CreatorNotes, &sheet.SystemPrompt, &sheet.PostHistoryInstructions,
+	}
+	for index := range sheet.AlternateGreetings {
+		fields = append(fields, (*property.String)(&sheet.AlternateGreetings[index]))
+	}
+	for index := range sheet.GroupGreetings {
+		fields = append(fields, (*property.String)(&sheet.GroupGreetings[index]))
+	}
+
+	// Download the referenced images (once per URL)
+	attempted := map[string]bool{}
+	for _, field := range fields {
+		for _, imageURL := range FindImageURLs(string(*field)) {
+			if attempted[imageURL] {
+				continue
+			}
+			attempted[imageURL] = true
+			if err := e.embed(bundle, imageURL); err != nil {
+				bundle.Skipped = append(bundle.Skipped, Skipped{URL: imageURL, Err: err})
+			}
+		}
+	}
+
+	// Rewrite the references to the self-contained assets
+	if e.Storage == StorageDataURI {
+		for _, field := range fields {
+			*field = property.String(bundle.Rewrite(string(*field)))
+		}
+	}
+
+	return bundle
+}
+
+// embed downloads the image and adds it to the bundle
+func (e *Embedder) embed(bundle *Bundle, imageURL string) error {
+	// Check the policy
+	if !e.Policy.AllowsURL(imageURL) {
+		return ErrHostNotAllowed
+	}
+	if len(bundle.Assets) >= e.Policy.maxCount() {
+		return ErrTooMany
+	}
+
+	// Download the image
+	data, err := e.download(imageURL)
+	if err != nil {
+		return err
+	}
+
+	// Check the sniffed media type (the declared content type is not trusted)
+	mediaType := http.DetectContentType(data)
+	if !e.Policy.AllowsMediaType(mediaType) {
+		return fmt.Errorf("%w: %s", ErrMediaType, mediaType)
+	}
+
+	// Name the asset after its content (identical images share the file)
+	hash := sha256.Sum256(data)
+	asset := Asset{
+		Name:      hex.EncodeToString(hash[:8]),
+		Ext:       extension(mediaType),
+		MediaType: mediaType,
+		SourceURL: imageURL,
+		Data:      data,
+	}
+	if e.Storage == StorageDataURI {
+		asset.URI = asset.DataURI()
+	} else {
+		asset.URI = asset.EmbeddedURI()
+	}
+	bundle.Assets = append(bundle.Assets, asset)
+	return nil
+}
+
+// download downloads the image (limited to the maximum size)
+func (e *Embedder) download(imageURL string) ([]byte, error) {
+	response, err := e.Client.Get(imageURL)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	// Check the final URL (the clients without redirect check may have followed a redirect to another host)
+	if response.Request != nil && response.Request.URL != nil && !e.Policy.AllowsURL(response.Request.URL.String()) {
+		return nil, fmt.Errorf("%w: redirect to %s", ErrHostNotAllowed, response.Request.URL.Hostname())
+	}
+
+	// Check the response
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: status %d", ErrStatus, response.StatusCode)
+	}
+	maxSize := e.Policy.maxSize()
+	if response.ContentLength > maxSize {
+		return nil, ErrTooLarge
+	}
+
+	// Read the body (one byte over the limit to detect larger bodies without length)
+	data, err := io.ReadAll(io.LimitReader(response.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxSize {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
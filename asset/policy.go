@@ -0,0 +1,76 @@
+package asset
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Default limits of the asset policy
+const (
+	DefaultMaxSize  = 8 << 20
+	DefaultMaxCount = 32
+)
+
+// DefaultMediaTypes are the media types embedded by default
+var DefaultMediaTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// Policy represents the rules restricting the downloaded assets
+type Policy struct {
+	// AllowedHosts restricts the downloads to these hosts and their subdomains (empty allows all hosts)
+	AllowedHosts []string
+	// DeniedHosts excludes these hosts and their subdomains
+	DeniedHosts []string
+	// MaxSize is the maximum size of an asset in bytes (0 uses DefaultMaxSize)
+	MaxSize int64
+	// MaxCount is the maximum number of assets embedded per card (0 uses DefaultMaxCount)
+	MaxCount int
+	// MediaTypes are the embedded media types (empty uses DefaultMediaTypes)
+	MediaTypes []string
+}
+
+// AllowsURL checks if the URL can be downloaded (HTTP(S) URLs of allowed hosts only)
+func (p *Policy) AllowsURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return false
+	}
+	host := strings.ToLower(parsed.Hostname())
+	if slices.ContainsFunc(p.DeniedHosts, func(denied string) bool { return matchesHost(host, denied) }) {
+		return false
+	}
+	return len(p.AllowedHosts) == 0 || slices.ContainsFunc(p.AllowedHosts, func(allowed string) bool { return matchesHost(host, allowed) })
+}
+
+// AllowsMediaType checks if the media type can be embedded
+func (p *Policy) AllowsMediaType(mediaType string) bool {
+	mediaTypes := p.MediaTypes
+	if len(mediaTypes) == 0 {
+		mediaTypes = DefaultMediaTypes
+	}
+	return extension(mediaType) != "" && slices.ContainsFunc(mediaTypes, func(allowed string) bool {
+		return strings.EqualFold(allowed, mediaType)
+	})
+}
+
+// maxSize returns the maximum size of an asset
+func (p *Policy) maxSize() int64 {
+	if p.MaxSize > 0 {
+		return p.MaxSize
+	}
+	return DefaultMaxSize
+}
+
+// maxCount returns the maximum number of assets per card
+func (p *Policy) maxCount() int {
+	if p.MaxCount > 0 {
+		return p.MaxCount
+	}
+	return DefaultMaxCount
+}
+
+// matchesHost checks if the host is the pattern or one of its subdomains
+func matchesHost(host string, pattern string) bool {
+	pattern = strings.ToLower(strings.TrimPrefix(pattern, "."))
+	return host == pattern || strings.HasSuffix(host, "."+pattern)
+}
@@ -0,0 +1,190 @@
+package avatar
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	imagepng "image/png"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/r3dpixel/card-parser/png"
+)
+
+// Format is the image format of the cards and thumbnails
+type Format string
+
+// Formats
+const (
+	FormatPNG  Format = "png"
+	FormatWebP Format = "webp"
+	FormatJPEG Format = "jpeg"
+)
+
+// DefaultQuality is the default JPEG quality of the thumbnails
+const DefaultQuality = 85
+
+// ErrUnsupportedCardFormat is returned for card formats unable to hold the character data
+// (JPEG metadata segments are limited to 64 KB, too small for cards with lorebooks)
+var ErrUnsupportedCardFormat = errors.New("unsupported card format")
+
+// Options for processing the avatars (the zero value re-encodes the avatar without thumbnails)
+type Options struct {
+	// MaxSize is the maximum width and height of the avatar (0 keeps the size, the aspect ratio is always kept)
+	MaxSize int
+	// Format is the image format of the card (defaults to FormatPNG, FormatJPEG is rejected)
+	Format Format
+	// Thumbnails are the maximum sizes of the thumbnails (none by default)
+	Thumbnails []int
+	// ThumbnailFormat is the image format of the thumbnails (defaults to FormatPNG)
+	ThumbnailFormat Format
+	// Quality is the JPEG quality of the thumbnails (0 uses DefaultQuality)
+	Quality int
+}
+
+// Thumbnail represents a scaled down copy of the avatar (without character data)
+type Thumbnail struct {
+	// Size is the requested maximum size
+	Size int
+	// Width and Height are the actual dimensions of the thumbnail
+	Width  int
+	Height int
+	// Format is the image format of the thumbnail
+	Format Format
+	// Data is the encoded thumbnail
+	Data []byte
+}
+
+// Result represents a processed avatar
+type Result struct {
+	// Card is the re-encoded card (character data kept, other metadata stripped)
+	Card []byte
+	// Format is the image format of the card
+	Format Format
+	// Width and Height are the dimensions of the re-encoded avatar
+	Width  int
+	Height int
+	// OriginalSize is the size of the card before processing (in bytes)
+	OriginalSize int
+	// Thumbnails are the thumbnails of the avatar (in the order of the requested sizes)
+	Thumbnails []Thumbnail
+}
+
+// ProcessCard encodes the character card and processes its avatar (the character data of the card is embedded in the result)
+func ProcessCard(characterCard *png.CharacterCard, options Options) (*Result, error) {
+	rawCard, err := characterCard.Encode()
+	if err != nil {
+		return nil, err
+	}
+	data, err := rawCard.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	return Process(data, options)
+}
+
+// Process resizes and re-encodes the PNG card, and produces the thumbnails
+// The character data chunks are copied to the re-encoded card, any other metadata is stripped
+// WebP cards store the V2 card JSON in the EXIF user comment and the V3 card JSON in the XMP packet
+func Process(data []byte, options Options) (*Result, error) {
+	// Only the PNG and WebP containers can hold the character data
+	format := options.Format
+	if format == "" {
+		format = FormatPNG
+	}
+	if format != FormatPNG && format != FormatWebP {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedCardFormat, format)
+	}
+
+	// Keep the character data (a card without character data would be lost)
+	chunks, err := readChunks(data)
+	if err != nil {
+		return nil, err
+	}
+	characterData := characterChunks(chunks)
+	if len(characterData) == 0 {
+		return nil, ErrNoCharacterData
+	}
+
+	// Decode the avatar
+	src, err := imagepng.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMalformedPNG, err)
+	}
+
+	// Resize and re-encode the avatar (with the character data)
+	resized := resize(src, options.MaxSize)
+	card, err := encodeCard(data, resized, format, characterData)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{Card: card, Format: format, Width: resized.Bounds().Dx(), Height: resized.Bounds().Dy(), OriginalSize: len(data)}
+
+	// Produce the thumbnails (from the original avatar, for the best quality)
+	thumbnailFormat := options.ThumbnailFormat
+	if thumbnailFormat == "" {
+		thumbnailFormat = FormatPNG
+	}
+	for _, size := range options.Thumbnails {
+		thumbnail := resize(src, size)
+		thumbnailData, err := encode(thumbnail, thumbnailFormat, options.Quality)
+		if err != nil {
+			return nil, err
+		}
+		result.Thumbnails = append(result.Thumbnails, Thumbnail{
+			Size:   size,
+			Width:  thumbnail.Bounds().Dx(),
+			Height: thumbnail.Bounds().Dy(),
+			Format: thumbnailFormat,
+			Data:   thumbnailData,
+		})
+	}
+
+	return result, nil
+}
+
+// encodeCard encodes the avatar as a card in the format (PNG cards get the character data chunks, WebP cards the V2 and V3 card JSONs)
+func encodeCard(data []byte, img image.Image, format Format, characterData []chunk) ([]byte, error) {
+	if format == FormatWebP {
+		cardJSONs, err := CharacterData(data)
+		if err != nil {
+			return nil, err
+		}
+		if len(cardJSONs) == 0 {
+			return nil, ErrNoCharacterData
+		}
+		return encodeWebPCard(img, cardJSONs)
+	}
+
+	encoded, err := encode(img, FormatPNG, 0)
+	if err != nil {
+		return nil, err
+	}
+	return withChunks(encoded, characterData)
+}
+
+// encode encodes the image in the format (JPEG images are drawn over a white background)
+func encode(img image.Image, format Format, quality int) ([]byte, error) {
+	var buffer bytes.Buffer
+	var err error
+	switch format {
+	case FormatPNG:
+		encoder := imagepng.Encoder{CompressionLevel: imagepng.BestCompression}
+		err = encoder.Encode(&buffer, img)
+	case FormatWebP:
+		err = nativewebp.Encode(&buffer, img, nil)
+	case FormatJPEG:
+		if quality <= 0 {
+			quality = DefaultQuality
+		}
+		err = jpeg.Encode(&buffer, flatten(img, color.White), &jpeg.Options{Quality: min(quality, 100)})
+	default:
+		err = fmt.Errorf("unsupported avatar format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,237 @@
+package avatar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	imagepng "image/png"
+	"testing"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// card encodes a PNG card of the size with the text chunks (keyword, text)
+func card(t *testing.T, width, height int, texts ...string) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 0x80, A: 0xff})
+		}
+	}
+	var buffer bytes.Buffer
+	require.NoError(t, imagepng.Encode(&buffer, img))
+
+	var extra []chunk
+	for index := 0; index+1 < len(texts); index += 2 {
+		extra = append(extra, chunk{kind: "tEXt", data: []byte(texts[index] + "\x00" + texts[index+1])})
+	}
+	data, err := withChunks(buffer.Bytes(), extra)
+	require.NoError(t, err)
+	return data
+}
+
+// texts returns the text chunks of the PNG file
+func texts(t *testing.T, data []byte) map[string]string {
+	chunks, err := readChunks(data)
+	require.NoError(t, err)
+	found := map[string]string{}
+	for _, c := range chunks {
+		if c.kind == "tEXt" {
+			keyword, text, _ := bytes.Cut(c.data, []byte{0})
+			found[string(keyword)] = string(text)
+		}
+	}
+	return found
+}
+
+func TestFit(t *testing.T) {
+	width, height := fit(2000, 1000, 512)
+	assert.Equal(t, []int{512, 256}, []int{width, height})
+	width, height = fit(1000, 3000, 512)
+	assert.Equal(t, []int{171, 512}, []int{width, height})
+	width, height = fit(300, 200, 512)
+	assert.Equal(t, []int{300, 200}, []int{width, height})
+	width, height = fit(300, 200, 0)
+	assert.Equal(t, []int{300, 200}, []int{width, height})
+}
+
+func TestProcess(t *testing.T) {
+	source := card(t, 200, 100, "chara", "djJkYXRh", "ccv3", "djNkYXRh", "Software", "Editor", "Comment", "Private")
+
+	t.Run("should resize the avatar and keep the character data only", func(t *testing.T) {
+		result, err := Process(source, Options{MaxSize: 64})
+		require.NoError(t, err)
+
+		assert.Equal(t, 64, result.Width)
+		assert.Equal(t, 32, result.Height)
+		assert.Equal(t, len(source), result.OriginalSize)
+		assert.Equal(t, map[string]string{"chara": "djJkYXRh", "ccv3": "djNkYXRh"}, texts(t, result.Card))
+
+		decoded, err := imagepng.Decode(bytes.NewReader(result.Card))
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 64, 32), decoded.Bounds())
+	})
+
+	t.Run("should keep the size of smaller avatars", func(t *testing.T) {
+		result, err := Process(source, Options{MaxSize: 512})
+		require.NoError(t, err)
+
+		assert.Equal(t, 200, result.Width)
+		assert.Equal(t, 100, result.Height)
+		assert.Equal(t, map[string]string{"chara": "djJkYXRh", "ccv3": "djNkYXRh"}, texts(t, result.Card))
+	})
+
+	t.Run("should produce the thumbnails", func(t *testing.T) {
+		result, err := Process(source, Options{Thumbnails: []int{50, 20}, ThumbnailFormat: FormatJPEG})
+		require.NoError(t, err)
+
+		require.Len(t, result.Thumbnails, 2)
+		assert.Equal(t, 50, result.Thumbnails[0].Width)
+		assert.Equal(t, 25, result.Thumbnails[0].Height)
+		assert.Equal(t, 20, result.Thumbnails[1].Width)
+		assert.Equal(t, 10, result.Thumbnails[1].Height)
+		decoded, err := jpeg.Decode(bytes.NewReader(result.Thumbnails[1].Data))
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 20, 10), decoded.Bounds())
+	})
+
+	t.Run("should encode the thumbnails in the format", func(t *testing.T) {
+		result, err := Process(source, Options{Thumbnails: []int{16}, ThumbnailFormat: FormatWebP})
+		require.NoError(t, err)
+		require.Len(t, result.Thumbnails, 1)
+		assert.Equal(t, "WEBP", string(result.Thumbnails[0].Data[8:12]))
+
+		result, err = Process(source, Options{Thumbnails: []int{16}})
+		require.NoError(t, err)
+		require.Len(t, result.Thumbnails, 1)
+		assert.Equal(t, FormatPNG, result.Thumbnails[0].Format)
+		assert.Empty(t, texts(t, result.Thumbnails[0].Data))
+	})
+
+	t.Run("should encode WebP cards with the V2 and V3 card JSONs", func(t *testing.T) {
+		result, err := Process(source, Options{MaxSize: 63, Format: FormatWebP})
+		require.NoError(t, err)
+
+		assert.Equal(t, FormatWebP, result.Format)
+		cardJSONs, err := WebPCharacterData(result.Card)
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]byte{"chara": []byte("v2data"), "ccv3": []byte("v3data")}, cardJSONs)
+
+		decoded, err := nativewebp.Decode(bytes.NewReader(result.Card))
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 63, 32), decoded.Bounds())
+	})
+
+	t.Run("should reject the card formats unable to hold the character data", func(t *testing.T) {
+		_, err := Process(source, Options{Format: FormatJPEG})
+		assert.ErrorIs(t, err, ErrUnsupportedCardFormat)
+	})
+
+	t.Run("should reject the avatars without character data", func(t *testing.T) {
+		_, err := Process(card(t, 10, 10, "Comment", "Private"), Options{})
+		assert.ErrorIs(t, err, ErrNoCharacterData)
+
+		_, err = Process([]byte("GIF89a"), Options{})
+		assert.ErrorIs(t, err, ErrNotPNG)
+
+		_, err = Process(source[:len(source)-20], Options{})
+		assert.ErrorIs(t, err, ErrMalformedPNG)
+	})
+}
+
+func TestResize(t *testing.T) {
+	// Half transparent, half opaque red (transparent pixels must not darken the average)
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	for y := range 2 {
+		img.SetNRGBA(0, y, color.NRGBA{})
+		img.SetNRGBA(1, y, color.NRGBA{R: 0xff, A: 0xff})
+		img.SetNRGBA(2, y, color.NRGBA{R: 0xff, A: 0xff})
+		img.SetNRGBA(3, y, color.NRGBA{R: 0xff, A: 0xff})
+	}
+
+	resized := resize(img, 2)
+
+	assert.Equal(t, image.Rect(0, 0, 2, 1), resized.Bounds())
+	assert.Equal(t, color.NRGBA{R: 0xff, A: 0x80}, color.NRGBAModel.Convert(resized.At(0, 0)))
+	assert.Equal(t, color.NRGBA{R: 0xff, A: 0xff}, color.NRGBAModel.Convert(resized.At(1, 0)))
+}
+
+func TestIdenticon(t *testing.T) {
+	first, same, other := Identicon("Aria", 64), Identicon(" aria ", 64), Identicon("Bastion", 64)
+
+	assert.Equal(t, image.Rect(0, 0, 64, 64), first.Bounds())
+	assert.Equal(t, first, same)
+	assert.NotEqual(t, first, other)
+
+	// The grid is mirrored
+	for y := range 64 {
+		for x := range 32 {
+			assert.Equal(t, first.At(x, y), first.At(63-x, y), "pixel %d,%d", x, y)
+		}
+	}
+}
+
+func TestGenerateCard(t *testing.T) {
+	data, err := GenerateCard("Aria", 64)
+	require.NoError(t, err)
+
+	chunks, err := readChunks(data)
+	require.NoError(t, err)
+	assert.Len(t, characterChunks(chunks), 1)
+
+	decoded, err := imagepng.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 64, 64), decoded.Bounds())
+}
+
+func TestReplaceCharacterData(t *testing.T) {
+	source := card(t, 4, 4, "chara", "b2xk", "Comment", "Kept")
+
+	data, err := ReplaceCharacterData(source, map[string][]byte{"ccv3": []byte("v3"), "chara": []byte("v2")})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"chara": "djI=", "ccv3": "djM=", "Comment": "Kept"}, texts(t, data))
+	chunks, err := readChunks(data)
+	require.NoError(t, err)
+	assert.Equal(t, "chara", string(characterChunks(chunks)[0].data[:5]))
+}
+
+func TestCharacterData(t *testing.T) {
+	data, err := ReplaceCharacterData(card(t, 4, 4, "Comment", "Kept"), map[string][]byte{"chara": []byte(`{"spec":"chara_card_v2"}`)})
+	require.NoError(t, err)
+
+	cardJSONs, err := CharacterData(data)
+
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"chara": []byte(`{"spec":"chara_card_v2"}`)}, cardJSONs)
+}
+
+func TestWebPCharacterData(t *testing.T) {
+	_, err := WebPCharacterData([]byte("\x89PNG"))
+	assert.ErrorIs(t, err, ErrNotWebP)
+
+	var buffer bytes.Buffer
+	require.NoError(t, nativewebp.Encode(&buffer, image.NewNRGBA(image.Rect(0, 0, 4, 4)), nil))
+	_, err = WebPCharacterData(buffer.Bytes())
+	assert.ErrorIs(t, err, ErrNoCharacterData)
+
+	card, err := encodeWebPCard(image.NewNRGBA(image.Rect(0, 0, 4, 4)), map[string][]byte{"chara": []byte(`{"name":"Ümlaut"}`)})
+	require.NoError(t, err)
+	cardJSONs, err := WebPCharacterData(card)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"chara": []byte(`{"name":"Ümlaut"}`)}, cardJSONs)
+	assert.Equal(t, len(card)-8, int(binary.LittleEndian.Uint32(card[4:])))
+
+	card, err = encodeWebPCard(image.NewNRGBA(image.Rect(0, 0, 4, 4)), map[string][]byte{"ccv3": []byte(`{"data":{"assets":[]}}`)})
+	require.NoError(t, err)
+	cardJSONs, err = WebPCharacterData(card)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]byte{"ccv3": []byte(`{"data":{"assets":[]}}`)}, cardJSONs)
+
+	_, err = WebPCharacterData(card[:len(card)-4])
+	assert.ErrorIs(t, err, ErrMalformedWebP)
+}
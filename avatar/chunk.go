@@ -0,0 +1,162 @@
+package avatar
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"slices"
+	"strings"
+)
+
+// pngSignature is the signature starting every PNG file
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// characterKeywords are the keywords of the text chunks holding the character data (V2 and V3 cards)
+var characterKeywords = []string{"chara", "ccv3"}
+
+// textChunks are the PNG chunk types holding keyword/text pairs
+var textChunks = []string{"tEXt", "zTXt", "iTXt"}
+
+// Chunk errors
+var (
+	ErrNotPNG          = errors.New("avatar is not a PNG image")
+	ErrMalformedPNG    = errors.New("avatar PNG is malformed")
+	ErrNoCharacterData = errors.New("avatar has no character data")
+)
+
+// chunk is a PNG chunk (length and CRC are computed when written)
+type chunk struct {
+	kind string
+	data []byte
+}
+
+// readChunks splits the PNG file into chunks
+func readChunks(data []byte) ([]chunk, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, ErrNotPNG
+	}
+
+	var chunks []chunk
+	for offset := len(pngSignature); offset < len(data); {
+		// Length (4 bytes), type (4 bytes), data, CRC (4 bytes)
+		if len(data)-offset < 12 {
+			return nil, ErrMalformedPNG
+		}
+		length := int(binary.BigEndian.Uint32(data[offset:]))
+		if len(data)-offset-12 < length {
+			return nil, ErrMalformedPNG
+		}
+		kind := string(data[offset+4 : offset+8])
+		chunks = append(chunks, chunk{kind: kind, data: data[offset+8 : offset+8+length]})
+		offset += 12 + length
+		if kind == "IEND" {
+			return chunks, nil
+		}
+	}
+	// The end marker is missing
+	return nil, ErrMalformedPNG
+}
+
+// characterChunks returns the text chunks holding the character data
+func characterChunks(chunks []chunk) []chunk {
+	var found []chunk
+	for _, c := range chunks {
+		if !slices.Contains(textChunks, c.kind) {
+			continue
+		}
+		keyword, _, _ := bytes.Cut(c.data, []byte{0})
+		for _, characterKeyword := range characterKeywords {
+			if strings.EqualFold(string(keyword), characterKeyword) {
+				found = append(found, c)
+			}
+		}
+	}
+	return found
+}
+
+// withChunks inserts the chunks into the encoded PNG file (before its end marker)
+func withChunks(encoded []byte, extra []chunk) ([]byte, error) {
+	chunks, err := readChunks(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	var buffer bytes.Buffer
+	buffer.Write(pngSignature)
+	for _, c := range chunks {
+		if c.kind == "IEND" {
+			for _, e := range extra {
+				writeChunk(&buffer, e)
+			}
+		}
+		writeChunk(&buffer, c)
+	}
+	return buffer.Bytes(), nil
+}
+
+// writeChunk writes the chunk (with its length and CRC)
+func writeChunk(buffer *bytes.Buffer, c chunk) {
+	_ = binary.Write(buffer, binary.BigEndian, uint32(len(c.data)))
+	buffer.WriteString(c.kind)
+	buffer.Write(c.data)
+	checksum := crc32.NewIEEE()
+	checksum.Write([]byte(c.kind))
+	checksum.Write(c.data)
+	_ = binary.Write(buffer, binary.BigEndian, checksum.Sum32())
+}
+
+// CharacterData returns the card JSON of the character data chunks of the PNG card (by keyword, tEXt chunks only)
+func CharacterData(data []byte) (map[string][]byte, error) {
+	chunks, err := readChunks(data)
+	if err != nil {
+		return nil, err
+	}
+	cardJSONs := map[string][]byte{}
+	for _, c := range characterChunks(chunks) {
+		if c.kind != "tEXt" {
+			continue
+		}
+		keyword, text, _ := bytes.Cut(c.data, []byte{0})
+		cardJSON, err := base64.StdEncoding.DecodeString(string(text))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrMalformedPNG, err)
+		}
+		cardJSONs[strings.ToLower(string(keyword))] = cardJSON
+	}
+	return cardJSONs, nil
+}
+
+// ReplaceCharacterData replaces the character data chunks of the PNG card (card JSON by keyword, "chara" for V2 and "ccv3" for V3)
+// The card JSON is stored base64 encoded in tEXt chunks, the other chunks are kept
+func ReplaceCharacterData(data []byte, cardJSONs map[string][]byte) ([]byte, error) {
+	chunks, err := readChunks(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Drop the previous character data
+	chunks = slices.DeleteFunc(chunks, func(c chunk) bool { return len(characterChunks([]chunk{c})) > 0 })
+
+	// Write the chunks, with the character data before the end marker
+	var buffer bytes.Buffer
+	buffer.Write(pngSignature)
+	for _, c := range chunks {
+		if c.kind == "IEND" {
+			for _, keyword := range characterKeywords {
+				if cardJSON, ok := cardJSONs[keyword]; ok {
+					writeChunk(&buffer, textChunk(keyword, cardJSON))
+				}
+			}
+		}
+		writeChunk(&buffer, c)
+	}
+	return buffer.Bytes(), nil
+}
+
+// textChunk returns the tEXt chunk holding the card JSON (base64 encoded)
+func textChunk(keyword string, cardJSON []byte) chunk {
+	return chunk{kind: "tEXt", data: []byte(keyword + "\x00" + base64.StdEncoding.EncodeToString(cardJSON))}
+}
@@ -0,0 +1,88 @@
+package avatar
+
+import (
+	"crypto/sha256"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/card-parser/property"
+)
+
+// identiconGrid is the number of cells per side of the identicon (the left half is mirrored)
+const identiconGrid = 5
+
+// identiconBackground is the background color of the identicons
+var identiconBackground = color.RGBA{R: 0xf0, G: 0xf0, B: 0xf0, A: 0xff}
+
+// Identicon generates a deterministic avatar from the name (mirrored grid, colored after the hash of the name)
+func Identicon(name string, size int) image.Image {
+	hash := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(name))))
+	foreground := hueColor(float64(int(hash[0])<<8|int(hash[1])) / 65536)
+
+	// Fill the background
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(img, img.Bounds(), image.NewUniform(identiconBackground), image.Point{}, draw.Src)
+
+	// Fill the cells of the left half (and their mirrors), one hash byte per cell
+	cell := max(size*4/5/identiconGrid, 1)
+	offset := (size - cell*identiconGrid) / 2
+	for row := range identiconGrid {
+		for column := range (identiconGrid + 1) / 2 {
+			if hash[2+row*3+column]&1 == 0 {
+				continue
+			}
+			for _, mirrored := range []int{column, identiconGrid - 1 - column} {
+				rect := image.Rect(offset+mirrored*cell, offset+row*cell, offset+(mirrored+1)*cell, offset+(row+1)*cell)
+				draw.Draw(img, rect, image.NewUniform(foreground), image.Point{}, draw.Src)
+			}
+		}
+	}
+	return img
+}
+
+// GenerateCard generates a PNG card with an identicon avatar and a sheet holding the name only
+func GenerateCard(name string, size int) ([]byte, error) {
+	// Serialize the sheet
+	sheet := character.DefaultSheet(character.RevisionV2)
+	sheet.Name = property.String(name)
+	sheetJSON, err := sheet.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	// Encode the avatar with the character data
+	encoded, err := encode(Identicon(name, size), FormatPNG, 0)
+	if err != nil {
+		return nil, err
+	}
+	return withChunks(encoded, []chunk{textChunk("chara", sheetJSON)})
+}
+
+// hueColor returns the saturated color of the hue (0 to 1, fixed saturation and lightness)
+func hueColor(hue float64) color.RGBA {
+	const saturation, lightness = 0.55, 0.55
+	chroma := (1 - math.Abs(2*lightness-1)) * saturation
+	sector := hue * 6
+	x := chroma * (1 - math.Abs(math.Mod(sector, 2)-1))
+	var r, g, b float64
+	switch int(sector) % 6 {
+	case 0:
+		r, g = chroma, x
+	case 1:
+		r, g = x, chroma
+	case 2:
+		g, b = chroma, x
+	case 3:
+		g, b = x, chroma
+	case 4:
+		r, b = x, chroma
+	default:
+		r, b = chroma, x
+	}
+	m := lightness - chroma/2
+	return color.RGBA{R: uint8((r + m) * 255), G: uint8((g + m) * 255), B: uint8((b + m) * 255), A: 0xff}
+}
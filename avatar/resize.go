@@ -0,0 +1,66 @@
+package avatar
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// fit returns the size of the image scaled down to fit the maximum size (aspect ratio kept, never scaled up)
+func fit(width, height, maxSize int) (int, int) {
+	if maxSize <= 0 || (width <= maxSize && height <= maxSize) {
+		return width, height
+	}
+	if width >= height {
+		return maxSize, max(1, (height*maxSize+width/2)/width)
+	}
+	return max(1, (width*maxSize+height/2)/height), maxSize
+}
+
+// resize scales the image down to fit the maximum size (each pixel averages the source area it covers)
+func resize(src image.Image, maxSize int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	newWidth, newHeight := fit(width, height, maxSize)
+	if newWidth == width && newHeight == height {
+		return src
+	}
+
+	// Convert the source to premultiplied RGBA (averaging premultiplied colors keeps transparent pixels from bleeding)
+	rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
+	for y := range newHeight {
+		y0, y1 := y*height/newHeight, max((y+1)*height/newHeight, y*height/newHeight+1)
+		for x := range newWidth {
+			x0, x1 := x*width/newWidth, max((x+1)*width/newWidth, x*width/newWidth+1)
+
+			// Average the covered source area
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride+x0*4 : sy*rgba.Stride+x1*4]
+				for index, value := range row {
+					sum[index%4] += int(value)
+				}
+			}
+			count := (y1 - y0) * (x1 - x0)
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8((sum[0] + count/2) / count),
+				G: uint8((sum[1] + count/2) / count),
+				B: uint8((sum[2] + count/2) / count),
+				A: uint8((sum[3] + count/2) / count),
+			})
+		}
+	}
+	return dst
+}
+
+// flatten draws the image over an opaque background (for formats without transparency)
+func flatten(src image.Image, background color.Color) image.Image {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Over)
+	return dst
+}
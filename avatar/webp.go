@@ -0,0 +1,257 @@
+package avatar
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+
+	"github.com/HugoSmits86/nativewebp"
+)
+
+// WebP errors
+var (
+	ErrNotWebP       = errors.New("avatar is not a WebP image")
+	ErrMalformedWebP = errors.New("avatar WebP is malformed")
+)
+
+// EXIF tags and types of the user comment holding the character data
+const (
+	tagExifIFD     = 0x8769
+	tagUserComment = 0x9286
+	typeLong       = 4
+	typeUndefined  = 7
+)
+
+// VP8X flags announcing the EXIF and XMP chunks
+const (
+	webpExifFlag = 1 << 3
+	webpXMPFlag  = 1 << 2
+)
+
+// ccv3Namespace is the XMP namespace of the V3 card JSON (stored base64 encoded, like the ccv3 PNG chunk)
+const ccv3Namespace = "https://github.com/kwaroran/character-card-spec-v3"
+
+// xmpPacket is the XMP packet holding the V3 card JSON
+const xmpPacket = `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+	`<rdf:Description rdf:about="" xmlns:ccv3="` + ccv3Namespace + `" ccv3:data="%s"/></rdf:RDF></x:xmpmeta>`
+
+// webpChunk is a RIFF chunk of the WebP file (size and padding are computed when written)
+type webpChunk struct {
+	kind string
+	data []byte
+}
+
+// encodeWebPCard encodes the image as an extended WebP file with the card JSONs (by keyword, "chara" for V2 and "ccv3" for V3)
+// The V2 card JSON goes in the EXIF user comment (the container the WebP cards of TavernAI use),
+// the V3 card JSON in the XMP packet (there is no WebP equivalent of the chara/ccv3 chunks)
+func encodeWebPCard(img image.Image, cardJSONs map[string][]byte) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := nativewebp.Encode(&buffer, img, &nativewebp.Options{UseExtendedFormat: true}); err != nil {
+		return nil, err
+	}
+	chunks, err := readWebPChunks(buffer.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	// Announce the EXIF and XMP chunks in the header, then append them after the image data (in this order)
+	if len(chunks) == 0 || chunks[0].kind != "VP8X" || len(chunks[0].data) < 1 {
+		return nil, ErrMalformedWebP
+	}
+	if cardJSON, ok := cardJSONs["chara"]; ok {
+		chunks[0].data[0] |= webpExifFlag
+		chunks = append(chunks, webpChunk{kind: "EXIF", data: exifUserComment(cardJSON)})
+	}
+	if cardJSON, ok := cardJSONs["ccv3"]; ok {
+		chunks[0].data[0] |= webpXMPFlag
+		chunks = append(chunks, webpChunk{kind: "XMP ", data: fmt.Appendf(nil, xmpPacket, base64.StdEncoding.EncodeToString(cardJSON))})
+	}
+	return writeWebPChunks(chunks), nil
+}
+
+// readWebPChunks splits the WebP file into chunks
+func readWebPChunks(data []byte) ([]webpChunk, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, ErrNotWebP
+	}
+
+	var chunks []webpChunk
+	for offset := 12; offset < len(data); {
+		// Type (4 bytes), size (4 bytes), data, padding to an even size
+		if len(data)-offset < 8 {
+			return nil, ErrMalformedWebP
+		}
+		size := int(binary.LittleEndian.Uint32(data[offset+4:]))
+		if len(data)-offset-8 < size {
+			return nil, ErrMalformedWebP
+		}
+		chunks = append(chunks, webpChunk{kind: string(data[offset : offset+4]), data: data[offset+8 : offset+8+size]})
+		offset += 8 + size + size%2
+	}
+	return chunks, nil
+}
+
+// writeWebPChunks writes the WebP file (with the RIFF header and the padding of odd sized chunks)
+func writeWebPChunks(chunks []webpChunk) []byte {
+	var body bytes.Buffer
+	body.WriteString("WEBP")
+	for _, c := range chunks {
+		body.WriteString(c.kind)
+		_ = binary.Write(&body, binary.LittleEndian, uint32(len(c.data)))
+		body.Write(c.data)
+		if len(c.data)%2 == 1 {
+			body.WriteByte(0)
+		}
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString("RIFF")
+	_ = binary.Write(&buffer, binary.LittleEndian, uint32(body.Len()))
+	buffer.Write(body.Bytes())
+	return buffer.Bytes()
+}
+
+// exifUserComment returns the EXIF data (little endian TIFF) holding the comment in the user comment of the Exif IFD
+func exifUserComment(comment []byte) []byte {
+	var buffer bytes.Buffer
+	order := binary.LittleEndian
+
+	// Header, IFD0 at offset 8
+	buffer.WriteString("II")
+	_ = binary.Write(&buffer, order, uint16(42))
+	_ = binary.Write(&buffer, order, uint32(8))
+
+	// IFD0 (pointer to the Exif IFD at offset 26)
+	_ = binary.Write(&buffer, order, uint16(1))
+	writeIFDEntry(&buffer, tagExifIFD, typeLong, 1, 26)
+	_ = binary.Write(&buffer, order, uint32(0))
+
+	// Exif IFD (user comment at offset 44, after the undefined character code)
+	_ = binary.Write(&buffer, order, uint16(1))
+	writeIFDEntry(&buffer, tagUserComment, typeUndefined, uint32(8+len(comment)), 44)
+	_ = binary.Write(&buffer, order, uint32(0))
+	buffer.Write(make([]byte, 8))
+	buffer.Write(comment)
+	return buffer.Bytes()
+}
+
+// writeIFDEntry writes the IFD entry (little endian)
+func writeIFDEntry(buffer *bytes.Buffer, tag, kind uint16, count, value uint32) {
+	buffer.Write(binary.LittleEndian.AppendUint16(nil, tag))
+	buffer.Write(binary.LittleEndian.AppendUint16(nil, kind))
+	buffer.Write(binary.LittleEndian.AppendUint32(nil, count))
+	buffer.Write(binary.LittleEndian.AppendUint32(nil, value))
+}
+
+// WebPCharacterData returns the card JSONs of the WebP card by keyword ("chara" from the EXIF user comment, "ccv3" from the XMP packet)
+func WebPCharacterData(data []byte) (map[string][]byte, error) {
+	chunks, err := readWebPChunks(data)
+	if err != nil {
+		return nil, err
+	}
+	cardJSONs := map[string][]byte{}
+	for _, c := range chunks {
+		var keyword string
+		var cardJSON []byte
+		switch c.kind {
+		case "EXIF":
+			keyword = "chara"
+			cardJSON, err = readUserComment(c.data)
+		case "XMP ":
+			keyword = "ccv3"
+			cardJSON, err = readXMPCardData(c.data)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(cardJSON) > 0 {
+			cardJSONs[keyword] = cardJSON
+		}
+	}
+	if len(cardJSONs) == 0 {
+		return nil, ErrNoCharacterData
+	}
+	return cardJSONs, nil
+}
+
+// readXMPCardData returns the V3 card JSON of the XMP packet (nil if missing)
+func readXMPCardData(packet []byte) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(packet))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrMalformedWebP, err)
+		}
+		element, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		for _, attribute := range element.Attr {
+			if attribute.Name.Space == ccv3Namespace && attribute.Name.Local == "data" {
+				cardJSON, err := base64.StdEncoding.DecodeString(attribute.Value)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %w", ErrMalformedWebP, err)
+				}
+				return cardJSON, nil
+			}
+		}
+	}
+}
+
+// readUserComment returns the user comment of the EXIF data (without its character code, nil if missing)
+func readUserComment(exif []byte) ([]byte, error) {
+	// Byte order of the TIFF header
+	var order binary.ByteOrder
+	switch {
+	case len(exif) < 8:
+		return nil, ErrMalformedWebP
+	case bytes.HasPrefix(exif, []byte("II*\x00")):
+		order = binary.LittleEndian
+	case bytes.HasPrefix(exif, []byte("MM\x00*")):
+		order = binary.BigEndian
+	default:
+		return nil, ErrMalformedWebP
+	}
+
+	// entry returns the count and the value/offset of the tag in the IFD at the offset
+	entry := func(offset uint32, tag uint16) (uint32, uint32, bool, error) {
+		if uint64(offset)+2 > uint64(len(exif)) {
+			return 0, 0, false, ErrMalformedWebP
+		}
+		count := int(order.Uint16(exif[offset:]))
+		if uint64(offset)+2+uint64(count)*12 > uint64(len(exif)) {
+			return 0, 0, false, ErrMalformedWebP
+		}
+		for index := range count {
+			field := exif[int(offset)+2+index*12:]
+			if order.Uint16(field) == tag {
+				return order.Uint32(field[4:]), order.Uint32(field[8:]), true, nil
+			}
+		}
+		return 0, 0, false, nil
+	}
+
+	// IFD0 points to the Exif IFD, which holds the user comment
+	_, exifIFD, found, err := entry(order.Uint32(exif[4:]), tagExifIFD)
+	if err != nil || !found {
+		return nil, err
+	}
+	count, offset, found, err := entry(exifIFD, tagUserComment)
+	if err != nil || !found {
+		return nil, err
+	}
+	if count < 8 || uint64(offset)+uint64(count) > uint64(len(exif)) {
+		return nil, ErrMalformedWebP
+	}
+	return exif[offset+8 : offset+count], nil
+}
@@ -0,0 +1,234 @@
+package export
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/r3dpixel/card-fetcher/asset"
+	"github.com/r3dpixel/card-fetcher/avatar"
+	"github.com/r3dpixel/card-fetcher/language"
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/card-parser/png"
+	"github.com/r3dpixel/toolkit/sonicx"
+	"github.com/r3dpixel/toolkit/stringsx"
+)
+
+// Version is the Character Card specification version of the encoded cards
+type Version string
+
+// Versions
+const (
+	// VersionKeep keeps the version of the sheet
+	VersionKeep Version = ""
+	// VersionV2 writes Character Card V2 cards (the chara chunk only for PNG cards)
+	VersionV2 Version = "v2"
+	// VersionV3 writes Character Card V3 cards (the ccv3 chunk, and the chara chunk as V2 fallback for PNG cards)
+	VersionV3 Version = "v3"
+)
+
+// Spec values of the card JSON
+const (
+	specV2        = "chara_card_v2"
+	specVersionV2 = "2.0"
+	specV3        = "chara_card_v3"
+	specVersionV3 = "3.0"
+)
+
+// MultilingualMinShare is the minimum share of the dominant language for the creator notes to be keyed by it
+const MultilingualMinShare = 0.75
+
+// ErrMalformedCard is returned when the card JSON has no data object
+var ErrMalformedCard = errors.New("card JSON has no data object")
+
+// DefaultIcon is the V3 asset of the main icon (the avatar of the PNG card)
+var DefaultIcon = Asset{Type: "icon", URI: "ccdefault:", Name: "main", Ext: "png"}
+
+// v3Keys are the keys of the card data only defined by the V3 specification (removed from the V2 cards)
+var v3Keys = []string{
+	"assets", "nickname", "creator_notes_multilingual", "source", "group_only_greetings", "creation_date", "modification_date",
+}
+
+// Options for encoding the character cards
+type Options struct {
+	// Version is the specification version of the encoded cards (VersionKeep keeps the version of the sheet)
+	Version Version
+	// Assets are the embedded assets listed in the V3 assets (optional, see asset.Embedder)
+	Assets *asset.Bundle
+	// Fields are the V3 fields exposed by the platform (optional, preferred to the fields derived from the sheet)
+	Fields *Fields
+}
+
+// Asset represents an entry of the V3 assets
+type Asset struct {
+	Type string `json:"type"`
+	URI  string `json:"uri"`
+	Name string `json:"name"`
+	Ext  string `json:"ext"`
+}
+
+// Fields holds the V3 fields not exposed by the character sheet
+type Fields struct {
+	// Source are the origin URLs of the card (direct link, then the parent of forks)
+	Source []string
+	// CreatorNotesMultilingual are the creator notes keyed by their language (ISO 639-1)
+	CreatorNotesMultilingual map[string]string
+	// Assets are the assets of the card (the main icon first)
+	Assets []Asset
+}
+
+// FieldsOf derives the V3 fields of the sheet (the fork parent is read from the embedded metadata)
+// The fields exposed by the platform are preferred when available (see Options.Fields)
+func FieldsOf(sheet *character.Sheet) Fields {
+	fields := Fields{Assets: []Asset{DefaultIcon}}
+
+	// Collect the origin URLs
+	fields.Source = appendURL(fields.Source, string(sheet.DirectLink))
+	if metadata, err := models.MetadataFromSheet(sheet); err == nil && metadata.Fork != nil {
+		fields.Source = appendURL(fields.Source, metadata.Fork.URL)
+	}
+
+	// Key the creator notes by their dominant language
+	if notes := string(sheet.CreatorNotes); stringsx.IsNotBlank(notes) {
+		if languages := language.Detect(notes); len(languages) > 0 && languages[0].Confidence >= MultilingualMinShare {
+			fields.CreatorNotesMultilingual = map[string]string{languages[0].Code: notes}
+		}
+	}
+
+	return fields
+}
+
+// Merge returns the fields completed with the derived fields (the origin URLs are joined, the other fields are kept if present)
+func (f *Fields) Merge(derived Fields) Fields {
+	if f == nil {
+		return derived
+	}
+	merged := Fields{CreatorNotesMultilingual: f.CreatorNotesMultilingual, Assets: f.Assets}
+	for _, url := range slices.Concat(f.Source, derived.Source) {
+		merged.Source = appendURL(merged.Source, url)
+	}
+	if len(merged.CreatorNotesMultilingual) == 0 {
+		merged.CreatorNotesMultilingual = derived.CreatorNotesMultilingual
+	}
+	if len(merged.Assets) == 0 {
+		merged.Assets = derived.Assets
+	}
+	return merged
+}
+
+// JSON encodes the sheet as card JSON in the version of the options
+func JSON(sheet *character.Sheet, options Options) ([]byte, error) {
+	cardJSON, err := sheet.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	return Convert(cardJSON, options.Fields.Merge(FieldsOf(sheet)), options)
+}
+
+// PNG encodes the character card as PNG card in the version of the options
+func PNG(characterCard *png.CharacterCard, options Options) ([]byte, error) {
+	rawCard, err := characterCard.Encode()
+	if err != nil {
+		return nil, err
+	}
+	data, err := rawCard.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	cardJSON, err := characterCard.Sheet.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+	return ConvertPNG(data, cardJSON, options.Fields.Merge(FieldsOf(characterCard.Sheet)), options)
+}
+
+// ConvertPNG replaces the character data of the PNG card with the card JSON in the version of the options
+func ConvertPNG(data []byte, cardJSON []byte, fields Fields, options Options) ([]byte, error) {
+	// The V2 data is always written (V3 cards keep it as fallback for V2 readers)
+	options.Version = resolveVersion(cardJSON, options.Version)
+	v2JSON, err := Convert(cardJSON, fields, Options{Version: VersionV2})
+	if err != nil {
+		return nil, err
+	}
+	cardJSONs := map[string][]byte{"chara": v2JSON}
+	if options.Version == VersionV3 {
+		if cardJSONs["ccv3"], err = Convert(cardJSON, fields, options); err != nil {
+			return nil, err
+		}
+	}
+	return avatar.ReplaceCharacterData(data, cardJSONs)
+}
+
+// Convert converts the card JSON to the version of the options (V3 cards get the V3 fields missing from the card JSON)
+func Convert(cardJSON []byte, fields Fields, options Options) ([]byte, error) {
+	// Decode the card
+	var card map[string]any
+	if err := sonicx.Config.Unmarshal(cardJSON, &card); err != nil {
+		return nil, err
+	}
+	data, ok := card["data"].(map[string]any)
+	if !ok {
+		return nil, ErrMalformedCard
+	}
+
+	// Write the spec of the version (V2 cards drop the V3 fields)
+	version := resolveVersion(cardJSON, options.Version)
+	if version == VersionV2 {
+		card["spec"], card["spec_version"] = specV2, specVersionV2
+		for _, key := range v3Keys {
+			delete(data, key)
+		}
+		return sonicx.Config.Marshal(card)
+	}
+	card["spec"], card["spec_version"] = specV3, specVersionV3
+
+	// Fill the V3 fields (the values of the card JSON are kept)
+	setMissing(data, "source", fields.Source, len(fields.Source) > 0)
+	setMissing(data, "creator_notes_multilingual", fields.CreatorNotesMultilingual, len(fields.CreatorNotesMultilingual) > 0)
+	setMissing(data, "group_only_greetings", []string{}, true)
+	setMissing(data, "assets", fields.Assets, len(fields.Assets) > 0)
+	cardJSON, err := sonicx.Config.Marshal(card)
+	if err != nil {
+		return nil, err
+	}
+
+	// List the embedded assets
+	return options.Assets.InjectAssets(cardJSON)
+}
+
+// resolveVersion returns the version of the card JSON for VersionKeep
+func resolveVersion(cardJSON []byte, version Version) Version {
+	if version != VersionKeep {
+		return version
+	}
+	var header struct {
+		Spec string `json:"spec"`
+	}
+	if err := sonicx.Config.Unmarshal(cardJSON, &header); err == nil && header.Spec == specV3 {
+		return VersionV3
+	}
+	return VersionV2
+}
+
+// setMissing sets the value of the key if the card JSON has none (null or absent)
+func setMissing(data map[string]any, key string, value any, present bool) {
+	if current, ok := data[key]; (!ok || current == nil) && present {
+		data[key] = value
+	}
+}
+
+// appendURL appends the URL (prefixed with https:// if it has no scheme) if not blank or already present
+func appendURL(urls []string, url string) []string {
+	url = strings.TrimSpace(url)
+	if url == "" {
+		return urls
+	}
+	if !strings.Contains(url, "://") {
+		url = "https://" + url
+	}
+	if slices.Contains(urls, url) {
+		return urls
+	}
+	return append(urls, url)
+}
@@ -0,0 +1,185 @@
+package export
+
+import (
+	"testing"
+
+	"github.com/r3dpixel/card-fetcher/asset"
+	"github.com/r3dpixel/card-fetcher/avatar"
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/card-parser/property"
+	"github.com/r3dpixel/toolkit/sonicx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// v2Card is a V2 card JSON as written by the character sheet
+const v2Card = `{"spec":"chara_card_v2","spec_version":"2.0","data":{"name":"Aria","creator_notes":"Notes","group_only_greetings":["Hello everyone"],"source":null}}`
+
+// decode decodes the card JSON
+func decode(t *testing.T, cardJSON []byte) map[string]any {
+	var card map[string]any
+	require.NoError(t, sonicx.Config.Unmarshal(cardJSON, &card))
+	return card
+}
+
+func TestFieldsOf(t *testing.T) {
+	t.Run("should collect the origin URLs and key the creator notes by language", func(t *testing.T) {
+		sheet := character.DefaultSheet(character.RevisionV3)
+		sheet.DirectLink = "chub.ai/characters/creator/card"
+		sheet.CreatorNotes = "A wandering knight who protects the travelers crossing the northern mountains, and tells stories by the fire."
+		metadata := &models.Metadata{CardInfo: models.CardInfo{
+			IsForked: true,
+			Fork:     &models.ForkInfo{URL: "chub.ai/characters/original/card"},
+		}}
+		require.NoError(t, metadata.EmbedIn(sheet))
+
+		fields := FieldsOf(sheet)
+
+		assert.Equal(t, []string{"https://chub.ai/characters/creator/card", "https://chub.ai/characters/original/card"}, fields.Source)
+		assert.Equal(t, map[string]string{"en": string(sheet.CreatorNotes)}, fields.CreatorNotesMultilingual)
+		assert.Equal(t, []Asset{DefaultIcon}, fields.Assets)
+	})
+
+	t.Run("should skip the missing data", func(t *testing.T) {
+		sheet := character.DefaultSheet(character.RevisionV3)
+		sheet.CreatorNotes = property.String("Hi")
+
+		fields := FieldsOf(sheet)
+
+		assert.Empty(t, fields.Source)
+		assert.Empty(t, fields.CreatorNotesMultilingual)
+		assert.Equal(t, []Asset{DefaultIcon}, fields.Assets)
+	})
+}
+
+func TestFields_Merge(t *testing.T) {
+	derived := Fields{
+		Source:                   []string{"https://chub.ai/characters/creator/card", "https://chub.ai/characters/original/card"},
+		CreatorNotesMultilingual: map[string]string{"en": "Notes"},
+		Assets:                   []Asset{DefaultIcon},
+	}
+
+	t.Run("should prefer the fields of the platform", func(t *testing.T) {
+		platform := &Fields{
+			Source:                   []string{"https://chub.ai/characters/creator/card"},
+			CreatorNotesMultilingual: map[string]string{"en": "Notes", "fr": "Notes en français"},
+		}
+
+		merged := platform.Merge(derived)
+
+		assert.Equal(t, derived.Source, merged.Source)
+		assert.Equal(t, platform.CreatorNotesMultilingual, merged.CreatorNotesMultilingual)
+		assert.Equal(t, derived.Assets, merged.Assets)
+		assert.Len(t, platform.Source, 1, "the platform fields should not be modified")
+	})
+
+	t.Run("should use the derived fields without platform fields", func(t *testing.T) {
+		var platform *Fields
+
+		assert.Equal(t, derived, platform.Merge(derived))
+	})
+}
+
+func TestConvert(t *testing.T) {
+	fields := Fields{
+		Source:                   []string{"https://chub.ai/characters/creator/card"},
+		CreatorNotesMultilingual: map[string]string{"en": "Notes"},
+		Assets:                   []Asset{DefaultIcon},
+	}
+
+	t.Run("should keep the version of the card", func(t *testing.T) {
+		cardJSON, err := Convert([]byte(v2Card), fields, Options{})
+		require.NoError(t, err)
+
+		card := decode(t, cardJSON)
+		assert.Equal(t, "chara_card_v2", card["spec"])
+		assert.NotContains(t, card["data"], "source")
+		assert.NotContains(t, card["data"], "group_only_greetings")
+		assert.NotContains(t, card["data"], "assets")
+		assert.Equal(t, "Notes", card["data"].(map[string]any)["creator_notes"])
+	})
+
+	t.Run("should fill the V3 fields", func(t *testing.T) {
+		cardJSON, err := Convert([]byte(v2Card), fields, Options{Version: VersionV3})
+		require.NoError(t, err)
+
+		card := decode(t, cardJSON)
+		data := card["data"].(map[string]any)
+		assert.Equal(t, "chara_card_v3", card["spec"])
+		assert.Equal(t, "3.0", card["spec_version"])
+		assert.Equal(t, []any{"https://chub.ai/characters/creator/card"}, data["source"])
+		assert.Equal(t, map[string]any{"en": "Notes"}, data["creator_notes_multilingual"])
+		assert.Equal(t, []any{"Hello everyone"}, data["group_only_greetings"])
+		assert.Equal(t, []any{map[string]any{"type": "icon", "uri": "ccdefault:", "name": "main", "ext": "png"}}, data["assets"])
+		assert.Equal(t, "Aria", data["name"])
+	})
+
+	t.Run("should keep the V3 fields of the card", func(t *testing.T) {
+		v3Card := `{"spec":"chara_card_v3","spec_version":"3.0","data":{"name":"Aria","source":["https://example.com/aria"]}}`
+
+		cardJSON, err := Convert([]byte(v3Card), fields, Options{})
+		require.NoError(t, err)
+
+		data := decode(t, cardJSON)["data"].(map[string]any)
+		assert.Equal(t, []any{"https://example.com/aria"}, data["source"])
+		assert.Equal(t, []any{}, data["group_only_greetings"])
+	})
+
+	t.Run("should list the embedded assets", func(t *testing.T) {
+		bundle := &asset.Bundle{Assets: []asset.Asset{{Name: "a", Ext: "png", URI: "embeded://assets/other/image/a.png"}}}
+
+		cardJSON, err := Convert([]byte(v2Card), fields, Options{Version: VersionV3, Assets: bundle})
+		require.NoError(t, err)
+
+		assets := decode(t, cardJSON)["data"].(map[string]any)["assets"].([]any)
+		require.Len(t, assets, 2)
+		assert.Equal(t, "embeded://assets/other/image/a.png", assets[1].(map[string]any)["uri"])
+	})
+
+	t.Run("should write V2 cards without the V3 fields", func(t *testing.T) {
+		v3Card := `{"spec":"chara_card_v3","spec_version":"3.0","data":{"name":"Aria","nickname":"Ari","source":["https://example.com/aria"],` +
+			`"creator_notes_multilingual":{"en":"Notes"},"group_only_greetings":[],"assets":[],"creation_date":1,"modification_date":2,"extensions":{}}}`
+
+		cardJSON, err := Convert([]byte(v3Card), fields, Options{Version: VersionV2})
+		require.NoError(t, err)
+
+		card := decode(t, cardJSON)
+		assert.Equal(t, "chara_card_v2", card["spec"])
+		assert.Equal(t, "2.0", card["spec_version"])
+		assert.Equal(t, map[string]any{"name": "Aria", "extensions": map[string]any{}}, card["data"])
+	})
+
+	t.Run("should reject malformed cards", func(t *testing.T) {
+		_, err := Convert([]byte(`{"spec":"chara_card_v2"}`), fields, Options{})
+		assert.ErrorIs(t, err, ErrMalformedCard)
+	})
+}
+
+func TestConvertPNG(t *testing.T) {
+	data, err := avatar.GenerateCard("Aria", 16)
+	require.NoError(t, err)
+	fields := Fields{Assets: []Asset{DefaultIcon}}
+
+	t.Run("should write the V2 chunk only", func(t *testing.T) {
+		converted, err := ConvertPNG(data, []byte(v2Card), fields, Options{Version: VersionV2})
+		require.NoError(t, err)
+
+		cardJSONs, err := avatar.CharacterData(converted)
+		require.NoError(t, err)
+		require.Len(t, cardJSONs, 1)
+		assert.Equal(t, "chara_card_v2", decode(t, cardJSONs["chara"])["spec"])
+	})
+
+	t.Run("should write the V3 chunk and the V2 fallback", func(t *testing.T) {
+		converted, err := ConvertPNG(data, []byte(v2Card), fields, Options{Version: VersionV3})
+		require.NoError(t, err)
+
+		cardJSONs, err := avatar.CharacterData(converted)
+		require.NoError(t, err)
+		require.Len(t, cardJSONs, 2)
+		assert.Equal(t, "chara_card_v2", decode(t, cardJSONs["chara"])["spec"])
+		assert.Equal(t, "chara_card_v3", decode(t, cardJSONs["ccv3"])["spec"])
+		assert.Contains(t, decode(t, cardJSONs["ccv3"])["data"], "assets")
+	})
+}
@@ -0,0 +1,87 @@
+package fetcher
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/r3dpixel/card-fetcher/avatar"
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-parser/png"
+	"github.com/r3dpixel/toolkit/stringsx"
+)
+
+// DefaultAvatarSize is the size of the generated and placeholder avatars
+const DefaultAvatarSize = 512
+
+// errNoAvatarURL is returned when an avatar URL of the chain is blank
+var errNoAvatarURL = errors.New("no avatar URL")
+
+// AvatarChain describes the avatar of a card and its fallbacks (alternate URLs, generated avatar, then placeholder)
+type AvatarChain struct {
+	// URLs are the avatar URLs in order of preference (the first one is the primary avatar, duplicates are skipped)
+	URLs []string
+	// Name is the name of the character (seeds the generated avatar)
+	Name string
+	// Longest selects the longest card version embedded in the avatars (the last version otherwise)
+	Longest bool
+	// Required disables the generated and placeholder avatars (for sources whose card data is only in the avatar)
+	Required bool
+	// Size is the size of the generated and placeholder avatars (0 uses DefaultAvatarSize)
+	Size int
+}
+
+// Fetch walks the chain until an avatar is obtained (get downloads the card of a URL)
+// Returns the card and the fallback that provided it, or the errors of every attempt
+func (c *AvatarChain) Fetch(get func(url string) (*png.RawCard, error)) (*png.RawCard, models.AvatarFallback, error) {
+	var errs []error
+
+	// Try the URLs in order of preference
+	var tried []string
+	for index, url := range c.URLs {
+		if stringsx.IsBlank(url) {
+			errs = append(errs, errNoAvatarURL)
+			continue
+		}
+		if slices.Contains(tried, url) {
+			continue
+		}
+		tried = append(tried, url)
+		rawCard, err := get(url)
+		if err == nil {
+			if index == 0 {
+				return rawCard, models.AvatarFallbackNone, nil
+			}
+			return rawCard, models.AvatarFallbackAlternate, nil
+		}
+		errs = append(errs, err)
+	}
+	if len(c.URLs) == 0 {
+		errs = append(errs, errNoAvatarURL)
+	}
+
+	// The card data is only in the avatar (a synthetic avatar would lose it)
+	if c.Required {
+		return nil, models.AvatarFallbackNone, errors.Join(errs...)
+	}
+
+	// Generate a deterministic avatar from the name
+	size := c.Size
+	if size <= 0 {
+		size = DefaultAvatarSize
+	}
+	data, err := avatar.GenerateCard(c.Name, size)
+	if err == nil {
+		var rawCard *png.RawCard
+		if rawCard, err = png.FromBytes(data).LastVersion().Get(); err == nil {
+			return rawCard, models.AvatarFallbackGenerated, nil
+		}
+	}
+	errs = append(errs, err)
+
+	// Use the placeholder
+	rawCard, err := png.PlaceholderCharacterCard(size)
+	if err == nil {
+		return rawCard, models.AvatarFallbackPlaceholder, nil
+	}
+	return nil, models.AvatarFallbackNone, errors.Join(append(errs, err)...)
+}
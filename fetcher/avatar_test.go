@@ -0,0 +1,82 @@
+package fetcher
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-parser/png"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAvatarChain_Fetch(t *testing.T) {
+	primary, alternate := &png.RawCard{}, &png.RawCard{}
+	errDownload := errors.New("download failed")
+
+	// get serves the cards by URL (unknown URLs fail), and records the requested URLs
+	newGet := func(cards map[string]*png.RawCard, requested *[]string) func(url string) (*png.RawCard, error) {
+		return func(url string) (*png.RawCard, error) {
+			*requested = append(*requested, url)
+			if card, ok := cards[url]; ok {
+				return card, nil
+			}
+			return nil, errDownload
+		}
+	}
+
+	t.Run("should fetch the primary avatar", func(t *testing.T) {
+		var requested []string
+		chain := AvatarChain{URLs: []string{"https://a/primary.png", "https://a/alternate.png"}}
+
+		rawCard, fallback, err := chain.Fetch(newGet(map[string]*png.RawCard{"https://a/primary.png": primary}, &requested))
+
+		assert.NoError(t, err)
+		assert.Same(t, primary, rawCard)
+		assert.Equal(t, models.AvatarFallbackNone, fallback)
+		assert.Equal(t, []string{"https://a/primary.png"}, requested)
+	})
+
+	t.Run("should fall back to the alternate URLs", func(t *testing.T) {
+		var requested []string
+		chain := AvatarChain{URLs: []string{"https://a/primary.png", "https://a/primary.png", "", "https://a/alternate.png"}}
+
+		rawCard, fallback, err := chain.Fetch(newGet(map[string]*png.RawCard{"https://a/alternate.png": alternate}, &requested))
+
+		assert.NoError(t, err)
+		assert.Same(t, alternate, rawCard)
+		assert.Equal(t, models.AvatarFallbackAlternate, fallback)
+		assert.Equal(t, []string{"https://a/primary.png", "https://a/alternate.png"}, requested)
+	})
+
+	t.Run("should generate an avatar when every URL fails", func(t *testing.T) {
+		var requested []string
+		chain := AvatarChain{URLs: []string{"https://a/primary.png"}, Name: "Aria", Size: 64}
+
+		_, fallback, err := chain.Fetch(newGet(nil, &requested))
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.AvatarFallbackGenerated, fallback)
+		assert.True(t, fallback.IsSynthetic())
+	})
+
+	t.Run("should fail when the avatar is required", func(t *testing.T) {
+		var requested []string
+		chain := AvatarChain{URLs: []string{"https://a/primary.png", "https://a/alternate.png"}, Required: true}
+
+		rawCard, _, err := chain.Fetch(newGet(nil, &requested))
+
+		assert.ErrorIs(t, err, errDownload)
+		assert.Nil(t, rawCard)
+		assert.Len(t, requested, 2)
+	})
+
+	t.Run("should fail without URLs when the avatar is required", func(t *testing.T) {
+		var requested []string
+		chain := AvatarChain{Required: true}
+
+		_, _, err := chain.Fetch(newGet(nil, &requested))
+
+		assert.ErrorIs(t, err, errNoAvatarURL)
+		assert.Empty(t, requested)
+	})
+}
@@ -2,6 +2,8 @@ package fetcher
 
 import (
 	"github.com/PuerkitoBio/goquery"
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-parser/character"
 	"github.com/r3dpixel/toolkit/timestamp"
 )
 
@@ -9,6 +11,19 @@ import (
 type Binder struct {
 	MetadataBinder
 	BookBinder
+	// Provenance records the origin of the sheet fields merged by the fetcher (nil if the fetcher does not merge fields)
+	Provenance *models.Provenance
+	// BookStrategy is the strategy for merging the books of the card (see BookMerger)
+	BookStrategy BookStrategy
+}
+
+// TraceMerge records the origin of the sheet fields after the API fields were merged into the PNG sheet
+// (pngValues are the field values of the PNG sheet, taken with models.SheetFieldValues before the merge)
+func (b *Binder) TraceMerge(pngValues map[models.SheetField]string, sheet *character.Sheet) {
+	if b.Provenance == nil {
+		b.Provenance = models.NewProvenance()
+	}
+	b.Provenance.TraceMerge(pngValues, sheet)
 }
 
 // MetadataBinder is a container for all the metadata fetched from a single source
@@ -24,6 +39,8 @@ type MetadataBinder struct {
 type BookBinder struct {
 	Responses  []JsonResponse
 	UpdateTime timestamp.Nano
+	// Books describes the books of the card (embedded and linked, see models.BookInfo)
+	Books []models.BookInfo
 }
 
 // EmptyBinder singleton
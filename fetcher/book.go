@@ -0,0 +1,183 @@
+package fetcher
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/toolkit/stringsx"
+	"github.com/r3dpixel/toolkit/timestamp"
+)
+
+// BookStrategy is the strategy for merging the books of a card into its character book
+type BookStrategy string
+
+// Book strategies
+const (
+	// BookStrategyMerge merges every book into the character book (default)
+	BookStrategyMerge BookStrategy = ""
+	// BookStrategySeparate keeps the linked books as separate World Info books (see Card.WorldBooks)
+	BookStrategySeparate BookStrategy = "separate"
+	// BookStrategyDeduplicate merges every book, dropping the entries with the same normalized keys and content
+	BookStrategyDeduplicate BookStrategy = "deduplicate"
+)
+
+// BookOrigin is the origin of a book of a card (see models.BookOrigin)
+type BookOrigin = models.BookOrigin
+
+// Book origins
+const (
+	BookOriginEmbedded  = models.BookOriginEmbedded
+	BookOriginLinked    = models.BookOriginLinked
+	BookOriginAuxiliary = models.BookOriginAuxiliary
+)
+
+// EntryOriginKey is the key of the entry origin inside the raw extensions of the book entries
+// (dedicated to the origin, the extensions of the platforms and of other tools are left untouched)
+const EntryOriginKey = models.ExtensionKey + "_origin"
+
+// BookSource identifies a book of a card
+type BookSource struct {
+	Origin BookOrigin
+	// ID is the platform ID of the book (empty for embedded books)
+	ID string
+	// Name is the name of the book on the platform (the name of the book is used if blank)
+	Name string
+}
+
+// BookMerger merges the books of a card with the strategy of the binder, recording the origin of every entry
+type BookMerger struct {
+	binder *Binder
+	parts  []bookPart
+}
+
+// bookPart is a book or a loose entry appended to the merger
+type bookPart struct {
+	source BookSource
+	book   *character.Book
+	entry  *character.BookEntry
+}
+
+// NewBookMerger creates a book merger with the strategy of the binder
+func (b *Binder) NewBookMerger() *BookMerger {
+	return &BookMerger{binder: b}
+}
+
+// AppendBook appends a book (nil books are skipped)
+func (m *BookMerger) AppendBook(book *character.Book, source BookSource) {
+	if book == nil {
+		return
+	}
+	if stringsx.IsBlank(source.Name) {
+		source.Name = string(book.Name)
+	}
+	for _, entry := range book.Entries {
+		recordOrigin(entry, source)
+	}
+	m.parts = append(m.parts, bookPart{source: source, book: book})
+}
+
+// AppendEntry appends a loose entry (nil entries are skipped)
+func (m *BookMerger) AppendEntry(entry *character.BookEntry, source BookSource) {
+	if entry == nil {
+		return
+	}
+	recordOrigin(entry, source)
+	m.parts = append(m.parts, bookPart{source: source, entry: entry})
+}
+
+// Build builds the character book, and returns the books kept separate (BookStrategySeparate only)
+func (m *BookMerger) Build() (*character.Book, []*character.Book) {
+	strategy := m.binder.BookStrategy
+	merger := character.NewBookMerger()
+	seen := map[string]struct{}{}
+	var worldBooks []*character.Book
+
+	for _, part := range m.parts {
+		// Merge the loose entries
+		if part.entry != nil {
+			if strategy == BookStrategyDeduplicate && !markEntry(seen, part.entry) {
+				continue
+			}
+			merger.AppendEntry(part.entry)
+			continue
+		}
+
+		// Keep the shared books separate
+		if strategy == BookStrategySeparate && part.source.Origin != BookOriginEmbedded {
+			worldBooks = append(worldBooks, part.book)
+			continue
+		}
+
+		// Drop the entries already merged (books left without entries are skipped, they were merged already)
+		if strategy == BookStrategyDeduplicate && len(part.book.Entries) > 0 {
+			part.book.Entries = slices.DeleteFunc(part.book.Entries, func(entry *character.BookEntry) bool {
+				return !markEntry(seen, entry)
+			})
+			if len(part.book.Entries) == 0 {
+				continue
+			}
+		}
+		merger.AppendBook(part.book)
+	}
+
+	// Return the character book and the separate books
+	return merger.Build(), worldBooks
+}
+
+// BookUpdateTime returns the latest update time of the books merged into the character book
+// (the shared books kept separate by BookStrategySeparate are skipped, Metadata.Books keeps the time of each book)
+func (b *Binder) BookUpdateTime() timestamp.Nano {
+	if b.BookStrategy != BookStrategySeparate {
+		return b.UpdateTime
+	}
+	var updateTime timestamp.Nano
+	for _, book := range b.Books {
+		if !book.IsShared() {
+			updateTime = max(updateTime, book.UpdateTime)
+		}
+	}
+	return updateTime
+}
+
+// recordOrigin records the book of the entry in its raw extensions (under EntryOriginKey, the other extensions are kept)
+func recordOrigin(entry *character.BookEntry, source BookSource) {
+	if entry == nil {
+		return
+	}
+	if entry.RawExtensions == nil {
+		entry.RawExtensions = make(map[string]any)
+	}
+	origin := map[string]any{"book_origin": string(source.Origin)}
+	if stringsx.IsNotBlank(source.ID) {
+		origin["book_id"] = source.ID
+	}
+	if stringsx.IsNotBlank(source.Name) {
+		origin["book_name"] = source.Name
+	}
+	entry.RawExtensions[EntryOriginKey] = origin
+}
+
+// markEntry marks the entry as seen, returns false if an entry with the same normalized keys and content was seen
+func markEntry(seen map[string]struct{}, entry *character.BookEntry) bool {
+	key := entryKey(entry)
+	if _, ok := seen[key]; ok {
+		return false
+	}
+	seen[key] = struct{}{}
+	return true
+}
+
+// entryKey returns the normalized keys and content of the entry (keys lowercased, sorted and deduplicated, whitespace collapsed)
+func entryKey(entry *character.BookEntry) string {
+	var keys []string
+	for _, key := range entry.Keys {
+		if key = strings.ToLower(strings.TrimSpace(key)); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+	return strings.Join(keys, "\x1f") + "\x00" + strings.Join(strings.Fields(string(entry.Content)), " ")
+}
@@ -0,0 +1,111 @@
+package fetcher
+
+import (
+	"testing"
+
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/card-parser/property"
+	"github.com/stretchr/testify/assert"
+)
+
+// bookOf creates a book with an entry per content (keyed by the content)
+func bookOf(name string, contents ...string) *character.Book {
+	book := character.DefaultBook()
+	book.Name = property.String(name)
+	for _, content := range contents {
+		entry := character.DefaultBookEntry()
+		entry.Keys = property.StringArray{content}
+		entry.Content = property.String(content)
+		book.Entries = append(book.Entries, entry)
+	}
+	return book
+}
+
+// contentsOf returns the contents of the book entries
+func contentsOf(book *character.Book) []string {
+	var contents []string
+	for _, entry := range book.Entries {
+		contents = append(contents, string(entry.Content))
+	}
+	return contents
+}
+
+func TestBookMerger(t *testing.T) {
+	t.Run("should record the origin of the entries", func(t *testing.T) {
+		embedded, linked := bookOf("Embedded", "castle"), bookOf("World", "kingdom")
+		merger := (&Binder{}).NewBookMerger()
+
+		merger.AppendBook(embedded, BookSource{Origin: BookOriginEmbedded})
+		merger.AppendBook(linked, BookSource{Origin: BookOriginLinked, ID: "42"})
+		merger.AppendEntry(nil, BookSource{Origin: BookOriginEmbedded})
+		merger.AppendBook(nil, BookSource{Origin: BookOriginLinked})
+
+		assert.Equal(t, map[string]any{"book_origin": "embedded", "book_name": "Embedded"}, embedded.Entries[0].RawExtensions[EntryOriginKey])
+		assert.Equal(t, map[string]any{"book_origin": "linked", "book_id": "42", "book_name": "World"}, linked.Entries[0].RawExtensions[EntryOriginKey])
+		assert.Len(t, merger.parts, 2)
+	})
+
+	t.Run("should keep the other extensions of the entries", func(t *testing.T) {
+		book := bookOf("Embedded", "castle")
+		book.Entries[0].RawExtensions = map[string]any{models.ExtensionKey: "tool data", "depth": 4}
+
+		(&Binder{}).NewBookMerger().AppendBook(book, BookSource{Origin: BookOriginEmbedded})
+
+		assert.Equal(t, "tool data", book.Entries[0].RawExtensions[models.ExtensionKey])
+		assert.Equal(t, 4, book.Entries[0].RawExtensions["depth"])
+		assert.Contains(t, book.Entries[0].RawExtensions, EntryOriginKey)
+	})
+
+	t.Run("should merge every book by default", func(t *testing.T) {
+		merger := (&Binder{}).NewBookMerger()
+		merger.AppendBook(bookOf("Embedded", "castle"), BookSource{Origin: BookOriginEmbedded})
+		merger.AppendBook(bookOf("World", "castle"), BookSource{Origin: BookOriginLinked})
+
+		_, worldBooks := merger.Build()
+
+		assert.Empty(t, worldBooks)
+	})
+
+	t.Run("should keep the linked books separate", func(t *testing.T) {
+		linked, auxiliary := bookOf("World", "kingdom"), bookOf("Extra", "dragon")
+		merger := (&Binder{BookStrategy: BookStrategySeparate}).NewBookMerger()
+		merger.AppendBook(bookOf("Embedded", "castle"), BookSource{Origin: BookOriginEmbedded})
+		merger.AppendBook(linked, BookSource{Origin: BookOriginLinked})
+		merger.AppendBook(auxiliary, BookSource{Origin: BookOriginAuxiliary})
+
+		_, worldBooks := merger.Build()
+
+		assert.Equal(t, []*character.Book{linked, auxiliary}, worldBooks)
+	})
+
+	t.Run("should drop the duplicate entries", func(t *testing.T) {
+		embedded := bookOf("Embedded", "castle", "moat")
+		linked := bookOf("World", "  castle ", "kingdom")
+		duplicate := bookOf("World", "castle", "kingdom")
+		linked.Entries[0].Keys = property.StringArray{"CASTLE", "castle", ""}
+		merger := (&Binder{BookStrategy: BookStrategyDeduplicate}).NewBookMerger()
+		merger.AppendBook(embedded, BookSource{Origin: BookOriginEmbedded})
+		merger.AppendBook(linked, BookSource{Origin: BookOriginLinked})
+		merger.AppendBook(duplicate, BookSource{Origin: BookOriginAuxiliary})
+
+		_, worldBooks := merger.Build()
+
+		assert.Equal(t, []string{"castle", "moat"}, contentsOf(embedded))
+		assert.Equal(t, []string{"kingdom"}, contentsOf(linked))
+		assert.Empty(t, contentsOf(duplicate))
+		assert.Empty(t, worldBooks)
+	})
+
+	t.Run("should keep the entries with other keys", func(t *testing.T) {
+		embedded, linked := bookOf("Embedded", "castle"), bookOf("World", "castle")
+		linked.Entries[0].Keys = property.StringArray{"fortress"}
+		merger := (&Binder{BookStrategy: BookStrategyDeduplicate}).NewBookMerger()
+		merger.AppendBook(embedded, BookSource{Origin: BookOriginEmbedded})
+		merger.AppendBook(linked, BookSource{Origin: BookOriginLinked})
+
+		merger.Build()
+
+		assert.Equal(t, []string{"castle"}, contentsOf(linked))
+	})
+}
@@ -24,6 +24,9 @@ const (
 	FetchAvatarErr
 	DecodeErr
 	MissingCookieProviderErr
+	RemovedErr
+	BlockedErr
+	RatingErr
 	None
 	errCodeSize
 )
@@ -40,6 +43,9 @@ var errMessages = [errCodeSize]string{
 	FetchAvatarErr:           "failed to fetch avatar",
 	DecodeErr:                "failed to decode card png",
 	MissingCookieProviderErr: "missing cookie provider",
+	RemovedErr:               "card removed from source",
+	BlockedErr:               "card blocked by tag policy",
+	RatingErr:                "card rejected by rating filter",
 	None:                     "",
 }
 
@@ -4,6 +4,7 @@ import (
 	"github.com/imroc/req/v3"
 	"github.com/r3dpixel/card-fetcher/models"
 	"github.com/r3dpixel/card-fetcher/source"
+	"github.com/r3dpixel/card-parser/character"
 	"github.com/r3dpixel/card-parser/png"
 	"github.com/r3dpixel/toolkit/reqx"
 	"github.com/r3dpixel/toolkit/sonicx"
@@ -23,6 +24,19 @@ type Builder interface {
 // JsonResponse type alias for *sonicx.Wrap
 type JsonResponse = *sonicx.Wrap
 
+// Card is a character card fetched from a source, with the details of the fetch
+type Card struct {
+	*png.CharacterCard
+	// AvatarFallback records the fallback that provided the avatar (see AvatarChain)
+	AvatarFallback models.AvatarFallback
+	// Source are the origin URLs of the card exposed by the platform (V3 source)
+	Source []string
+	// CreatorNotesMultilingual are the creator notes keyed by language exposed by the platform (V3 creator_notes_multilingual, optional)
+	CreatorNotesMultilingual map[string]string
+	// WorldBooks are the linked books kept out of the character book (BookStrategySeparate only)
+	WorldBooks []*character.Book
+}
+
 // Fetcher interface for all fetchers
 type Fetcher interface {
 	// Extends allows a fetcher to extend another fetcher
@@ -45,16 +59,20 @@ type Fetcher interface {
 
 	// FetchMetadataResponse fetches the metadata response from the source for the given characterID
 	FetchMetadataResponse(characterID string) (*req.Response, error)
+	// IsRemoved checks if the metadata response confirms that the card was removed from the source
+	IsRemoved(response *req.Response) bool
 	// CreateBinder creates a MetadataBinder from the metadata response
 	CreateBinder(characterID string, response string) (*MetadataBinder, error)
 	// FetchCardInfo fetches the card info from the source
 	FetchCardInfo(metadataBinder *MetadataBinder) (*models.CardInfo, error)
 	// FetchCreatorInfo fetches the creator info from the source
 	FetchCreatorInfo(metadataBinder *MetadataBinder) (*models.CreatorInfo, error)
+	// FetchStats extracts the engagement statistics from the metadata (empty if the platform provides none)
+	FetchStats(metadataBinder *MetadataBinder) (*models.Stats, error)
 	// FetchBookResponses fetches the book responses from the source
 	FetchBookResponses(metadataBinder *MetadataBinder) (*BookBinder, error)
 	// FetchCharacterCard fetches the character card from the source
-	FetchCharacterCard(binder *Binder) (*png.CharacterCard, error)
+	FetchCharacterCard(binder *Binder) (*Card, error)
 	// Close closes the fetcher
 	Close()
 
@@ -0,0 +1,38 @@
+package fetcher
+
+import (
+	"github.com/r3dpixel/card-fetcher/markdown"
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-fetcher/source"
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/card-parser/property"
+)
+
+// HTMLConversion converts the platform HTML of the taglines and creator notes into Markdown or plain text
+type HTMLConversion struct {
+	// Default is the format used for the sources without a setting (markdown.FormatKeep keeps the HTML)
+	Default markdown.Format
+	// Sources overrides the format per source
+	Sources map[source.ID]markdown.Format
+}
+
+// Format returns the format used for the source (a nil conversion keeps the HTML)
+func (c *HTMLConversion) Format(sourceID source.ID) markdown.Format {
+	if c == nil {
+		return markdown.FormatKeep
+	}
+	if format, ok := c.Sources[sourceID]; ok {
+		return format
+	}
+	return c.Default
+}
+
+// ConvertMetadata converts the HTML of the tagline
+func (c *HTMLConversion) ConvertMetadata(metadata *models.Metadata) {
+	metadata.Tagline = markdown.Convert(metadata.Tagline, c.Format(metadata.Source))
+}
+
+// ConvertSheet converts the HTML of the creator notes (the source is the source of the card)
+func (c *HTMLConversion) ConvertSheet(sheet *character.Sheet, sourceID source.ID) {
+	sheet.CreatorNotes = property.String(markdown.Convert(string(sheet.CreatorNotes), c.Format(sourceID)))
+}
@@ -0,0 +1,47 @@
+package fetcher
+
+import (
+	"testing"
+
+	"github.com/r3dpixel/card-fetcher/markdown"
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-fetcher/source"
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/card-parser/property"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTMLConversion(t *testing.T) {
+	conversion := &HTMLConversion{
+		Default: markdown.FormatMarkdown,
+		Sources: map[source.ID]markdown.Format{source.AICC: markdown.FormatText, source.WyvernChat: markdown.FormatKeep},
+	}
+
+	t.Run("should select the format of the source", func(t *testing.T) {
+		assert.Equal(t, markdown.FormatMarkdown, conversion.Format(source.ChubAI))
+		assert.Equal(t, markdown.FormatText, conversion.Format(source.AICC))
+		assert.Equal(t, markdown.FormatKeep, conversion.Format(source.WyvernChat))
+	})
+
+	t.Run("should keep the HTML without a conversion", func(t *testing.T) {
+		var conversion *HTMLConversion
+		metadata := &models.Metadata{Source: source.ChubAI, CardInfo: models.CardInfo{Tagline: "<b>Tagline</b>"}}
+		conversion.ConvertMetadata(metadata)
+
+		assert.Equal(t, "<b>Tagline</b>", metadata.Tagline)
+	})
+
+	t.Run("should convert the tagline", func(t *testing.T) {
+		metadata := &models.Metadata{Source: source.AICC, CardInfo: models.CardInfo{Tagline: "<b>Tagline</b> &amp; more"}}
+		conversion.ConvertMetadata(metadata)
+
+		assert.Equal(t, "Tagline & more", metadata.Tagline)
+	})
+
+	t.Run("should convert the creator notes", func(t *testing.T) {
+		sheet := &character.Sheet{Content: character.Content{CreatorNotes: `<p>Notes</p><script>x()</script><a href="https://a.com">Link</a>`}}
+		conversion.ConvertSheet(sheet, source.ChubAI)
+
+		assert.Equal(t, property.String("Notes\n\n[Link](https://a.com)"), sheet.CreatorNotes)
+	})
+}
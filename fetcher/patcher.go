@@ -38,33 +38,12 @@ func PatchMetadata(metadata *models.Metadata) {
 
 // PatchSheet ensures that the sheet is consistent with the metadata
 func PatchSheet(sheet *character.Sheet, metadata *models.Metadata) {
-	// Patch name and title
-	patchNameAndTitle(sheet, metadata)
-	// Patch creator notes
-	patchCreatorNotes(sheet, metadata)
-	// Patch tags
-	patchTags(sheet, metadata)
-	// Patch timestamps
-	patchTimestamps(sheet, metadata)
-	// Patch book name
-	patchBookName(sheet.CharacterBook, metadata.Name)
-	// Patch meta fields
-	patchMetaFields(sheet, metadata)
-
-	// Set greetings count
-	metadata.GreetingsCount = len(sheet.AlternateGreetings)
-
-	// Set has book flag
-	metadata.HasBook = sheet.CharacterBook != nil
-
-	// Set the creator of the sheet to the nickname
-	sheet.Creator = property.String(metadata.Nickname)
-
-	// Fix user templates
-	sheet.FixUserCharTemplates()
+	PatchSheetWithPolicy(sheet, metadata, nil)
+}
 
-	// Normalize symbols in sheet
-	sheet.NormalizeSymbols()
+// PatchSheetWithPolicy ensures that the sheet is consistent with the metadata, filtering the tags with the policy (nil keeps all tags)
+func PatchSheetWithPolicy(sheet *character.Sheet, metadata *models.Metadata, policy *TagPolicy) {
+	DefaultPipeline(PipelineOptions{TagPolicy: policy}).Patch(sheet, metadata)
 }
 
 // patchNameAndTitle ensures that the name and title fields are consistent with the metadata
@@ -100,45 +79,99 @@ func patchNameAndTitle(sheet *character.Sheet, metadata *models.Metadata) {
 }
 
 // patchCreatorNotes ensures that the creator notes field is consistent with the metadata
+// The tagline added by an earlier patch (current or embedded in the sheet) is removed first, so re-patching is idempotent
 func patchCreatorNotes(sheet *character.Sheet, metadata *models.Metadata) {
+	// Remove the tagline added by an earlier patch
+	notes := string(sheet.CreatorNotes)
+	for _, tagline := range patchedTaglines(sheet, metadata) {
+		if notes == tagline {
+			notes = ""
+			break
+		}
+		if trimmed, found := strings.CutPrefix(notes, tagline+character.CreatorNotesSeparator); found {
+			notes = trimmed
+			break
+		}
+	}
+
 	// Join the tagline with the existing notes, using the separator
 	sheet.CreatorNotes = property.String(
 		stringsx.JoinNonBlank(
 			character.CreatorNotesSeparator,
 			metadata.Tagline,
-			string(sheet.CreatorNotes),
+			notes,
 		),
 	)
 }
 
-// patchTags ensures that the tags field is consistent with the metadata
-func patchTags(sheet *character.Sheet, metadata *models.Metadata) {
+// patchedTaglines returns the taglines an earlier patch could have added to the creator notes (as given and with normalized symbols)
+func patchedTaglines(sheet *character.Sheet, metadata *models.Metadata) []string {
+	// The embedded tagline was added by the last patch (it differs from the current one if the tagline was edited since)
+	taglines := make([]string, 0, 4)
+	if previous := embeddedMetadata(sheet); previous != nil {
+		taglines = append(taglines, previous.Tagline, stringsx.NormalizeSymbols(previous.Tagline))
+	}
+	taglines = append(taglines, metadata.Tagline, stringsx.NormalizeSymbols(metadata.Tagline))
+
+	// Keep the distinct non-blank taglines
+	result := taglines[:0]
+	for _, tagline := range taglines {
+		if stringsx.IsNotBlank(tagline) && !slices.Contains(result, tagline) {
+			result = append(result, tagline)
+		}
+	}
+	return result
+}
+
+// embeddedMetadata returns the metadata embedded in the sheet by an earlier patch (nil if missing or invalid)
+func embeddedMetadata(sheet *character.Sheet) *models.Metadata {
+	metadata, err := models.MetadataFromSheet(sheet)
+	if err != nil {
+		return nil
+	}
+	return metadata
+}
+
+// patchTags ensures that the tags field is consistent with the metadata (and filtered by the policy)
+func patchTags(sheet *character.Sheet, metadata *models.Metadata, policy *TagPolicy) {
 	// Create a map of tags from the sheet and the metadata
 	capacity := len(metadata.Tags) + len(sheet.Tags)
 	mapping := make(map[models.Slug]string, capacity)
 
+	// Use the same taxonomy for all the tags (aliases are merged into their canonical tag)
+	taxonomy := models.CurrentTaxonomy()
+
 	// Iterate over the sheet tags
 	for _, stringTag := range sheet.Tags {
 		// Resolve the tag slug and name
-		cardTag := models.ResolveTag(stringTag)
+		cardTag := taxonomy.Resolve(stringTag)
 		// Add the tag to the map
 		mapping[cardTag.Slug] = cardTag.Name
 	}
 
+	// Add the tags of the detected languages (if enabled by the policy)
+	if policy.AddsLanguageTags() {
+		for _, languageTag := range languageTags(metadata) {
+			mapping[languageTag.Slug] = languageTag.Name
+		}
+	}
+
 	// Iterate over the metadata tags
 	for _, metadataTag := range metadata.Tags {
+		// Canonicalize the tag (metadata tags are already resolved)
+		metadataTag = taxonomy.Canonicalize(metadataTag)
 		// Add the tag to the map
 		mapping[metadataTag.Slug] = metadataTag.Name
 	}
 
-	// Add the source tag to the map
-	sourceTag := models.ResolveTag(string(metadata.Source))
-	if _, ok := mapping[sourceTag.Slug]; !ok {
-		mapping[sourceTag.Slug] = sourceTag.Name
-	}
+	// Create a slice of tags from the map, filtered by the policy
+	mergedTags := policy.Apply(models.TagsFromMap(mapping))
 
-	// Create a slice of tags from the map
-	mergedTags := models.TagsFromMap(mapping)
+	// Add the source tag (unless disabled by the policy)
+	sourceTag := taxonomy.Resolve(string(metadata.Source))
+	if policy.AddsSourceTag() && !slices.ContainsFunc(mergedTags, func(tag models.Tag) bool { return tag.Slug == sourceTag.Slug }) {
+		mergedTags = append(mergedTags, sourceTag)
+	}
 
 	// Sort the tags by slug
 	slices.SortFunc(mergedTags, func(a, b models.Tag) int {
@@ -164,21 +197,47 @@ func patchTimestamps(sheet *character.Sheet, metadata *models.Metadata) {
 
 // patchBookName ensures that the book name is consistent with the metadata
 func patchBookName(book *character.Book, characterName string) {
+	patchBookNameWithTemplate(book, characterName, DefaultBookNameTemplate)
+}
+
+// patchBookNameWithTemplate ensures that the book name is consistent with the metadata, naming the unnamed books with the template
+func patchBookNameWithTemplate(book *character.Book, characterName string, template string) {
+	patchBookNameFrom(book, characterName, "", template)
+}
+
+// patchBookNameFrom ensures that the book name is consistent with the metadata, naming the unnamed books with the template
+// A name generated by an earlier patch for the previous character name is generated again (for renamed characters)
+func patchBookNameFrom(book *character.Book, characterName string, previousName string, template string) {
 	// Return if the book is nil
 	if book == nil {
 		return
 	}
-	// If the book name is blank, set it to the character name with the Lore Book suffix
+	// If the book name was generated by an earlier patch, generate it again
+	if stringsx.IsNotBlank(previousName) && string(book.Name) == renderBookName(template, previousName) {
+		book.Name = ""
+	}
+	// If the book name is blank, name it with the template
 	if stringsx.IsBlank(string(book.Name)) {
-		book.Name = property.String(characterName + " Lore Book")
-	} else {
-		// Replace the placeholder with the character name
-		book.Name = property.String(strings.Replace(string(book.Name), character.BookNamePlaceholder, characterName, 1))
+		book.Name = property.String(renderBookName(template, characterName))
+		return
 	}
+	// Replace the placeholder with the character name
+	book.Name = property.String(strings.Replace(string(book.Name), character.BookNamePlaceholder, characterName, 1))
 	// Replace slashes with dashes in the book name
 	book.Name = property.String(strings.Replace(string(book.Name), "/", "-", -1))
 }
 
+// renderBookName renders the book name template for the character (slashes are replaced with dashes)
+func renderBookName(template string, characterName string) string {
+	return strings.Replace(strings.ReplaceAll(template, BookNameVariable, characterName), "/", "-", -1)
+}
+
+// patchCounts ensures that the greetings count and the book flag are consistent with the sheet
+func patchCounts(sheet *character.Sheet, metadata *models.Metadata) {
+	metadata.GreetingsCount = len(sheet.AlternateGreetings)
+	metadata.HasBook = sheet.CharacterBook != nil
+}
+
 // patchMetaFields ensures that the meta-fields are consistent with the metadata
 func patchMetaFields(sheet *character.Sheet, metadata *models.Metadata) {
 	sheet.SourceID = property.String(metadata.Source)
@@ -462,7 +462,7 @@ func TestPatchTags(t *testing.T) {
 			},
 		}
 
-		patchTags(sheet, metadata)
+		patchTags(sheet, metadata, nil)
 
 		// Should contain all unique tags
 		assert.Contains(t, sheet.Content.Tags, "Fantasy")
@@ -487,7 +487,7 @@ func TestPatchTags(t *testing.T) {
 			},
 		}
 
-		patchTags(sheet, metadata)
+		patchTags(sheet, metadata, nil)
 
 		assert.Equal(t, []string{"", "Adventure"}, []string(sheet.Content.Tags))
 		assert.Len(t, metadata.Tags, 2)
@@ -506,7 +506,7 @@ func TestPatchTags(t *testing.T) {
 			},
 		}
 
-		patchTags(sheet, metadata)
+		patchTags(sheet, metadata, nil)
 
 		assert.Equal(t, []string{"", "Adventure"}, []string(sheet.Content.Tags))
 		assert.Len(t, metadata.Tags, 2)
@@ -525,7 +525,7 @@ func TestPatchTags(t *testing.T) {
 			},
 		}
 
-		patchTags(sheet, metadata)
+		patchTags(sheet, metadata, nil)
 
 		assert.Equal(t, []string{"", "Fantasy"}, []string(sheet.Content.Tags))
 		assert.Len(t, metadata.Tags, 2)
@@ -544,7 +544,7 @@ func TestPatchTags(t *testing.T) {
 			},
 		}
 
-		patchTags(sheet, metadata)
+		patchTags(sheet, metadata, nil)
 
 		assert.Equal(t, []string{"", "Test"}, []string(sheet.Content.Tags))
 	})
@@ -562,7 +562,7 @@ func TestPatchTags(t *testing.T) {
 			},
 		}
 
-		patchTags(sheet, metadata)
+		patchTags(sheet, metadata, nil)
 
 		assert.Len(t, sheet.Content.Tags, 1)
 		assert.Len(t, metadata.Tags, 1)
@@ -581,7 +581,7 @@ func TestPatchTags(t *testing.T) {
 			},
 		}
 
-		patchTags(sheet, metadata)
+		patchTags(sheet, metadata, nil)
 
 		assert.Len(t, sheet.Content.Tags, 1)
 		assert.Len(t, metadata.Tags, 1)
@@ -600,7 +600,7 @@ func TestPatchTags(t *testing.T) {
 			},
 		}
 
-		patchTags(sheet, metadata)
+		patchTags(sheet, metadata, nil)
 
 		// Should deduplicate
 		assert.Len(t, sheet.Content.Tags, 3)
@@ -621,7 +621,7 @@ func TestPatchTags(t *testing.T) {
 			},
 		}
 
-		patchTags(sheet, metadata)
+		patchTags(sheet, metadata, nil)
 
 		// Standard tags should be resolved to proper names
 		assert.Contains(t, sheet.Content.Tags, "NSFW")      // Standard tag
@@ -642,7 +642,7 @@ func TestPatchTags(t *testing.T) {
 			},
 		}
 
-		patchTags(sheet, metadata)
+		patchTags(sheet, metadata, nil)
 
 		// All should resolve to the same standard tag and be deduplicated
 		assert.Len(t, sheet.Content.Tags, 2)
@@ -662,7 +662,7 @@ func TestPatchTags(t *testing.T) {
 			},
 		}
 
-		patchTags(sheet, metadata)
+		patchTags(sheet, metadata, nil)
 
 		// Tags should be sorted in metadata by slug
 		assert.Len(t, metadata.Tags, 5)
@@ -690,7 +690,7 @@ func TestPatchTags(t *testing.T) {
 			},
 		}
 
-		patchTags(sheet, metadata)
+		patchTags(sheet, metadata, nil)
 
 		assert.Len(t, sheet.Content.Tags, 5)
 		assert.Contains(t, sheet.Content.Tags, "テスト")
@@ -712,7 +712,7 @@ func TestPatchTags(t *testing.T) {
 			},
 		}
 
-		patchTags(sheet, metadata)
+		patchTags(sheet, metadata, nil)
 
 		// Empty and whitespace-only tags should be handled by ResolveTag
 		assert.Contains(t, sheet.Content.Tags, "Valid")
@@ -738,7 +738,7 @@ func TestPatchTags(t *testing.T) {
 			},
 		}
 
-		patchTags(sheet, metadata)
+		patchTags(sheet, metadata, nil)
 
 		assert.Len(t, sheet.Content.Tags, 1001)
 		assert.Len(t, metadata.Tags, 1001)
@@ -760,7 +760,7 @@ func TestPatchTags(t *testing.T) {
 			},
 		}
 
-		patchTags(sheet, metadata)
+		patchTags(sheet, metadata, nil)
 
 		// Should deduplicate correctly
 		assert.Len(t, sheet.Content.Tags, 4)
@@ -782,7 +782,7 @@ func TestPatchTags(t *testing.T) {
 			},
 		}
 
-		patchTags(sheet, metadata)
+		patchTags(sheet, metadata, nil)
 
 		// These should be processed by ResolveTag
 		assert.GreaterOrEqual(t, len(sheet.Content.Tags), 0) // Depends on ResolveTag behavior
@@ -801,7 +801,7 @@ func TestPatchTags(t *testing.T) {
 			},
 		}
 
-		patchTags(sheet, metadata)
+		patchTags(sheet, metadata, nil)
 
 		// Should all resolve to the same tag and be deduplicated
 		assert.Len(t, sheet.Content.Tags, 2)
@@ -1613,6 +1613,11 @@ func TestPatchSheet(t *testing.T) {
 
 		// Test metadata synchronization
 		assert.Equal(t, "Test Character", metadata.Name)
+
+		// Test metadata embedding
+		embedded, err := models.MetadataFromSheet(sheet)
+		assert.NoError(t, err)
+		assert.Equal(t, metadata, embedded)
 	})
 
 	t.Run("should handle completely empty metadata and sheet", func(t *testing.T) {
@@ -0,0 +1,269 @@
+package fetcher
+
+import (
+	"slices"
+
+	"github.com/r3dpixel/card-fetcher/language"
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/card-parser/property"
+)
+
+// DefaultBookNameTemplate is the name given to the books without a name ({{name}} is replaced with the character name)
+const DefaultBookNameTemplate = BookNameVariable + " Lore Book"
+
+// BookNameVariable is replaced with the character name in the book name templates
+const BookNameVariable = "{{name}}"
+
+// StepName identifies a step of the patch pipeline
+type StepName string
+
+// Steps of the default patch pipeline (in execution order)
+const (
+	StepNameAndTitle     StepName = "name_and_title"
+	StepCreatorNotes     StepName = "creator_notes"
+	StepLanguages        StepName = "languages"
+	StepTags             StepName = "tags"
+	StepTimestamps       StepName = "timestamps"
+	StepBookName         StepName = "book_name"
+	StepMetaFields       StepName = "meta_fields"
+	StepCounts           StepName = "counts"
+	StepCreator          StepName = "creator"
+	StepUserTemplates    StepName = "user_templates"
+	StepNormalizeSymbols StepName = "normalize_symbols"
+	StepEmbedMetadata    StepName = "embed_metadata"
+)
+
+// Patcher patches a fetched sheet to be consistent with the metadata
+type Patcher interface {
+	// Patch patches the sheet and the metadata in place
+	Patch(sheet *character.Sheet, metadata *models.Metadata)
+}
+
+// PatcherFunc adapts a function to the Patcher interface
+type PatcherFunc func(sheet *character.Sheet, metadata *models.Metadata)
+
+// Patch calls the function
+func (f PatcherFunc) Patch(sheet *character.Sheet, metadata *models.Metadata) {
+	f(sheet, metadata)
+}
+
+// Step is a named step of the patch pipeline
+type Step struct {
+	// Name identifies the step (to enable, disable, replace, or insert steps around it)
+	Name StepName
+	// Patcher executes the step
+	Patcher Patcher
+}
+
+// PipelineOptions configures the steps of the default patch pipeline
+type PipelineOptions struct {
+	// TagPolicy filters the tags of the card (optional, nil uses the policy given with WithTagPolicy, the router or task policy)
+	TagPolicy *TagPolicy
+	// BookNameTemplate names the books without a name (optional, defaults to DefaultBookNameTemplate)
+	BookNameTemplate string
+}
+
+// Pipeline is an ordered list of patch steps (configure the pipeline before sharing it between tasks)
+type Pipeline struct {
+	steps     []Step
+	disabled  map[StepName]bool
+	tagPolicy *TagPolicy
+}
+
+// tagsStep is the tags step of the default pipeline (filters the tags with the tag policy of the pipeline)
+type tagsStep struct{}
+
+// Patch merges and sorts the tags without a tag policy (the pipeline executes the step with its tag policy)
+func (tagsStep) Patch(sheet *character.Sheet, metadata *models.Metadata) {
+	patchTags(sheet, metadata, nil)
+}
+
+// NewPipeline creates a pipeline executing the steps in order
+func NewPipeline(steps ...Step) *Pipeline {
+	return &Pipeline{steps: slices.Clone(steps), disabled: map[StepName]bool{}}
+}
+
+// DefaultPipeline creates the pipeline executing all the patch steps (the behavior of PatchSheet)
+func DefaultPipeline(options PipelineOptions) *Pipeline {
+	// Use the default book name template if not set
+	bookNameTemplate := options.BookNameTemplate
+	if bookNameTemplate == "" {
+		bookNameTemplate = DefaultBookNameTemplate
+	}
+
+	pipeline := NewPipeline(
+		// Synchronize the name and the title
+		Step{StepNameAndTitle, PatcherFunc(patchNameAndTitle)},
+		// Prefix the tagline to the creator notes
+		Step{StepCreatorNotes, PatcherFunc(patchCreatorNotes)},
+		// Detect the languages of the content (before the tags, to add the language tags)
+		Step{StepLanguages, PatcherFunc(func(sheet *character.Sheet, metadata *models.Metadata) {
+			metadata.Languages = language.DetectSheet(sheet)
+		})},
+		// Merge, filter, and sort the tags
+		Step{StepTags, tagsStep{}},
+		// Synchronize the timestamps
+		Step{StepTimestamps, PatcherFunc(patchTimestamps)},
+		// Name the book after the character (the name generated by an earlier patch follows the renamed characters)
+		Step{StepBookName, PatcherFunc(func(sheet *character.Sheet, metadata *models.Metadata) {
+			var previousName string
+			if previous := embeddedMetadata(sheet); previous != nil {
+				previousName = previous.Name
+			}
+			patchBookNameFrom(sheet.CharacterBook, metadata.Name, previousName, bookNameTemplate)
+		})},
+		// Set the source fields
+		Step{StepMetaFields, PatcherFunc(patchMetaFields)},
+		// Count the greetings and flag the book
+		Step{StepCounts, PatcherFunc(patchCounts)},
+		// Set the creator of the sheet to the nickname
+		Step{StepCreator, PatcherFunc(func(sheet *character.Sheet, metadata *models.Metadata) {
+			sheet.Creator = property.String(metadata.Nickname)
+		})},
+		// Fix user templates
+		Step{StepUserTemplates, PatcherFunc(func(sheet *character.Sheet, _ *models.Metadata) {
+			sheet.FixUserCharTemplates()
+		})},
+		// Normalize symbols in sheet
+		Step{StepNormalizeSymbols, PatcherFunc(func(sheet *character.Sheet, _ *models.Metadata) {
+			sheet.NormalizeSymbols()
+		})},
+		// Embed the full metadata in the sheet extensions (serializing plain fields cannot fail)
+		Step{StepEmbedMetadata, PatcherFunc(func(sheet *character.Sheet, metadata *models.Metadata) {
+			_ = metadata.EmbedIn(sheet)
+		})},
+	)
+	pipeline.tagPolicy = options.TagPolicy
+	return pipeline
+}
+
+// metadataSteps are the steps copying metadata values into the sheet (the other steps are traced as patcher changes)
+var metadataSteps = map[StepName]bool{StepNameAndTitle: true, StepTags: true, StepCreator: true}
+
+// Patch executes the enabled steps in order (a nil pipeline executes the default pipeline)
+// The fields changed by each step are traced in the metadata provenance (if the metadata has one)
+func (p *Pipeline) Patch(sheet *character.Sheet, metadata *models.Metadata) {
+	if p == nil {
+		p = DefaultPipeline(PipelineOptions{})
+	}
+
+	// Take the field values before the steps (only if traced)
+	var values map[models.SheetField]string
+	if metadata.Provenance != nil {
+		values = models.SheetFieldValues(sheet)
+	}
+
+	for _, step := range p.steps {
+		if p.disabled[step.Name] {
+			continue
+		}
+		if _, ok := step.Patcher.(tagsStep); ok {
+			patchTags(sheet, metadata, p.tagPolicy)
+		} else {
+			step.Patcher.Patch(sheet, metadata)
+		}
+
+		// Trace the fields changed by the step
+		if metadata.Provenance != nil {
+			origin := models.OriginPatcher
+			if metadataSteps[step.Name] {
+				origin = models.OriginMetadata
+			}
+			changedValues := models.SheetFieldValues(sheet)
+			metadata.Provenance.TraceChanges(values, changedValues, origin)
+			values = changedValues
+		}
+	}
+}
+
+// Steps returns the names of the enabled steps in execution order
+func (p *Pipeline) Steps() []StepName {
+	names := make([]StepName, 0, len(p.steps))
+	for _, step := range p.steps {
+		if !p.disabled[step.Name] {
+			names = append(names, step.Name)
+		}
+	}
+	return names
+}
+
+// Enable enables the disabled steps
+func (p *Pipeline) Enable(names ...StepName) *Pipeline {
+	for _, name := range names {
+		delete(p.disabled, name)
+	}
+	return p
+}
+
+// Disable disables the steps (the steps keep their position, to be enabled again)
+func (p *Pipeline) Disable(names ...StepName) *Pipeline {
+	for _, name := range names {
+		p.disabled[name] = true
+	}
+	return p
+}
+
+// Replace replaces the patcher of the step (appended if the step is missing)
+func (p *Pipeline) Replace(name StepName, patcher Patcher) *Pipeline {
+	if index := p.indexOf(name); index >= 0 {
+		p.steps[index].Patcher = patcher
+		return p
+	}
+	return p.Append(Step{Name: name, Patcher: patcher})
+}
+
+// InsertBefore inserts the step before the named step (appended if the named step is missing)
+func (p *Pipeline) InsertBefore(name StepName, step Step) *Pipeline {
+	if index := p.indexOf(name); index >= 0 {
+		p.steps = slices.Insert(p.steps, index, step)
+		return p
+	}
+	return p.Append(step)
+}
+
+// InsertAfter inserts the step after the named step (appended if the named step is missing)
+func (p *Pipeline) InsertAfter(name StepName, step Step) *Pipeline {
+	if index := p.indexOf(name); index >= 0 {
+		p.steps = slices.Insert(p.steps, index+1, step)
+		return p
+	}
+	return p.Append(step)
+}
+
+// Append appends the step at the end of the pipeline
+func (p *Pipeline) Append(step Step) *Pipeline {
+	p.steps = append(p.steps, step)
+	return p
+}
+
+// Clone returns a copy of the pipeline (to customize a shared pipeline for a single task)
+func (p *Pipeline) Clone() *Pipeline {
+	clone := NewPipeline(p.steps...)
+	for name := range p.disabled {
+		clone.disabled[name] = true
+	}
+	clone.tagPolicy = p.tagPolicy
+	return clone
+}
+
+// TagPolicy returns the tag policy filtering the tags of the default tags step (nil if not set)
+func (p *Pipeline) TagPolicy() *TagPolicy {
+	return p.tagPolicy
+}
+
+// WithTagPolicy returns the pipeline filtering the tags with the policy, if the pipeline has no tag policy
+// (the router and task policy fill in for the custom pipelines, the policy of PipelineOptions takes precedence)
+func (p *Pipeline) WithTagPolicy(policy *TagPolicy) *Pipeline {
+	if policy == nil || p.tagPolicy != nil {
+		return p
+	}
+	clone := p.Clone()
+	clone.tagPolicy = policy
+	return clone
+}
+
+// indexOf returns the index of the named step (-1 if missing)
+func (p *Pipeline) indexOf(name StepName) int {
+	return slices.IndexFunc(p.steps, func(step Step) bool { return step.Name == name })
+}
@@ -0,0 +1,277 @@
+package fetcher
+
+import (
+	"testing"
+	"testing/quick"
+
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-fetcher/snapshots"
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/card-parser/property"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPipelineFixture creates a raw sheet and its metadata
+func newPipelineFixture() (*character.Sheet, *models.Metadata) {
+	sheet := character.DefaultSheet(character.RevisionV2)
+	sheet.Name = "Raw Name"
+	sheet.CreatorNotes = "Notes"
+	sheet.Creator = "Raw Creator"
+	sheet.Tags = []string{"Fantasy"}
+	sheet.CharacterBook = &character.Book{}
+	metadata := &models.Metadata{
+		Source:      "test-source",
+		CardInfo:    models.CardInfo{Name: "Hero", Title: "Title", Tagline: "Tagline", CharacterID: "123"},
+		CreatorInfo: models.CreatorInfo{Nickname: "Creator", Username: "creator"},
+	}
+	return sheet, metadata
+}
+
+func TestPipeline_Default(t *testing.T) {
+	t.Run("should patch like PatchSheet", func(t *testing.T) {
+		expectedSheet, expectedMetadata := newPipelineFixture()
+		PatchSheet(expectedSheet, expectedMetadata)
+
+		sheet, metadata := newPipelineFixture()
+		DefaultPipeline(PipelineOptions{}).Patch(sheet, metadata)
+
+		assert.Equal(t, expectedSheet, sheet)
+		assert.Equal(t, expectedMetadata, metadata)
+	})
+
+	t.Run("should patch with the default pipeline when nil", func(t *testing.T) {
+		sheet, metadata := newPipelineFixture()
+		var pipeline *Pipeline
+		pipeline.Patch(sheet, metadata)
+
+		assert.Equal(t, property.String("Hero Lore Book"), sheet.CharacterBook.Name)
+		assert.Equal(t, property.String("Creator"), sheet.Creator)
+	})
+
+	t.Run("should list the steps in execution order", func(t *testing.T) {
+		assert.Equal(t, []StepName{
+			StepNameAndTitle, StepCreatorNotes, StepLanguages, StepTags, StepTimestamps, StepBookName,
+			StepMetaFields, StepCounts, StepCreator, StepUserTemplates, StepNormalizeSymbols, StepEmbedMetadata,
+		}, DefaultPipeline(PipelineOptions{}).Steps())
+	})
+
+	t.Run("should name the books with the template", func(t *testing.T) {
+		sheet, metadata := newPipelineFixture()
+		DefaultPipeline(PipelineOptions{BookNameTemplate: "Lore of {{name}}"}).Patch(sheet, metadata)
+
+		assert.Equal(t, property.String("Lore of Hero"), sheet.CharacterBook.Name)
+	})
+
+	t.Run("should filter the tags with the policy", func(t *testing.T) {
+		sheet, metadata := newPipelineFixture()
+		DefaultPipeline(PipelineOptions{TagPolicy: &TagPolicy{Deny: []string{"fantasy"}, SkipSourceTag: true}}).Patch(sheet, metadata)
+
+		assert.Empty(t, sheet.Tags)
+		assert.Empty(t, metadata.Tags)
+	})
+}
+
+func TestPipeline_Configure(t *testing.T) {
+	t.Run("should skip the disabled steps", func(t *testing.T) {
+		sheet, metadata := newPipelineFixture()
+		pipeline := DefaultPipeline(PipelineOptions{}).Disable(StepCreatorNotes, StepCreator, StepBookName)
+		pipeline.Patch(sheet, metadata)
+
+		assert.Equal(t, property.String("Notes"), sheet.CreatorNotes)
+		assert.Equal(t, property.String("Raw Creator"), sheet.Creator)
+		assert.Empty(t, sheet.CharacterBook.Name)
+		assert.NotContains(t, pipeline.Steps(), StepCreator)
+	})
+
+	t.Run("should execute the enabled steps again", func(t *testing.T) {
+		sheet, metadata := newPipelineFixture()
+		DefaultPipeline(PipelineOptions{}).Disable(StepCreator).Enable(StepCreator).Patch(sheet, metadata)
+
+		assert.Equal(t, property.String("Creator"), sheet.Creator)
+	})
+
+	t.Run("should replace steps", func(t *testing.T) {
+		sheet, metadata := newPipelineFixture()
+		pipeline := DefaultPipeline(PipelineOptions{}).Replace(StepCreator, PatcherFunc(func(sheet *character.Sheet, metadata *models.Metadata) {
+			sheet.Creator = property.String(metadata.Username)
+		}))
+		pipeline.Patch(sheet, metadata)
+
+		assert.Equal(t, property.String("creator"), sheet.Creator)
+		assert.Len(t, pipeline.Steps(), 12)
+	})
+
+	t.Run("should insert custom steps in order", func(t *testing.T) {
+		var order []StepName
+		record := func(name StepName) Step {
+			return Step{Name: name, Patcher: PatcherFunc(func(*character.Sheet, *models.Metadata) { order = append(order, name) })}
+		}
+		pipeline := NewPipeline(record("a"), record("c")).
+			InsertBefore("a", record("first")).
+			InsertAfter("a", record("b")).
+			Append(record("last")).
+			InsertAfter("missing", record("appended"))
+
+		sheet, metadata := newPipelineFixture()
+		pipeline.Patch(sheet, metadata)
+
+		assert.Equal(t, []StepName{"first", "a", "b", "c", "last", "appended"}, order)
+		assert.Equal(t, order, pipeline.Steps())
+	})
+
+	t.Run("should not share the configuration of clones", func(t *testing.T) {
+		pipeline := DefaultPipeline(PipelineOptions{}).Disable(StepTags)
+		clone := pipeline.Clone().Enable(StepTags).Disable(StepCreator)
+
+		assert.NotContains(t, pipeline.Steps(), StepTags)
+		assert.Contains(t, pipeline.Steps(), StepCreator)
+		assert.Contains(t, clone.Steps(), StepTags)
+		assert.NotContains(t, clone.Steps(), StepCreator)
+	})
+
+	t.Run("should filter the tags with the given tag policy", func(t *testing.T) {
+		deny := &TagPolicy{Deny: []string{"fantasy"}, SkipSourceTag: true}
+		pipeline := DefaultPipeline(PipelineOptions{}).Disable(StepCreator)
+
+		sheet, metadata := newPipelineFixture()
+		pipeline.WithTagPolicy(deny).Patch(sheet, metadata)
+
+		assert.Empty(t, sheet.Tags)
+		assert.Equal(t, property.String("Raw Creator"), sheet.Creator)
+		assert.Nil(t, pipeline.TagPolicy(), "the shared pipeline should not be modified")
+	})
+
+	t.Run("should keep the tag policy of the pipeline", func(t *testing.T) {
+		own := &TagPolicy{SkipSourceTag: true}
+		pipeline := DefaultPipeline(PipelineOptions{TagPolicy: own})
+
+		sheet, metadata := newPipelineFixture()
+		pipeline.WithTagPolicy(&TagPolicy{Deny: []string{"fantasy"}}).Patch(sheet, metadata)
+
+		assert.Equal(t, []string{"Fantasy"}, []string(sheet.Tags))
+		assert.Same(t, own, pipeline.Clone().TagPolicy())
+	})
+
+	t.Run("should keep the raw platform output without steps", func(t *testing.T) {
+		sheet, metadata := newPipelineFixture()
+		NewPipeline().Patch(sheet, metadata)
+
+		expectedSheet, expectedMetadata := newPipelineFixture()
+		assert.Equal(t, expectedSheet, sheet)
+		assert.Equal(t, expectedMetadata, metadata)
+	})
+}
+
+func TestPipeline_Idempotent(t *testing.T) {
+	t.Run("should not patch the snapshot cards twice", func(t *testing.T) {
+		for sourceID, urls := range snapshots.GetResourceMap() {
+			for index := range urls {
+				sheet, err := snapshots.GetResourceJson(sourceID, index)
+				require.NoError(t, err)
+
+				// Use the embedded metadata if any (otherwise rebuild it from the sheet)
+				metadata, err := models.MetadataFromSheet(sheet)
+				if err != nil {
+					metadata = &models.Metadata{
+						Source:      sourceID,
+						CardInfo:    models.CardInfo{Name: string(sheet.Name), Title: string(sheet.Title), Tagline: "Snapshot tagline"},
+						CreatorInfo: models.CreatorInfo{Nickname: string(sheet.Creator), Username: string(sheet.Creator)},
+					}
+				}
+
+				assertIdempotent(t, sheet, metadata, string(sourceID))
+			}
+		}
+	})
+
+	t.Run("should not prefix the tagline twice", func(t *testing.T) {
+		idempotent := func(tagline string, notes string) bool {
+			sheet := &character.Sheet{Content: character.Content{CreatorNotes: property.String(notes)}}
+			metadata := &models.Metadata{CardInfo: models.CardInfo{Tagline: tagline}}
+
+			patchCreatorNotes(sheet, metadata)
+			once := sheet.CreatorNotes
+			patchCreatorNotes(sheet, metadata)
+			return once == sheet.CreatorNotes
+		}
+
+		assert.NoError(t, quick.Check(idempotent, nil))
+	})
+
+	t.Run("should not patch the fixture twice", func(t *testing.T) {
+		sheet, metadata := newPipelineFixture()
+		assertIdempotent(t, sheet, metadata, "fixture")
+	})
+
+	t.Run("should replace the tagline of an earlier patch", func(t *testing.T) {
+		sheet, metadata := newPipelineFixture()
+		PatchSheet(sheet, metadata)
+
+		metadata.Tagline = "Edited tagline"
+		PatchSheet(sheet, metadata)
+
+		assert.Equal(t, property.String("Edited tagline"+character.CreatorNotesSeparator+"Notes"), sheet.CreatorNotes)
+	})
+
+	t.Run("should rename the book of an earlier patch", func(t *testing.T) {
+		sheet, metadata := newPipelineFixture()
+		PatchSheet(sheet, metadata)
+
+		metadata.Name = "Renamed"
+		PatchSheet(sheet, metadata)
+
+		assert.Equal(t, property.String("Renamed Lore Book"), sheet.CharacterBook.Name)
+	})
+
+	t.Run("should keep the book names set by the creator", func(t *testing.T) {
+		sheet, metadata := newPipelineFixture()
+		sheet.CharacterBook.Name = "World of Hero"
+		PatchSheet(sheet, metadata)
+
+		metadata.Name = "Renamed"
+		PatchSheet(sheet, metadata)
+
+		assert.Equal(t, property.String("World of Hero"), sheet.CharacterBook.Name)
+	})
+}
+
+// assertIdempotent asserts that patching the sheet twice gives the same result as patching it once
+func assertIdempotent(t *testing.T, sheet *character.Sheet, metadata *models.Metadata, name string) {
+	t.Helper()
+
+	// Patch once
+	PatchSheet(sheet, metadata)
+	onceSheet, err := sheet.ToBytes()
+	require.NoError(t, err, name)
+	onceMetadata := metadata.Clone()
+
+	// Patch twice
+	PatchSheet(sheet, metadata)
+	twiceSheet, err := sheet.ToBytes()
+	require.NoError(t, err, name)
+
+	assert.Equal(t, string(onceSheet), string(twiceSheet), name)
+	assert.Equal(t, onceMetadata, metadata, name)
+}
+
+func TestPipeline_Provenance(t *testing.T) {
+	t.Run("should trace the fields changed by the steps", func(t *testing.T) {
+		sheet, metadata := newPipelineFixture()
+		metadata.Provenance = models.NewProvenance()
+		metadata.Provenance.Set(models.FieldCreatorNotes, models.OriginPNG)
+		PatchSheet(sheet, metadata)
+
+		assert.Equal(t, models.OriginMetadata, metadata.Provenance.Origin(models.FieldName))
+		assert.Equal(t, models.OriginMetadata, metadata.Provenance.Origin(models.FieldCreator))
+		assert.Equal(t, models.OriginMetadata, metadata.Provenance.Origin(models.FieldTags))
+		assert.Equal(t, models.OriginPatcher, metadata.Provenance.Origin(models.FieldCreatorNotes))
+	})
+
+	t.Run("should not trace without provenance", func(t *testing.T) {
+		sheet, metadata := newPipelineFixture()
+		PatchSheet(sheet, metadata)
+
+		assert.Nil(t, metadata.Provenance)
+	})
+}
@@ -0,0 +1,122 @@
+package fetcher
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/r3dpixel/card-fetcher/language"
+	"github.com/r3dpixel/card-fetcher/models"
+)
+
+// nonEnglishTag is the tag of the cards not mainly written in English
+const nonEnglishTag = "nonenglish"
+
+// TagPolicy represents the filtering rules applied to the tags of a card
+// Tags are matched through the current taxonomy: a rule on a tag also matches its aliases and descendants
+type TagPolicy struct {
+	// Rename rewrites tags (applied first, keys match exact canonical tags only)
+	Rename map[string]string
+	// Deny drops the matching tags
+	Deny []string
+	// Allow keeps only the matching tags (ignored if empty)
+	Allow []string
+	// Reject fails the task if the card has any of the matching tags (see BlockedErr)
+	Reject []string
+	// SkipSourceTag disables the source tag added to every card
+	SkipSourceTag bool
+	// LanguageTags adds the tags of the detected non-English languages (and Non-English if English is not the main language)
+	LanguageTags bool
+}
+
+// Apply applies the rename, deny and allow rules to the tags (the result keeps the input order, without duplicates)
+func (p *TagPolicy) Apply(tags []models.Tag) []models.Tag {
+	if p == nil {
+		return tags
+	}
+	taxonomy := models.CurrentTaxonomy()
+
+	// Resolve the renamed tags (by canonical slug)
+	renames := make(map[models.Slug]models.Tag, len(p.Rename))
+	for from, to := range p.Rename {
+		renames[taxonomy.Resolve(from).Slug] = taxonomy.Resolve(to)
+	}
+
+	// Filter the tags
+	result := make([]models.Tag, 0, len(tags))
+	seen := make(map[models.Slug]bool, len(tags))
+	for _, tag := range tags {
+		// Rename the tag
+		tag = taxonomy.Canonicalize(tag)
+		if renamed, ok := renames[tag.Slug]; ok {
+			tag = renamed
+		}
+		// Drop denied, not allowed and duplicate tags
+		if tag.Slug == "" || seen[tag.Slug] || matchesAny(taxonomy, tag, p.Deny) {
+			continue
+		}
+		if len(p.Allow) > 0 && !matchesAny(taxonomy, tag, p.Allow) {
+			continue
+		}
+		seen[tag.Slug] = true
+		result = append(result, tag)
+	}
+
+	// Return the filtered tags
+	return result
+}
+
+// Blocked returns the tags matching the reject rule (renames are applied before matching)
+func (p *TagPolicy) Blocked(tags []models.Tag) []models.Tag {
+	if p == nil || len(p.Reject) == 0 {
+		return nil
+	}
+	taxonomy := models.CurrentTaxonomy()
+	var blocked []models.Tag
+	for _, tag := range (&TagPolicy{Rename: p.Rename}).Apply(tags) {
+		if matchesAny(taxonomy, tag, p.Reject) {
+			blocked = append(blocked, tag)
+		}
+	}
+	return blocked
+}
+
+// Check returns a BlockedErr error if any of the tags matches the reject rule
+func (p *TagPolicy) Check(tags []models.Tag) error {
+	blocked := p.Blocked(tags)
+	if len(blocked) == 0 {
+		return nil
+	}
+	return NewError(fmt.Errorf("blocked tags: %s", strings.Join(models.TagsToNames(blocked), ", ")), BlockedErr)
+}
+
+// AddsSourceTag checks if the source tag should be added to the tags
+func (p *TagPolicy) AddsSourceTag() bool {
+	return p == nil || !p.SkipSourceTag
+}
+
+// AddsLanguageTags checks if the tags of the detected languages should be added to the tags
+func (p *TagPolicy) AddsLanguageTags() bool {
+	return p != nil && p.LanguageTags
+}
+
+// languageTags returns the tags of the detected non-English languages (Non-English first if English is not the main language)
+func languageTags(metadata *models.Metadata) []models.Tag {
+	var tags []models.Tag
+	if primary := metadata.PrimaryLanguage(); primary != "" && primary != models.EnglishCode {
+		tags = append(tags, models.ResolveTag(nonEnglishTag))
+	}
+	for _, detected := range metadata.Languages {
+		if detected.Code != models.EnglishCode {
+			tags = append(tags, models.ResolveTag(language.Name(detected.Code)))
+		}
+	}
+	return tags
+}
+
+// matchesAny checks if the tag matches any of the queries
+func matchesAny(taxonomy *models.Taxonomy, tag models.Tag, queries []string) bool {
+	return slices.ContainsFunc(queries, func(query string) bool {
+		return taxonomy.Matches(tag, query)
+	})
+}
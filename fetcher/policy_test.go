@@ -0,0 +1,155 @@
+package fetcher
+
+import (
+	"testing"
+
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagPolicy_Apply(t *testing.T) {
+	tags := []models.Tag{
+		{Slug: "fantasy", Name: "Fantasy"},
+		{Slug: "ntrbait", Name: "NTR Bait"},
+		{Slug: "massuese", Name: "Massuese"},
+		{Slug: "oc", Name: "OC"},
+	}
+
+	t.Run("should keep all tags without a policy", func(t *testing.T) {
+		var policy *TagPolicy
+		assert.Equal(t, tags, policy.Apply(tags))
+		assert.True(t, policy.AddsSourceTag())
+		assert.NoError(t, policy.Check(tags))
+	})
+
+	t.Run("should drop denied tags and their descendants", func(t *testing.T) {
+		policy := &TagPolicy{Deny: []string{"NTR", "oc"}}
+
+		assert.Equal(t, []models.Tag{
+			{Slug: "fantasy", Name: "Fantasy"},
+			{Slug: "masseuse", Name: "Masseuse"},
+		}, policy.Apply(tags))
+	})
+
+	t.Run("should keep only allowed tags", func(t *testing.T) {
+		policy := &TagPolicy{Allow: []string{"fantasy", "masseuse"}}
+
+		assert.Equal(t, []models.Tag{
+			{Slug: "fantasy", Name: "Fantasy"},
+			{Slug: "masseuse", Name: "Masseuse"},
+		}, policy.Apply(tags))
+	})
+
+	t.Run("should rename tags before filtering and merge duplicates", func(t *testing.T) {
+		policy := &TagPolicy{
+			Rename: map[string]string{"OC": "Original Character", "Massuese": "Fantasy"},
+			Deny:   []string{"ntr"},
+		}
+
+		assert.Equal(t, []models.Tag{
+			{Slug: "fantasy", Name: "Fantasy"},
+			{Slug: "originalcharacter", Name: "Original Character"},
+		}, policy.Apply(tags))
+	})
+
+	t.Run("should rename tags once without following chained renames", func(t *testing.T) {
+		policy := &TagPolicy{Rename: map[string]string{"Fantasy": "Adventure", "Adventure": "Horror"}}
+
+		assert.Equal(t, []models.Tag{
+			{Slug: "adventure", Name: "Adventure"},
+			{Slug: "horror", Name: "Horror"},
+		}, policy.Apply([]models.Tag{{Slug: "fantasy", Name: "Fantasy"}, {Slug: "adventure", Name: "Adventure"}}))
+	})
+}
+
+func TestTagPolicy_Check(t *testing.T) {
+	tags := []models.Tag{{Slug: "fantasy", Name: "Fantasy"}, {Slug: "ntrbait", Name: "NTR Bait"}}
+
+	t.Run("should reject blocked tags and their descendants", func(t *testing.T) {
+		policy := &TagPolicy{Reject: []string{"ntr"}}
+
+		err := policy.Check(tags)
+
+		assert.Equal(t, BlockedErr, GetErrCode(err))
+		assert.ErrorContains(t, err, "NTR Bait")
+		assert.Equal(t, []models.Tag{{Slug: "ntrbait", Name: "NTR Bait"}}, policy.Blocked(tags))
+	})
+
+	t.Run("should match renamed tags", func(t *testing.T) {
+		policy := &TagPolicy{Rename: map[string]string{"fantasy": "nsfl"}, Reject: []string{"nsfl"}}
+		assert.Equal(t, BlockedErr, GetErrCode(policy.Check(tags)))
+	})
+
+	t.Run("should accept cards without blocked tags", func(t *testing.T) {
+		policy := &TagPolicy{Reject: []string{"nsfl"}}
+		assert.NoError(t, policy.Check(tags))
+	})
+}
+
+func TestPatchSheetWithPolicy(t *testing.T) {
+	newPair := func() (*character.Sheet, *models.Metadata) {
+		sheet := character.DefaultSheet(character.RevisionV2)
+		sheet.Tags = []string{"Adventure", "NTR Bait"}
+		metadata := &models.Metadata{
+			Source:   "ChubAI",
+			CardInfo: models.CardInfo{Name: "Name", Title: "Title", Tags: []models.Tag{{Slug: "fantasy", Name: "Fantasy"}}},
+		}
+		return sheet, metadata
+	}
+
+	t.Run("should filter the merged tags and skip the source tag", func(t *testing.T) {
+		sheet, metadata := newPair()
+
+		PatchSheetWithPolicy(sheet, metadata, &TagPolicy{Deny: []string{"ntr"}, SkipSourceTag: true})
+
+		assert.Equal(t, []string{"Adventure", "Fantasy"}, []string(sheet.Tags))
+		assert.Equal(t, []string{"Adventure", "Fantasy"}, models.TagsToNames(metadata.Tags))
+	})
+
+	t.Run("should add the source tag even if not allowed", func(t *testing.T) {
+		sheet, metadata := newPair()
+
+		PatchSheetWithPolicy(sheet, metadata, &TagPolicy{Allow: []string{"fantasy"}})
+
+		assert.Equal(t, []string{"ChubAI", "Fantasy"}, []string(sheet.Tags))
+	})
+
+	t.Run("should detect the languages of the content", func(t *testing.T) {
+		sheet, metadata := newPair()
+		sheet.Description = "A cheerful librarian who works in the old library at the edge of the town."
+
+		PatchSheetWithPolicy(sheet, metadata, nil)
+
+		assert.Equal(t, "en", metadata.PrimaryLanguage())
+	})
+
+	t.Run("should add the language tags if enabled", func(t *testing.T) {
+		sheet, metadata := newPair()
+		sheet.Description = "Une bibliothécaire joyeuse qui travaille dans la vieille bibliothèque au bord de la ville."
+		sheet.FirstMessage = "Bon retour ! J'ai gardé le livre que tu m'as demandé la semaine dernière."
+
+		PatchSheetWithPolicy(sheet, metadata, &TagPolicy{LanguageTags: true, SkipSourceTag: true})
+
+		assert.Equal(t, "fr", metadata.PrimaryLanguage())
+		assert.Equal(t, []string{"Adventure", "Fantasy", "French", "Non-English", "NTR Bait"}, []string(sheet.Tags))
+	})
+
+	t.Run("should not add language tags to English cards", func(t *testing.T) {
+		sheet, metadata := newPair()
+		sheet.Description = "A cheerful librarian who works in the old library at the edge of the town."
+
+		PatchSheetWithPolicy(sheet, metadata, &TagPolicy{LanguageTags: true, Deny: []string{"ntr"}, SkipSourceTag: true})
+
+		assert.Equal(t, []string{"Adventure", "Fantasy"}, []string(sheet.Tags))
+	})
+
+	t.Run("should filter the language tags", func(t *testing.T) {
+		sheet, metadata := newPair()
+		sheet.Description = "Une bibliothécaire joyeuse qui travaille dans la vieille bibliothèque au bord de la ville."
+
+		PatchSheetWithPolicy(sheet, metadata, &TagPolicy{LanguageTags: true, Deny: []string{"nonenglish", "ntr"}, SkipSourceTag: true})
+
+		assert.Equal(t, []string{"Adventure", "Fantasy"}, []string(sheet.Tags), "Languages are descendants of Non-English")
+	})
+}
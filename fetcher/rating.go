@@ -0,0 +1,147 @@
+package fetcher
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/r3dpixel/card-fetcher/models"
+)
+
+// RatingClassifier rates the cards from their tags, with a keyword classifier as a fallback
+// Tag and category rules only raise the rating set from the platform fields
+type RatingClassifier struct {
+	// Tags maps tags to the rating they imply (matched through the taxonomy, descendants included)
+	Tags map[string]models.Rating
+	// Categories maps tag categories to the rating they imply
+	Categories map[models.TagCategory]models.Rating
+	// Keywords maps ratings to the keywords implying them (whole words, case-insensitive), used if the card is still unrated
+	Keywords map[models.Rating][]string
+
+	// keywordsOnce compiles the keyword patterns on first use (the rules must not be changed afterwards)
+	keywordsOnce sync.Once
+	// keywordPatterns maps ratings to the compiled patterns of their keywords
+	keywordPatterns map[models.Rating]*regexp.Regexp
+}
+
+// defaultRatingClassifier is the classifier used by the nil classifiers (keyword patterns compiled once)
+var defaultRatingClassifier = sync.OnceValue(DefaultRatingClassifier)
+
+// DefaultRatingClassifier returns the built-in rating rules
+func DefaultRatingClassifier() *RatingClassifier {
+	return &RatingClassifier{
+		Tags: map[string]models.Rating{
+			"sfw":     models.RatingSFW,
+			"sfwnsfw": models.RatingSuggestive,
+			"nsfw":    models.RatingNSFW,
+			"nsfl":    models.RatingNSFL,
+		},
+		Categories: map[models.TagCategory]models.Rating{
+			models.TagCategoryKink: models.RatingNSFW,
+		},
+		Keywords: map[models.Rating][]string{
+			models.RatingSuggestive: {"suggestive", "ecchi", "flirty", "seductive", "lingerie"},
+			models.RatingNSFW:       {"nsfw", "explicit", "smut", "porn", "hentai", "lewd", "erotic", "sex"},
+			models.RatingNSFL:       {"nsfl", "gore", "guro", "snuff", "necrophilia", "scat"},
+		},
+	}
+}
+
+// Rate returns the rating of a card from its current rating (set from the platform fields), its tags and its texts
+// The keyword fallback runs only if neither the platform nor the tags rate the card (nil classifier uses the default rules)
+func (c *RatingClassifier) Rate(rating models.Rating, tags []models.Tag, texts ...string) models.Rating {
+	if c == nil {
+		c = defaultRatingClassifier()
+	}
+
+	// Raise the rating from the tags
+	rating = rating.Max(c.RateTags(tags))
+	if rating.IsKnown() {
+		return rating
+	}
+
+	// Fallback to the keywords
+	return c.RateText(texts...)
+}
+
+// RateTags returns the most extreme rating implied by the tags
+func (c *RatingClassifier) RateTags(tags []models.Tag) models.Rating {
+	taxonomy := models.CurrentTaxonomy()
+	rating := models.RatingUnknown
+	for _, tag := range tags {
+		rating = rating.Max(c.Categories[taxonomy.Category(tag.Slug)])
+		for query, tagRating := range c.Tags {
+			if taxonomy.Matches(tag, query) {
+				rating = rating.Max(tagRating)
+			}
+		}
+	}
+	return rating
+}
+
+// RateText returns the most extreme rating implied by the keywords found in the texts
+func (c *RatingClassifier) RateText(texts ...string) models.Rating {
+	c.keywordsOnce.Do(c.compileKeywords)
+	text := strings.ToLower(strings.Join(texts, "\n"))
+	rating := models.RatingUnknown
+	for keywordRating, pattern := range c.keywordPatterns {
+		if keywordRating.Level() > rating.Level() && pattern.MatchString(text) {
+			rating = keywordRating
+		}
+	}
+	return rating
+}
+
+// compileKeywords compiles the keywords of each rating into a single pattern (whole words only, ratings without keywords are skipped)
+func (c *RatingClassifier) compileKeywords() {
+	c.keywordPatterns = make(map[models.Rating]*regexp.Regexp, len(c.Keywords))
+	for rating, keywords := range c.Keywords {
+		if len(keywords) == 0 {
+			continue
+		}
+		quoted := make([]string, len(keywords))
+		for index, keyword := range keywords {
+			quoted[index] = regexp.QuoteMeta(strings.ToLower(keyword))
+		}
+		c.keywordPatterns[rating] = regexp.MustCompile(`(?:^|[^\pL\pN])(?:` + strings.Join(quoted, "|") + `)(?:$|[^\pL\pN])`)
+	}
+}
+
+// RatingFilter represents the content ratings accepted by the tasks
+type RatingFilter struct {
+	// Max is the most extreme accepted rating (RatingUnknown accepts all ratings)
+	Max models.Rating
+	// RejectUnknown rejects the cards whose rating could not be determined
+	RejectUnknown bool
+}
+
+// Accepts checks if the rating is accepted by the filter (nil accepts all ratings)
+func (f *RatingFilter) Accepts(rating models.Rating) bool {
+	switch {
+	case f == nil:
+		return true
+	case !rating.IsKnown():
+		return !f.RejectUnknown
+	default:
+		return !f.Max.IsKnown() || rating.Level() <= f.Max.Level()
+	}
+}
+
+// Check returns a RatingErr error if the rating is not accepted by the filter
+func (f *RatingFilter) Check(rating models.Rating) error {
+	if f.Accepts(rating) {
+		return nil
+	}
+	return NewError(fmt.Errorf("rating %s exceeds %s", rating, f.Max), RatingErr)
+}
+
+// CheckKnown returns a RatingErr error if the rating is known and not accepted by the filter
+// Unknown ratings are accepted, the card data may still rate the card (ratings are only raised by the card data,
+// so a card rejected by CheckKnown is also rejected by Check once the card data is rated)
+func (f *RatingFilter) CheckKnown(rating models.Rating) error {
+	if !rating.IsKnown() {
+		return nil
+	}
+	return f.Check(rating)
+}
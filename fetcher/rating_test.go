@@ -0,0 +1,87 @@
+package fetcher
+
+import (
+	"testing"
+
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRatingClassifier_Rate(t *testing.T) {
+	tags := func(names ...string) []models.Tag {
+		result := make([]models.Tag, len(names))
+		for index, name := range names {
+			result[index] = models.ResolveTag(name)
+		}
+		return result
+	}
+
+	testCases := []struct {
+		name     string
+		rating   models.Rating
+		tags     []models.Tag
+		texts    []string
+		expected models.Rating
+	}{
+		{name: "platform rating is kept", rating: models.RatingSuggestive, tags: tags("Fantasy"), expected: models.RatingSuggestive},
+		{name: "tags raise the platform rating", rating: models.RatingSFW, tags: tags("NSFL"), expected: models.RatingNSFL},
+		{name: "tags never lower the platform rating", rating: models.RatingNSFW, tags: tags("SFW"), expected: models.RatingNSFW},
+		{name: "descendant tags", tags: tags("Smut"), expected: models.RatingNSFW},
+		{name: "category rules", tags: tags("BDSM"), expected: models.RatingNSFW},
+		{name: "mixed tags", tags: tags("SFW <-> NSFW"), expected: models.RatingSuggestive},
+		{name: "keyword fallback", texts: []string{"A lewd succubus"}, expected: models.RatingNSFW},
+		{name: "keywords rate the most extreme match", texts: []string{"Explicit", "with GORE"}, expected: models.RatingNSFL},
+		{name: "keywords match whole words only", texts: []string{"Sussex essex"}, expected: models.RatingUnknown},
+		{name: "keywords are ignored for rated cards", tags: tags("SFW"), texts: []string{"explicit"}, expected: models.RatingSFW},
+		{name: "unrated cards", tags: tags("Fantasy"), texts: []string{"A knight"}, expected: models.RatingUnknown},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var classifier *RatingClassifier
+			assert.Equal(t, tc.expected, classifier.Rate(tc.rating, tc.tags, tc.texts...))
+		})
+	}
+
+	t.Run("should use custom rules", func(t *testing.T) {
+		classifier := &RatingClassifier{
+			Tags:     map[string]models.Rating{"Horror": models.RatingNSFL},
+			Keywords: map[models.Rating][]string{models.RatingSuggestive: {"kiss"}},
+		}
+
+		assert.Equal(t, models.RatingNSFL, classifier.Rate(models.RatingUnknown, tags("Horror")))
+		assert.Equal(t, models.RatingUnknown, classifier.Rate(models.RatingUnknown, tags("NSFW")))
+		assert.Equal(t, models.RatingSuggestive, classifier.Rate(models.RatingUnknown, nil, "A Kiss goodbye"))
+	})
+
+	t.Run("should compile the keywords once", func(t *testing.T) {
+		classifier := DefaultRatingClassifier()
+
+		assert.Equal(t, models.RatingNSFW, classifier.RateText("An explicit story"))
+		patterns := classifier.keywordPatterns
+		assert.Equal(t, models.RatingUnknown, classifier.RateText("A kissed goodbye"))
+		assert.Len(t, classifier.keywordPatterns, len(classifier.Keywords))
+		for rating, pattern := range patterns {
+			assert.Same(t, pattern, classifier.keywordPatterns[rating])
+		}
+	})
+}
+
+func TestRatingFilter(t *testing.T) {
+	var noFilter *RatingFilter
+	assert.True(t, noFilter.Accepts(models.RatingNSFL))
+
+	filter := &RatingFilter{Max: models.RatingSuggestive}
+	assert.True(t, filter.Accepts(models.RatingSFW))
+	assert.True(t, filter.Accepts(models.RatingSuggestive))
+	assert.True(t, filter.Accepts(models.RatingUnknown))
+	assert.False(t, filter.Accepts(models.RatingNSFW))
+	assert.NoError(t, filter.Check(models.RatingSFW))
+	assert.Equal(t, RatingErr, GetErrCode(filter.Check(models.RatingNSFL)))
+
+	strict := &RatingFilter{RejectUnknown: true}
+	assert.True(t, strict.Accepts(models.RatingNSFL))
+	assert.False(t, strict.Accepts(models.RatingUnknown))
+	assert.Equal(t, RatingErr, GetErrCode(strict.Check(models.RatingUnknown)))
+	assert.NoError(t, strict.CheckKnown(models.RatingUnknown), "unknown ratings are checked once the card data is rated")
+	assert.Equal(t, RatingErr, GetErrCode(filter.CheckKnown(models.RatingNSFW)))
+}
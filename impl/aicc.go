@@ -2,6 +2,7 @@ package impl
 
 import (
 	"fmt"
+	"net/url"
 	"path"
 	"strings"
 	"time"
@@ -11,7 +12,6 @@ import (
 	"github.com/r3dpixel/card-fetcher/fetcher"
 	"github.com/r3dpixel/card-fetcher/models"
 	"github.com/r3dpixel/card-fetcher/source"
-	"github.com/r3dpixel/card-parser/png"
 	"github.com/r3dpixel/toolkit/reqx"
 	"github.com/r3dpixel/toolkit/sonicx"
 	"github.com/r3dpixel/toolkit/stringsx"
@@ -26,6 +26,7 @@ const (
 	aiccImageURL   string = "https://aicharactercards.com/wp-json/pngapi/v1/image/%s"   // AICC image URL
 	aiccDetailsURL string = "https://aicharactercards.com/wp-json/pngapi/v1/details/%s" // AICC details URL
 	aiccDateFormat string = time.RFC3339                                                // Date Format for AICC
+	aiccRemovedURL string = "/charactercards/"                                          // AICC redirect target of removed cards
 )
 
 // aiccDetails represents the partial response from the details API
@@ -72,6 +73,29 @@ func (f *aiccFetcher) FetchMetadataResponse(characterID string) (*req.Response,
 	return f.client.R().Get(fmt.Sprintf(aiccPageURL, characterID))
 }
 
+// IsRemoved checks if the page request was redirected from the character page to the card listing (AICC redirects removed cards,
+// other redirects such as renamed cards or login pages do not confirm a removal)
+func (f *aiccFetcher) IsRemoved(response *req.Response) bool {
+	// Check the status code first
+	if f.BaseFetcher.IsRemoved(response) {
+		return true
+	}
+	// Missing responses (network errors) never confirm a removal
+	if response == nil || response.Response == nil || response.Request == nil || response.Response.Request == nil {
+		return false
+	}
+	// Parse the requested URL
+	requestedURL, err := url.Parse(response.Request.RawURL)
+	if err != nil {
+		return false
+	}
+	// Check that the character page was redirected to the card listing (after following redirects)
+	requestedPath := strings.TrimSuffix(requestedURL.Path, "/")
+	finalPath := strings.TrimSuffix(response.Response.Request.URL.Path, "/")
+	removedPath := strings.TrimSuffix(aiccRemovedURL, "/")
+	return requestedPath != removedPath && finalPath == removedPath
+}
+
 // CreateBinder stores the raw HTML in StringResponse (no JSON parsing for AICC)
 func (f *aiccFetcher) CreateBinder(characterID string, response string) (*fetcher.MetadataBinder, error) {
 	// Parse HTML
@@ -128,18 +152,20 @@ func (f *aiccFetcher) FetchCreatorInfo(metadataBinder *fetcher.MetadataBinder) (
 }
 
 // FetchCharacterCard downloads the PNG from the source
-func (f *aiccFetcher) FetchCharacterCard(binder *fetcher.Binder) (*png.CharacterCard, error) {
+func (f *aiccFetcher) FetchCharacterCard(binder *fetcher.Binder) (*fetcher.Card, error) {
 	// Extract author/title from characterID (truncated path)
 	authorTitle, err := f.extractAuthorTitle(binder.CharacterID)
 	if err != nil {
 		return nil, fetcher.NewError(err, fetcher.FetchAvatarErr)
 	}
 
-	// Download PNG from API
-	downloadURL := fmt.Sprintf(aiccImageURL, authorTitle)
-	rawCard, err := png.FromURL(f.client, downloadURL).LastVersion().Get()
+	// Download PNG from API (required, the card data is only in the PNG)
+	rawCard, fallback, err := f.fetchAvatar(binder, fetcher.AvatarChain{
+		URLs:     []string{fmt.Sprintf(aiccImageURL, authorTitle)},
+		Required: true,
+	})
 	if err != nil {
-		return nil, fetcher.NewError(err, fetcher.FetchAvatarErr)
+		return nil, err
 	}
 
 	// Decode the character card
@@ -149,7 +175,7 @@ func (f *aiccFetcher) FetchCharacterCard(binder *fetcher.Binder) (*png.Character
 	}
 
 	// Return the character card
-	return characterCard, nil
+	return f.newCard(binder, rawCard, characterCard, fallback), nil
 }
 
 // fetchDetails fetches metadata from the details API endpoint
@@ -0,0 +1,41 @@
+package impl
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/imroc/req/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAiccFetcher_IsRemoved(t *testing.T) {
+	f := NewAiccFetcher(nil).(*aiccFetcher)
+
+	// response returns the response of the character page request, redirected to the final URL
+	response := func(statusCode int, finalURL string) *req.Response {
+		final, err := url.Parse(finalURL)
+		assert.NoError(t, err)
+		return &req.Response{
+			Request:  &req.Request{RawURL: "https://aicharactercards.com/charactercards/fantasy/author/card/"},
+			Response: &http.Response{StatusCode: statusCode, Request: &http.Request{URL: final}},
+		}
+	}
+
+	t.Run("should confirm a redirect to the card listing", func(t *testing.T) {
+		assert.True(t, f.IsRemoved(response(http.StatusOK, "https://aicharactercards.com/charactercards/")))
+		assert.True(t, f.IsRemoved(response(http.StatusNotFound, "https://aicharactercards.com/charactercards/fantasy/author/card/")))
+	})
+
+	t.Run("should not confirm other redirects", func(t *testing.T) {
+		assert.False(t, f.IsRemoved(response(http.StatusOK, "https://aicharactercards.com/charactercards/fantasy/author/card/")))
+		assert.False(t, f.IsRemoved(response(http.StatusOK, "https://aicharactercards.com/charactercards/fantasy/author/renamed-card/")))
+		assert.False(t, f.IsRemoved(response(http.StatusOK, "https://aicharactercards.com/login/")))
+		assert.False(t, f.IsRemoved(response(http.StatusOK, "https://aicharactercards.com/")))
+	})
+
+	t.Run("should not confirm missing responses", func(t *testing.T) {
+		assert.False(t, f.IsRemoved(nil))
+		assert.False(t, f.IsRemoved(&req.Response{}))
+	})
+}
@@ -2,14 +2,23 @@ package impl
 
 import (
 	"fmt"
+	"net/http"
 	"path"
+	"slices"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/imroc/req/v3"
+	"github.com/r3dpixel/card-fetcher/avatar"
 	"github.com/r3dpixel/card-fetcher/fetcher"
+	"github.com/r3dpixel/card-fetcher/models"
 	"github.com/r3dpixel/card-fetcher/source"
+	"github.com/r3dpixel/card-parser/png"
 	"github.com/r3dpixel/toolkit/reqx"
 	"github.com/r3dpixel/toolkit/sonicx"
 	"github.com/r3dpixel/toolkit/stringsx"
+	"github.com/r3dpixel/toolkit/trace"
+	"github.com/rs/zerolog/log"
 )
 
 // BaseFetcher embeddable struct for creating a new fetcher
@@ -69,6 +78,17 @@ func (f *BaseFetcher) NormalizeURL(characterID string) string {
 	return path.Join(f.Fetcher.MainURL(), characterID)
 }
 
+// IsRemoved checks if the metadata response confirms that the card was removed from the source
+// (404 Not Found or 410 Gone by default, override if needed)
+func (f *BaseFetcher) IsRemoved(response *req.Response) bool {
+	// Missing responses (network errors) never confirm a removal
+	if response == nil || response.Response == nil {
+		return false
+	}
+	// Check the status code
+	return response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusGone
+}
+
 // CreateBinder creates a MetadataBinder from the metadata response
 func (f *BaseFetcher) CreateBinder(characterID string, response string) (*fetcher.MetadataBinder, error) {
 	return f.CreateBinderFromJSON(characterID, response)
@@ -79,6 +99,11 @@ func (f *BaseFetcher) FetchBookResponses(metadataBinder *fetcher.MetadataBinder)
 	return &fetcher.EmptyBookBinder, nil
 }
 
+// FetchStats extracts the engagement statistics (empty for convenience, override if the platform provides any)
+func (f *BaseFetcher) FetchStats(metadataBinder *fetcher.MetadataBinder) (*models.Stats, error) {
+	return &models.Stats{}, nil
+}
+
 // IsSourceUp checks if the source is up
 func (f *BaseFetcher) IsSourceUp() error {
 	_, err := f.client.R().Get("https://" + f.sourceURL)
@@ -88,6 +113,68 @@ func (f *BaseFetcher) IsSourceUp() error {
 // Close closes the fetcher (no-op for convenience, override if needed)
 func (f *BaseFetcher) Close() {}
 
+// fetchAvatar fetches the avatar through the fallback chain, and returns the fallback that provided it
+func (f *BaseFetcher) fetchAvatar(binder *fetcher.Binder, chain fetcher.AvatarChain) (*png.RawCard, models.AvatarFallback, error) {
+	rawCard, fallback, err := chain.Fetch(func(url string) (*png.RawCard, error) {
+		selector := png.FromURL(f.client, url)
+		if chain.Longest {
+			return selector.LastLongest().Get()
+		}
+		return selector.LastVersion().Get()
+	})
+	if err != nil {
+		return nil, models.AvatarFallbackNone, fetcher.NewError(err, fetcher.FetchAvatarErr)
+	}
+
+	// Warn about the avatars not fetched from their primary URL
+	if fallback != models.AvatarFallbackNone {
+		log.Warn().
+			Str(trace.SOURCE, string(f.sourceID)).
+			Str(trace.URL, binder.DirectURL).
+			Str("fallback", string(fallback)).
+			Msg("Avatar fetched from a fallback")
+	}
+	return rawCard, fallback, nil
+}
+
+// cardV3Fields are the V3 fields of the PNG card not kept by the character sheet
+type cardV3Fields struct {
+	Data struct {
+		Source                   []string          `json:"source"`
+		CreatorNotesMultilingual map[string]string `json:"creator_notes_multilingual"`
+	} `json:"data"`
+}
+
+// newCard creates the fetched card, with the platform URL of the card and the V3 fields of the PNG card (ccv3 chunk, if any)
+func (f *BaseFetcher) newCard(binder *fetcher.Binder, rawCard *png.RawCard, characterCard *png.CharacterCard, fallback models.AvatarFallback) *fetcher.Card {
+	card := &fetcher.Card{
+		CharacterCard:  characterCard,
+		AvatarFallback: fallback,
+		Source:         []string{"https://" + binder.NormalizedURL},
+	}
+
+	// Read the V3 fields of the PNG card (the generated avatars have none)
+	data, err := rawCard.ToBytes()
+	if err != nil {
+		return card
+	}
+	cardJSONs, err := avatar.CharacterData(data)
+	if err != nil || cardJSONs["ccv3"] == nil {
+		return card
+	}
+	var fields cardV3Fields
+	if err := sonicx.Config.Unmarshal(cardJSONs["ccv3"], &fields); err != nil {
+		return card
+	}
+	for _, source := range fields.Data.Source {
+		if stringsx.IsNotBlank(source) && !slices.Contains(card.Source, source) {
+			card.Source = append(card.Source, source)
+		}
+	}
+	card.CreatorNotesMultilingual = fields.Data.CreatorNotesMultilingual
+	return card
+}
+
 // CreateBinderFromJSON parses the JSON metadata response from the source, and creates a MetadataBinder
 // Optionally, the path to the character ID can be specified, for overriding the character ID
 func (f *BaseFetcher) CreateBinderFromJSON(characterID string, response string, pathCharacterID ...any) (*fetcher.MetadataBinder, error) {
@@ -114,3 +201,41 @@ func (f *BaseFetcher) CreateBinderFromJSON(characterID string, response string,
 		JsonResponse:  jsonResponse,
 	}, nil
 }
+
+// optionalCount returns the count stored in the node (nil if the platform does not provide it)
+func optionalCount(node *sonicx.Wrap) *int64 {
+	if !node.Exists() {
+		return nil
+	}
+	count := node.Integer64()
+	return &count
+}
+
+// optionalRating returns the rating stored in the node, normalized from the given scale (nil if the platform does not provide it)
+func optionalRating(node *sonicx.Wrap, scale float64) *float64 {
+	if !node.Exists() {
+		return nil
+	}
+	return models.NormalizeRating(node.Float64(), scale)
+}
+
+// ratingFromFlag returns the rating of a platform NSFW flag (RatingUnknown if the platform does not provide it)
+func ratingFromFlag(node *sonicx.Wrap) models.Rating {
+	switch strings.TrimSpace(node.Raw()) {
+	case "true":
+		return models.RatingNSFW
+	case "false":
+		return models.RatingSFW
+	default:
+		return models.RatingUnknown
+	}
+}
+
+// entryCount returns the number of entries of the book node (0 if the entries are missing or not an array)
+func entryCount(bookNode *sonicx.Wrap) int {
+	entries, err := bookNode.Get("entries").ArrayUseNode()
+	if err != nil {
+		return 0
+	}
+	return len(entries)
+}
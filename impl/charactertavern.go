@@ -11,7 +11,6 @@ import (
 	"github.com/r3dpixel/card-fetcher/fetcher"
 	"github.com/r3dpixel/card-fetcher/models"
 	"github.com/r3dpixel/card-fetcher/source"
-	"github.com/r3dpixel/card-parser/png"
 	"github.com/r3dpixel/card-parser/property"
 	"github.com/r3dpixel/toolkit/reqx"
 	"github.com/r3dpixel/toolkit/slicesx"
@@ -106,6 +105,7 @@ func (f *characterTavernFetcher) FetchCardInfo(metadataBinder *fetcher.MetadataB
 		CreateTime:    timestamp.ParseF(characterTavernDateFormat, cardNode.Get("createdAt").String(), trace.URL, metadataBinder.NormalizedURL),
 		UpdateTime:    timestamp.ParseF(characterTavernDateFormat, cardNode.Get("lastUpdatedAt").String(), trace.URL, metadataBinder.NormalizedURL),
 		IsForked:      false,
+		Rating:        ratingFromFlag(cardNode.Get("isNSFW")),
 		Tags:          resolvedTags,
 	}, nil
 }
@@ -122,12 +122,27 @@ func (f *characterTavernFetcher) FetchCreatorInfo(metadataBinder *fetcher.Metada
 	}, nil
 }
 
+// FetchStats extracts the engagement statistics from the metadata
+func (f *characterTavernFetcher) FetchStats(metadataBinder *fetcher.MetadataBinder) (*models.Stats, error) {
+	cardNode := metadataBinder.Get("card")
+	return &models.Stats{
+		Likes:     optionalCount(cardNode.Get("likes")),
+		Downloads: optionalCount(cardNode.Get("analytics_downloads")),
+		Views:     optionalCount(cardNode.Get("analytics_views")),
+		Messages:  optionalCount(cardNode.Get("analytics_messages")),
+	}, nil
+}
+
 // FetchCharacterCard retrieves card for given url
-func (f *characterTavernFetcher) FetchCharacterCard(binder *fetcher.Binder) (*png.CharacterCard, error) {
-	// Fetch avatar
-	rawCard, err := png.FromURL(f.client, fmt.Sprintf(characterTavernAvatarURL, binder.CharacterID)).LastLongest().Get()
+func (f *characterTavernFetcher) FetchCharacterCard(binder *fetcher.Binder) (*fetcher.Card, error) {
+	// Fetch avatar (generated or placeholder avatar if the download fails)
+	rawCard, fallback, err := f.fetchAvatar(binder, fetcher.AvatarChain{
+		URLs:    []string{fmt.Sprintf(characterTavernAvatarURL, binder.CharacterID)},
+		Name:    binder.GetByPath("card", "inChatName").String(),
+		Longest: true,
+	})
 	if err != nil {
-		return nil, fetcher.NewError(err, fetcher.FetchAvatarErr)
+		return nil, err
 	}
 
 	// Decode card
@@ -139,8 +154,9 @@ func (f *characterTavernFetcher) FetchCharacterCard(binder *fetcher.Binder) (*pn
 	// Extract card node
 	cardNode := binder.Get("card")
 
-	// Update character sheet fields
+	// Update character sheet fields (tracing the fields changed from the PNG)
 	sheet := characterCard.Sheet
+	pngValues := models.SheetFieldValues(sheet)
 	sheet.Description.SetIf(cardNode.Get("definition_character_description").String())
 	sheet.Personality.SetIf(cardNode.Get("definition_personality").String())
 	sheet.Scenario.SetIf(cardNode.Get("definition_scenario").String())
@@ -164,9 +180,10 @@ func (f *characterTavernFetcher) FetchCharacterCard(binder *fetcher.Binder) (*pn
 
 	// Update alternate greetings
 	sheet.AlternateGreetings = slicesx.DeduplicateStable(greetings, sheet.AlternateGreetings)
+	binder.TraceMerge(pngValues, sheet)
 
 	// Return the parsed PNG sheet
-	return characterCard, nil
+	return f.newCard(binder, rawCard, characterCard, fallback), nil
 }
 
 // CharacterID overrides the GetCharacterID behavior to account for allowed spaces in the URL
@@ -30,6 +30,7 @@ import (
 const (
 	chubDomain      string = "chub.ai"                                         // ChubAI domain
 	chubPath        string = "characters/"                                     // Path to characters on ChubAI
+	chubBookPath    string = "lorebooks/"                                      // Path to lorebooks on ChubAI
 	chubApiURL      string = "https://api.chub.ai/api/characters/%s?full=true" // Public API for retrieving metadata
 	chubApiBookURL  string = "https://api.chub.ai/api/lorebooks/%s?full=true"  // Public API for retrieving books
 	chubApiUsersURL string = "https://api.chub.ai/api/users/%s"
@@ -44,6 +45,10 @@ const (
 var (
 	// Regexp for extracting book URLs from the character description
 	bookRegexp = regexp.MustCompile(`lorebooks/([^"\s<>()]+)`)
+	// Regexps for the parent references of the fork labels (ChubAI character URL or path, hashed full path, project ID)
+	chubForkURLRegexp  = regexp.MustCompile(`(?:chub\.ai/|^/?)characters/([\w-]+/[\w.-]+?)/?(?:[?#\s]|$)`)
+	chubForkPathRegexp = regexp.MustCompile(`^[\w-]+/[\w.-]+-[0-9a-f]{12}$`)
+	chubForkIDRegexp   = regexp.MustCompile(`^\d+$`)
 )
 
 // ChubAIBuilder builder for ChubAI fetcher
@@ -100,14 +105,20 @@ func (f *chubAIFetcher) FetchCardInfo(metadataBinder *fetcher.MetadataBinder) (*
 	// Check if the character is forked (iterate through labels to find "forked")
 	forked := sonicx.ArrayToSlice(
 		node.Get("labels"),
-		func(s string) bool {
-			return strings.ToLower(s) == "forked"
+		func(label chubLabel) bool {
+			return strings.ToLower(label.title) == "forked"
 		},
-		func(wrap *sonicx.Wrap) string {
-			return wrap.Get("title").String()
+		func(wrap *sonicx.Wrap) chubLabel {
+			return chubLabel{title: wrap.Get("title").String(), description: wrap.Get("description").String()}
 		},
 	)
 
+	// Extract the parent of the forked character (the label description references the parent)
+	var fork *models.ForkInfo
+	if len(forked) > 0 {
+		fork = f.forkInfo(forked[0].description)
+	}
+
 	// Return the card info
 	return &models.CardInfo{
 		NormalizedURL: metadataBinder.NormalizedURL,
@@ -119,11 +130,44 @@ func (f *chubAIFetcher) FetchCardInfo(metadataBinder *fetcher.MetadataBinder) (*
 		Tagline:       node.Get("tagline").String(),
 		CreateTime:    timestamp.ParseF(chubAiDateFormat, node.Get("createdAt").String(), trace.URL, metadataBinder.NormalizedURL),
 		UpdateTime:    timestamp.ParseF(chubAiDateFormat, node.Get("lastActivityAt").String(), trace.URL, metadataBinder.NormalizedURL),
-		IsForked:      len(forked) > 0,
+		IsForked:      fork != nil,
+		Fork:          fork,
+		Rating:        f.rating(node),
 		Tags:          models.TagsFromJsonArray(node.Get("topics"), sonicx.WrapString),
 	}, nil
 }
 
+// rating returns the rating of the card from the NSFW flags (an NSFW avatar makes the card NSFW)
+func (f *chubAIFetcher) rating(node fetcher.JsonResponse) models.Rating {
+	return ratingFromFlag(node.Get("nsfw")).Max(ratingFromFlag(node.Get("nsfw_image")))
+}
+
+// chubLabel represents a label of a ChubAI character
+type chubLabel struct {
+	title       string
+	description string
+}
+
+// forkInfo extracts the parent of a forked character from the fork label description
+// The description references the parent by ChubAI URL or path, by hashed full path (creator/name-hash) or by project ID
+// (other descriptions, such as free text containing slashes, leave the parent unknown)
+func (f *chubAIFetcher) forkInfo(description string) *models.ForkInfo {
+	fork := &models.ForkInfo{Source: f.SourceID()}
+	reference := strings.TrimSpace(description)
+	// Resolve the reference
+	switch {
+	case chubForkURLRegexp.MatchString(reference):
+		fork.CharacterID = chubForkURLRegexp.FindStringSubmatch(reference)[1]
+		fork.URL = f.DirectURL(fork.CharacterID)
+	case chubForkPathRegexp.MatchString(reference):
+		fork.CharacterID = reference
+		fork.URL = f.DirectURL(reference)
+	case chubForkIDRegexp.MatchString(reference):
+		fork.PlatformID = reference
+	}
+	return fork
+}
+
 // FetchCreatorInfo fetches the creator info from the source
 func (f *chubAIFetcher) FetchCreatorInfo(metadataBinder *fetcher.MetadataBinder) (*models.CreatorInfo, error) {
 	// Extract the displayName from the CharacterID
@@ -149,6 +193,19 @@ func (f *chubAIFetcher) FetchCreatorInfo(metadataBinder *fetcher.MetadataBinder)
 	}, nil
 }
 
+// FetchStats extracts the engagement statistics from the metadata (ratings are already on a 0-5 scale)
+func (f *chubAIFetcher) FetchStats(metadataBinder *fetcher.MetadataBinder) (*models.Stats, error) {
+	node := metadataBinder.Get("node")
+	return &models.Stats{
+		Likes:       optionalCount(node.Get("starCount")),
+		Downloads:   optionalCount(node.Get("downloadCount")),
+		Chats:       optionalCount(node.Get("nChats")),
+		Messages:    optionalCount(node.Get("nMessages")),
+		Rating:      optionalRating(node.Get("rating"), models.MaxRating),
+		RatingCount: optionalCount(node.Get("ratingCount")),
+	}, nil
+}
+
 // FetchBookResponses fetches the book responses from the source
 func (f *chubAIFetcher) FetchBookResponses(metadataBinder *fetcher.MetadataBinder) (*fetcher.BookBinder, error) {
 	// Extract the book IDs from the metadataBinder
@@ -168,6 +225,23 @@ func (f *chubAIFetcher) FetchBookResponses(metadataBinder *fetcher.MetadataBinde
 	linkedBookResponses, linkedBookUpdateTime := f.retrieveLinkedBooks(metadataBinder, bookIDs)
 	// Fetch the aux book responses from the description and tagline
 	auxBookResponses, auxBookUpdateTime := f.retrieveAuxBooks(metadataBinder, bookIDs)
+
+	// Describe the books (embedded, linked, then auxiliary)
+	var books []models.BookInfo
+	if embeddedNode := metadataBinder.GetByPath("node", "definition", "embedded_lorebook"); entryCount(embeddedNode) > 0 {
+		books = append(books, models.BookInfo{
+			Origin:     models.BookOriginEmbedded,
+			Name:       embeddedNode.Get("name").String(),
+			EntryCount: entryCount(embeddedNode),
+		})
+	}
+	for _, bookResponse := range linkedBookResponses {
+		books = append(books, f.bookInfo(metadataBinder, bookResponse, models.BookOriginLinked))
+	}
+	for _, bookResponse := range auxBookResponses {
+		books = append(books, f.bookInfo(metadataBinder, bookResponse, models.BookOriginAuxiliary))
+	}
+
 	// Merge the book responses
 	linkedBookResponses = append(linkedBookResponses, auxBookResponses...)
 
@@ -175,11 +249,31 @@ func (f *chubAIFetcher) FetchBookResponses(metadataBinder *fetcher.MetadataBinde
 	return &fetcher.BookBinder{
 		Responses:  linkedBookResponses,
 		UpdateTime: max(linkedBookUpdateTime, auxBookUpdateTime),
+		Books:      books,
 	}, nil
 }
 
+// bookInfo describes a linked/auxiliary book from its API response (the creator is the first segment of the book path)
+func (f *chubAIFetcher) bookInfo(metadataBinder *fetcher.MetadataBinder, bookResponse fetcher.JsonResponse, origin models.BookOrigin) models.BookInfo {
+	node := bookResponse.Get("node")
+	fullPath := strings.Trim(node.Get("fullPath").String(), symbols.Slash)
+	creator, _, _ := strings.Cut(fullPath, symbols.Slash)
+	info := models.BookInfo{
+		Origin:     origin,
+		PlatformID: strings.TrimSpace(node.Get("id").String()),
+		Name:       node.GetByPath("definition", "name").String(),
+		Creator:    creator,
+		EntryCount: entryCount(node.GetByPath("definition", "embedded_lorebook")),
+		UpdateTime: timestamp.ParseF(chubAiDateFormat, node.Get("lastActivityAt").String(), trace.URL, metadataBinder.DirectURL),
+	}
+	if stringsx.IsNotBlank(fullPath) {
+		info.URL = path.Join(chubDomain, chubBookPath, fullPath)
+	}
+	return info
+}
+
 // FetchCharacterCard fetches the character card from the source
-func (f *chubAIFetcher) FetchCharacterCard(binder *fetcher.Binder) (*png.CharacterCard, error) {
+func (f *chubAIFetcher) FetchCharacterCard(binder *fetcher.Binder) (*fetcher.Card, error) {
 	// Extract the root node
 	node := binder.Get("node")
 	// Extract the character card URL
@@ -187,10 +281,13 @@ func (f *chubAIFetcher) FetchCharacterCard(binder *fetcher.Binder) (*png.Charact
 	// Extract the backup URL
 	backupURL := node.Get("avatar_url").String()
 
-	// Fetch the character card from the API (in order of preference: max_res_url, fixed max_res_url, avatar_url)
-	rawCard, err := png.FromURL(f.client, chubCardURL, f.fixAvatarURL(chubCardURL), backupURL).LastVersion().Get()
+	// Fetch the character card from the API (in order of preference: max_res_url, fixed max_res_url, avatar_url, then generated or placeholder avatar)
+	rawCard, fallback, err := f.fetchAvatar(binder, fetcher.AvatarChain{
+		URLs: []string{chubCardURL, f.fixAvatarURL(chubCardURL), backupURL},
+		Name: node.GetByPath("definition", "name").String(),
+	})
 	if err != nil {
-		return nil, fetcher.NewError(err, fetcher.FetchAvatarErr)
+		return nil, err
 	}
 
 	// Decode the character card
@@ -199,17 +296,22 @@ func (f *chubAIFetcher) FetchCharacterCard(binder *fetcher.Binder) (*png.Charact
 		return nil, fetcher.NewError(err, fetcher.DecodeErr)
 	}
 
-	// Update the character card with the definition data
+	// Update the character card with the definition data (tracing the fields changed from the PNG)
+	pngValues := models.SheetFieldValues(characterCard.Sheet)
 	definitionNode := node.Get("definition")
 	if err := f.updateFieldsWithFallback(characterCard, definitionNode); err != nil {
 		return nil, fetcher.NewError(err, fetcher.MalformedCardDataErr)
 	}
+	binder.TraceMerge(pngValues, characterCard.Sheet)
 
 	// Merge the books into the character card (including the embedded book)
-	characterCard.CharacterBook = f.mergeBooks(definitionNode.Get("embedded_lorebook").Raw(), binder)
+	var worldBooks []*character.Book
+	characterCard.CharacterBook, worldBooks = f.mergeBooks(definitionNode.Get("embedded_lorebook").Raw(), binder)
 
-	// Return the character card
-	return characterCard, nil
+	// Return the character card (with the books kept separate)
+	card := f.newCard(binder, rawCard, characterCard, fallback)
+	card.WorldBooks = worldBooks
+	return card, nil
 }
 
 // updateFieldsWithFallback updates the fields of the character card with the data from the definition node,
@@ -238,14 +340,15 @@ func (f *chubAIFetcher) updateFieldsWithFallback(characterCard *png.CharacterCar
 	return nil
 }
 
-// mergeBooks merges all books from the binder (including the embedded book) into a single Book object
-func (f *chubAIFetcher) mergeBooks(embeddedBookRaw string, binder *fetcher.Binder) *character.Book {
+// mergeBooks merges all books from the binder (including the embedded book) with the book strategy of the binder
+// The books kept separate by the strategy are returned with the merged book
+func (f *chubAIFetcher) mergeBooks(embeddedBookRaw string, binder *fetcher.Binder) (*character.Book, []*character.Book) {
 	// Create a new BookMerger
-	merger := character.NewBookMerger()
+	merger := binder.NewBookMerger()
 
 	// Parse and merge the embedded book
 	if embeddedBook, err := f.parseEmbeddedBook(embeddedBookRaw); err == nil {
-		merger.AppendBook(embeddedBook)
+		merger.AppendBook(embeddedBook, fetcher.BookSource{Origin: fetcher.BookOriginEmbedded})
 	} else {
 		log.Warn().
 			Err(err).
@@ -254,22 +357,38 @@ func (f *chubAIFetcher) mergeBooks(embeddedBookRaw string, binder *fetcher.Binde
 			Msg("Could not parse embedded book")
 	}
 
+	// Collect the IDs of the linked books (the other books are referenced in the description and tagline)
+	linkedIDs := sonicx.ArrayToMap(
+		binder.GetByPath("node", "related_lorebooks"),
+		func(string) bool { return true },
+		sonicx.WrapString,
+	)
+
 	// Iterate through all the book responses
 	for _, bookResponse := range binder.Responses {
 		// Parse and merge the linked/auxiliary book
-		if book, bookName, err := f.parseBookResponse(bookResponse); err == nil {
-			merger.AppendBook(book)
-		} else {
+		book, bookName, err := f.parseBookResponse(bookResponse)
+		if err != nil {
 			log.Warn().
 				Err(err).
 				Str(trace.SOURCE, string(f.sourceID)).
 				Str(trace.URL, binder.DirectURL).
 				Str("bookName", bookName).
 				Msg("Could not parse linked book")
+			continue
+		}
+		source := fetcher.BookSource{
+			Origin: fetcher.BookOriginAuxiliary,
+			ID:     strings.TrimSpace(bookResponse.GetByPath("node", "id").String()),
+			Name:   bookName,
+		}
+		if linkedIDs.Has(source.ID) {
+			source.Origin = fetcher.BookOriginLinked
 		}
+		merger.AppendBook(book, source)
 	}
 
-	// Return the merged book
+	// Return the merged book and the separate books
 	return merger.Build()
 }
 
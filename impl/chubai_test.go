@@ -0,0 +1,38 @@
+package impl
+
+import (
+	"testing"
+
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-fetcher/source"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChubAIFetcher_ForkInfo(t *testing.T) {
+	f := NewChubAIFetcher(nil).(*chubAIFetcher)
+
+	t.Run("should resolve the parent of a real fork", func(t *testing.T) {
+		expected := &models.ForkInfo{
+			Source:      source.ChubAI,
+			CharacterID: "Anonymous/test-f26406a9718a",
+			URL:         "chub.ai/characters/Anonymous/test-f26406a9718a",
+		}
+
+		assert.Equal(t, expected, f.forkInfo("https://chub.ai/characters/Anonymous/test-f26406a9718a"))
+		assert.Equal(t, expected, f.forkInfo("Forked from https://venus.chub.ai/characters/Anonymous/test-f26406a9718a/ (thanks!)"))
+		assert.Equal(t, expected, f.forkInfo("characters/Anonymous/test-f26406a9718a"))
+		assert.Equal(t, expected, f.forkInfo(" Anonymous/test-f26406a9718a "))
+		assert.Equal(t, &models.ForkInfo{Source: source.ChubAI, PlatformID: "3186564"}, f.forkInfo("3186564"))
+	})
+
+	t.Run("should not resolve descriptions containing slashes", func(t *testing.T) {
+		unknown := &models.ForkInfo{Source: source.ChubAI}
+
+		assert.Equal(t, unknown, f.forkInfo("and/or"))
+		assert.Equal(t, unknown, f.forkInfo("Based on the TV/movie series"))
+		assert.Equal(t, unknown, f.forkInfo("my characters/stuff"))
+		assert.Equal(t, unknown, f.forkInfo("https://example.com/characters/a/b/c"))
+		assert.Equal(t, unknown, f.forkInfo("N/A"))
+		assert.Equal(t, unknown, f.forkInfo(""))
+	})
+}
@@ -15,7 +15,6 @@ import (
 	"github.com/r3dpixel/card-fetcher/fetcher"
 	"github.com/r3dpixel/card-fetcher/models"
 	"github.com/r3dpixel/card-fetcher/source"
-	"github.com/r3dpixel/card-parser/png"
 	"github.com/r3dpixel/card-parser/property"
 	"github.com/r3dpixel/chromex"
 	"github.com/r3dpixel/toolkit/reqx"
@@ -28,11 +27,10 @@ const (
 	jannyAIUuidLength int    = 36                              // JannyAI UUID length
 	jannyAIDateFormat string = "2006-01-02 15:04:05.999999-07" // JannyAI date format
 
-	jannyAIDomain          string = "jannyai.com"                                  // Domain for JannyAI
-	jannyAIPath            string = "characters/"                                  // Path for JannyAI
-	jannyAIApiURL          string = "https://api.jannyai.com/api/v1/characters/%s" // API URL for JannyAI
-	jannyAIAvatarURL       string = "https://image.jannyai.com/bot-avatars/%s"     // Avatar URL for JannyAI
-	jannyAIPlaceholderSize int    = 512                                            // Placeholder avatar size for JannyAI
+	jannyAIDomain    string = "jannyai.com"                                  // Domain for JannyAI
+	jannyAIPath      string = "characters/"                                  // Path for JannyAI
+	jannyAIApiURL    string = "https://api.jannyai.com/api/v1/characters/%s" // API URL for JannyAI
+	jannyAIAvatarURL string = "https://image.jannyai.com/bot-avatars/%s"     // Avatar URL for JannyAI
 )
 
 // JannyAIInterceptor handles cookie extraction using chromedp for JannyAI.
@@ -130,6 +128,7 @@ func (f *jannyAIFetcher) FetchCardInfo(metadataBinder *fetcher.MetadataBinder) (
 		CreateTime:    createTime,
 		UpdateTime:    timestamp.Nano(time.Now().Truncate(24 * time.Hour).UnixNano()),
 		IsForked:      false,
+		Rating:        ratingFromFlag(metadataBinder.Get("isNsfw")),
 		Tags:          tags,
 	}, nil
 }
@@ -145,16 +144,14 @@ func (f *jannyAIFetcher) FetchCreatorInfo(metadataBinder *fetcher.MetadataBinder
 }
 
 // FetchCharacterCard fetches the character card from the source
-func (f *jannyAIFetcher) FetchCharacterCard(binder *fetcher.Binder) (*png.CharacterCard, error) {
-	// Fetch the character card from the API
-	jannyAIAvatarURL := fmt.Sprintf(jannyAIAvatarURL, binder.Get("avatar").String())
-	rawCard, err := png.FromURL(f.client, jannyAIAvatarURL).LastVersion().Get()
-	if err != nil {
-		// If the API call fails, return a placeholder character card
-		rawCard, err = png.PlaceholderCharacterCard(jannyAIPlaceholderSize)
-	}
+func (f *jannyAIFetcher) FetchCharacterCard(binder *fetcher.Binder) (*fetcher.Card, error) {
+	// Fetch the character avatar from the API (generated or placeholder avatar if the download fails)
+	rawCard, fallback, err := f.fetchAvatar(binder, fetcher.AvatarChain{
+		URLs: []string{fmt.Sprintf(jannyAIAvatarURL, binder.Get("avatar").String())},
+		Name: binder.Get("name").String(),
+	})
 	if err != nil {
-		return nil, fetcher.NewError(err, fetcher.FetchAvatarErr)
+		return nil, err
 	}
 
 	// Decode the character card
@@ -163,6 +160,9 @@ func (f *jannyAIFetcher) FetchCharacterCard(binder *fetcher.Binder) (*png.Charac
 		return nil, fetcher.NewError(err, fetcher.DecodeErr)
 	}
 
+	// Keep the field values of the PNG sheet (replaced by the API fields)
+	pngValues := models.SheetFieldValues(characterCard.Sheet)
+
 	// Update the character card fields
 	characterCard.Description = property.String(binder.Get("personality").String())
 	characterCard.Scenario = property.String(binder.Get("scenario").String())
@@ -170,8 +170,11 @@ func (f *jannyAIFetcher) FetchCharacterCard(binder *fetcher.Binder) (*png.Charac
 	characterCard.MessageExamples = property.String(binder.Get("exampleDialogs").String())
 	characterCard.CreatorNotes = property.String(binder.Get("description").String())
 
+	// Trace the fields changed from the PNG
+	binder.TraceMerge(pngValues, characterCard.Sheet)
+
 	// Return the character card
-	return characterCard, nil
+	return f.newCard(binder, rawCard, characterCard, fallback), nil
 }
 
 // IsSourceUp checks if the source is up
@@ -7,8 +7,10 @@ import (
 	"github.com/r3dpixel/card-fetcher/fetcher"
 	"github.com/r3dpixel/card-fetcher/models"
 	"github.com/r3dpixel/card-fetcher/source"
+	"github.com/r3dpixel/card-parser/character"
 	"github.com/r3dpixel/card-parser/png"
 	"github.com/r3dpixel/toolkit/reqx"
+	"github.com/r3dpixel/toolkit/timestamp"
 )
 
 // MockBuilder builder for mock fetchers
@@ -40,8 +42,14 @@ type MockData struct {
 	CardInfoErr      error
 	CreatorInfo      *models.CreatorInfo
 	CreatorErr       error
+	Stats            *models.Stats
+	StatsErr         error
 	CharacterCard    *png.CharacterCard
 	CharacterCardErr error
+	AvatarFallback   models.AvatarFallback
+	Provenance       *models.Provenance
+	LinkedBooks      []*character.Book
+	Books            []models.BookInfo
 }
 
 // mockFetcher is a fetcher that returns the mock data
@@ -86,7 +94,42 @@ func (f *mockFetcher) FetchCreatorInfo(metadataBinder *fetcher.MetadataBinder) (
 	return f.MockData.CreatorInfo, f.MockData.CreatorErr
 }
 
+// FetchStats extracts the engagement statistics (the mocked error first, then empty statistics if none are mocked)
+func (f *mockFetcher) FetchStats(metadataBinder *fetcher.MetadataBinder) (*models.Stats, error) {
+	if f.MockData.StatsErr != nil {
+		return nil, f.MockData.StatsErr
+	}
+	if f.MockData.Stats == nil {
+		return f.BaseFetcher.FetchStats(metadataBinder)
+	}
+	stats := f.MockData.Stats.Clone()
+	return &stats, nil
+}
+
+// FetchBookResponses returns the mock books (updated at the latest update time of the books, like the platforms)
+func (f *mockFetcher) FetchBookResponses(metadataBinder *fetcher.MetadataBinder) (*fetcher.BookBinder, error) {
+	var updateTime timestamp.Nano
+	for _, book := range f.MockData.Books {
+		updateTime = max(updateTime, book.UpdateTime)
+	}
+	return &fetcher.BookBinder{Books: f.MockData.Books, UpdateTime: updateTime}, nil
+}
+
 // FetchCharacterCard fetches the character card from the source
-func (f *mockFetcher) FetchCharacterCard(binder *fetcher.Binder) (*png.CharacterCard, error) {
-	return f.MockData.CharacterCard, f.MockData.CharacterCardErr
+func (f *mockFetcher) FetchCharacterCard(binder *fetcher.Binder) (*fetcher.Card, error) {
+	binder.Provenance = f.MockData.Provenance
+	// Merge the linked books into the character book (with the book strategy of the binder)
+	var worldBooks []*character.Book
+	if len(f.MockData.LinkedBooks) > 0 && f.MockData.CharacterCard != nil {
+		merger := binder.NewBookMerger()
+		merger.AppendBook(f.MockData.CharacterCard.CharacterBook, fetcher.BookSource{Origin: fetcher.BookOriginEmbedded})
+		for _, book := range f.MockData.LinkedBooks {
+			merger.AppendBook(book, fetcher.BookSource{Origin: fetcher.BookOriginLinked})
+		}
+		f.MockData.CharacterCard.CharacterBook, worldBooks = merger.Build()
+	}
+	if f.MockData.CharacterCardErr != nil {
+		return nil, f.MockData.CharacterCardErr
+	}
+	return &fetcher.Card{CharacterCard: f.MockData.CharacterCard, AvatarFallback: f.MockData.AvatarFallback, WorldBooks: worldBooks}, nil
 }
@@ -11,7 +11,6 @@ import (
 	"github.com/r3dpixel/card-fetcher/models"
 	"github.com/r3dpixel/card-fetcher/source"
 	"github.com/r3dpixel/card-parser/character"
-	"github.com/r3dpixel/card-parser/png"
 	"github.com/r3dpixel/card-parser/property"
 	"github.com/r3dpixel/toolkit/reqx"
 	"github.com/r3dpixel/toolkit/sonicx"
@@ -139,16 +138,16 @@ func (f *nyaiMeFetcher) FetchCreatorInfo(metadataBinder *fetcher.MetadataBinder)
 }
 
 // FetchCharacterCard fetches the character card from the source
-func (f *nyaiMeFetcher) FetchCharacterCard(binder *fetcher.Binder) (*png.CharacterCard, error) {
+func (f *nyaiMeFetcher) FetchCharacterCard(binder *fetcher.Binder) (*fetcher.Card, error) {
 	// Extract the post node
 	postNode := binder.Get("Post")
-	// Retrieve the avatar URL
-	nyaiMeCardURL := postNode.Get("ImageURL").String()
-	// Fetch the character card from the API
-	rawCard, err := png.FromURL(f.client, nyaiMeCardURL).LastVersion().Get()
-	// If the characterCard or the sheet is nil, then the export failed
+	// Fetch the character card from the API (required, the card data is only in the PNG)
+	rawCard, fallback, err := f.fetchAvatar(binder, fetcher.AvatarChain{
+		URLs:     []string{postNode.Get("ImageURL").String()},
+		Required: true,
+	})
 	if err != nil {
-		return nil, fetcher.NewError(err, fetcher.FetchAvatarErr)
+		return nil, err
 	}
 	// Decode the character card
 	characterCard, err := rawCard.Decode()
@@ -164,7 +163,7 @@ func (f *nyaiMeFetcher) FetchCharacterCard(binder *fetcher.Binder) (*png.Charact
 	))
 
 	// Return the character card
-	return characterCard, nil
+	return f.newCard(binder, rawCard, characterCard, fallback), nil
 }
 
 // downloadRequestBody - create the body for the POST download request (based on characterID)
@@ -9,7 +9,6 @@ import (
 	"github.com/r3dpixel/card-fetcher/fetcher"
 	"github.com/r3dpixel/card-fetcher/models"
 	"github.com/r3dpixel/card-fetcher/source"
-	"github.com/r3dpixel/card-parser/png"
 	"github.com/r3dpixel/card-parser/property"
 	"github.com/r3dpixel/toolkit/reqx"
 	"github.com/r3dpixel/toolkit/sonicx"
@@ -111,12 +110,14 @@ func (f *pephopFetcher) FetchCreatorInfo(metadataBinder *fetcher.MetadataBinder)
 }
 
 // FetchCharacterCard fetches the character card from the source
-func (f *pephopFetcher) FetchCharacterCard(binder *fetcher.Binder) (*png.CharacterCard, error) {
-	// Fetch the character avatar from the API
-	pepHopAvatarURL := fmt.Sprintf(pephopAvatarURL, binder.Get("avatar").String())
-	rawCard, err := png.FromURL(f.client, pepHopAvatarURL).LastVersion().Get()
+func (f *pephopFetcher) FetchCharacterCard(binder *fetcher.Binder) (*fetcher.Card, error) {
+	// Fetch the character avatar from the API (generated or placeholder avatar if the download fails)
+	rawCard, fallback, err := f.fetchAvatar(binder, fetcher.AvatarChain{
+		URLs: []string{fmt.Sprintf(pephopAvatarURL, binder.Get("avatar").String())},
+		Name: binder.Get("name").String(),
+	})
 	if err != nil {
-		return nil, fetcher.NewError(err, fetcher.FetchAvatarErr)
+		return nil, err
 	}
 
 	// Decode the character card
@@ -125,6 +126,9 @@ func (f *pephopFetcher) FetchCharacterCard(binder *fetcher.Binder) (*png.Charact
 		return nil, fetcher.NewError(err, fetcher.DecodeErr)
 	}
 
+	// Keep the field values of the PNG sheet (replaced by the API fields)
+	pngValues := models.SheetFieldValues(characterCard.Sheet)
+
 	// Update the character card fields
 	characterCard.Description = property.String(binder.Get("personality").String())
 	characterCard.Scenario = property.String(binder.Get("scenario").String())
@@ -132,8 +136,11 @@ func (f *pephopFetcher) FetchCharacterCard(binder *fetcher.Binder) (*png.Charact
 	characterCard.MessageExamples = property.String(binder.Get("example_dialogs").String())
 	characterCard.CreatorNotes = property.String(binder.GetByPath("introduction", "characterIntroduction").String())
 
+	// Trace the fields changed from the PNG
+	binder.TraceMerge(pngValues, characterCard.Sheet)
+
 	// Return the character card
-	return characterCard, nil
+	return f.newCard(binder, rawCard, characterCard, fallback), nil
 }
 
 // CharacterID returns the characterID for pephop source
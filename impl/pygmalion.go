@@ -13,7 +13,6 @@ import (
 	"github.com/r3dpixel/card-fetcher/models"
 	"github.com/r3dpixel/card-fetcher/source"
 	"github.com/r3dpixel/card-parser/character"
-	"github.com/r3dpixel/card-parser/png"
 	"github.com/r3dpixel/card-parser/property"
 	"github.com/r3dpixel/toolkit/cred"
 	"github.com/r3dpixel/toolkit/reqx"
@@ -38,6 +37,8 @@ const (
 	pygmalionAuthURL       = "https://auth.pygmalion.chat/session"                                                 // Authentication URL for Pygmalion
 	pygmalionCardExportURL = "https://server.pygmalion.chat/api/export/character/%s/v2"                            // Avatar Download URL for Pygmalion (contains chara metadata - PNG V2)
 	pygmalionLinkedBookURL = "https://server.pygmalion.chat/galatea.v1.UserLorebookService/LorebooksByCharacterId" // Book Download URL for Pygmalion
+
+	pygmalionNotFoundCode = "not_found" // Error code returned by the Pygmalion API for missing characters
 )
 
 // PygmalionOpts options for PygmalionBuilder
@@ -105,6 +106,24 @@ func (f *pygmalionFetcher) FetchMetadataResponse(characterID string) (*req.Respo
 		Post(pygmalionApiURL)
 }
 
+// IsRemoved checks if the metadata response is the Pygmalion not found error payload
+func (f *pygmalionFetcher) IsRemoved(response *req.Response) bool {
+	// Missing responses (network errors) never confirm a removal
+	if response == nil || response.Response == nil {
+		return false
+	}
+	// Successful responses never confirm a removal
+	if response.IsSuccessState() {
+		return false
+	}
+	// Parse the error payload and check the error code
+	code, err := sonicx.GetFromString(response.String(), "code")
+	if err != nil {
+		return f.BaseFetcher.IsRemoved(response)
+	}
+	return code.String() == pygmalionNotFoundCode
+}
+
 // CreateBinder creates a MetadataBinder from the metadata response
 func (f *pygmalionFetcher) CreateBinder(characterID string, metadataResponse string) (*fetcher.MetadataBinder, error) {
 	return f.CreateBinderFromJSON(characterID, metadataResponse, "character", "id")
@@ -127,6 +146,7 @@ func (f *pygmalionFetcher) FetchCardInfo(metadataBinder *fetcher.MetadataBinder)
 		CreateTime:    timestamp.ConvertToNano(timestamp.Seconds(characterNode.Get("createdAt").Integer64())),
 		UpdateTime:    timestamp.ConvertToNano(timestamp.Seconds(characterNode.Get("updatedAt").Integer64())),
 		IsForked:      false,
+		Rating:        ratingFromFlag(characterNode.Get("isSensitive")),
 		Tags:          models.TagsFromJsonArray(characterNode.Get("tags"), sonicx.WrapString),
 	}, nil
 }
@@ -144,6 +164,17 @@ func (f *pygmalionFetcher) FetchCreatorInfo(metadataBinder *fetcher.MetadataBind
 	}, nil
 }
 
+// FetchStats extracts the engagement statistics from the metadata
+func (f *pygmalionFetcher) FetchStats(metadataBinder *fetcher.MetadataBinder) (*models.Stats, error) {
+	characterNode := metadataBinder.Get("character")
+	return &models.Stats{
+		Likes:     optionalCount(characterNode.Get("stars")),
+		Downloads: optionalCount(characterNode.Get("downloads")),
+		Views:     optionalCount(characterNode.Get("views")),
+		Chats:     optionalCount(characterNode.Get("chatCount")),
+	}, nil
+}
+
 // FetchBookResponses fetches the book responses from the source
 func (f *pygmalionFetcher) FetchBookResponses(metadataBinder *fetcher.MetadataBinder) (*fetcher.BookBinder, error) {
 	// Fetch book responses
@@ -168,47 +199,60 @@ func (f *pygmalionFetcher) FetchBookResponses(metadataBinder *fetcher.MetadataBi
 
 	// Parse the book responses
 	parsedResponses := make([]fetcher.JsonResponse, len(bookArray))
+	books := make([]models.BookInfo, len(bookArray))
 	bookUpdateTime := timestamp.Nano(0)
 	for index, bookResult := range bookArray {
 		// Parse the book response
 		bookResponse := sonicx.Of(bookResult)
 		// Extract the updatedAt field
-		updatedAt := bookResponse.Get("updatedAt").Integer64()
+		updatedAt := timestamp.ConvertToNano(timestamp.Seconds(bookResponse.Get("updatedAt").Integer64()))
 		// Save the parsed response
 		parsedResponses[index] = bookResponse
+		// Describe the book (linked, Pygmalion has no book pages)
+		books[index] = models.BookInfo{
+			Origin:     models.BookOriginLinked,
+			PlatformID: strings.TrimSpace(bookResponse.Get("id").String()),
+			Name:       bookResponse.Get("name").String(),
+			Creator:    bookResponse.GetByPath("owner", "displayName").String(),
+			EntryCount: entryCount(bookResponse),
+			UpdateTime: updatedAt,
+		}
 		// Update the book update time
-		bookUpdateTime = max(bookUpdateTime, timestamp.ConvertToNano(timestamp.Seconds(updatedAt)))
+		bookUpdateTime = max(bookUpdateTime, updatedAt)
 	}
 
 	// Return the binder
 	return &fetcher.BookBinder{
 		Responses:  parsedResponses,
 		UpdateTime: bookUpdateTime,
+		Books:      books,
 	}, nil
 }
 
 // FetchCharacterCard fetches the character card from the source
-func (f *pygmalionFetcher) FetchCharacterCard(binder *fetcher.Binder) (*png.CharacterCard, error) {
+func (f *pygmalionFetcher) FetchCharacterCard(binder *fetcher.Binder) (*fetcher.Card, error) {
 	// Fetch the character card
-	characterCard, err := f.fetchCharacterCard(binder)
+	card, err := f.fetchCharacterCard(binder)
 	if err != nil {
 		return nil, err
 	}
 
 	// Parse the book responses
-	characterCard.Sheet.CharacterBook = f.parseBookResponses(binder)
+	card.Sheet.CharacterBook, card.WorldBooks = f.parseBookResponses(binder)
 
 	// Return the character card
-	return characterCard, nil
+	return card, nil
 }
 
 // fetchCharacterCard fetches the character card from the source
-func (f *pygmalionFetcher) fetchCharacterCard(binder *fetcher.Binder) (*png.CharacterCard, error) {
-	// Fetch the avatar
-	avatarUrl := binder.GetByPath("character", "avatarUrl").String()
-	rawCard, err := png.FromURL(f.client, avatarUrl).LastVersion().Get()
+func (f *pygmalionFetcher) fetchCharacterCard(binder *fetcher.Binder) (*fetcher.Card, error) {
+	// Fetch the avatar (generated or placeholder avatar if the download fails, the sheet is exported by the API)
+	rawCard, fallback, err := f.fetchAvatar(binder, fetcher.AvatarChain{
+		URLs: []string{binder.GetByPath("character", "avatarUrl").String()},
+		Name: binder.GetByPath("character", "personality", "name").String(),
+	})
 	if err != nil {
-		return nil, fetcher.NewError(err, fetcher.FetchAvatarErr)
+		return nil, err
 	}
 
 	// Decode the card
@@ -227,6 +271,9 @@ func (f *pygmalionFetcher) fetchCharacterCard(binder *fetcher.Binder) (*png.Char
 		return nil, fetcher.NewError(err, fetcher.FetchCardDataErr)
 	}
 
+	// Keep the field values of the PNG sheet (replaced by the exported sheet)
+	pngValues := models.SheetFieldValues(characterCard.Sheet)
+
 	// Optimization to remove the prefix `{character:` and suffix `}` from the byte response without processing
 	characterCard.Sheet, err = character.FromBytes(bytes[13 : len(bytes)-1])
 	// If the card is nil, then the export failed (error is treated upstream)
@@ -234,20 +281,27 @@ func (f *pygmalionFetcher) fetchCharacterCard(binder *fetcher.Binder) (*png.Char
 		return nil, fetcher.NewError(err, fetcher.MalformedCardDataErr)
 	}
 
+	// Trace the fields changed from the PNG (before the creator notes are cleared, the patcher traces their new value)
+	binder.TraceMerge(pngValues, characterCard.Sheet)
+
 	// Set empty creator notes (description == tagline, and the patcher will set the creator notes to the tagline)
 	characterCard.Sheet.CreatorNotes = property.String("")
 
 	// Return the parsed PNG card
-	return characterCard, nil
+	return f.newCard(binder, rawCard, characterCard, fallback), nil
 }
 
-// parseBookResponses merges the book responses into a single book
-func (f *pygmalionFetcher) parseBookResponses(binder *fetcher.Binder) *character.Book {
+// parseBookResponses merges the book responses with the book strategy of the binder (all the books are linked)
+func (f *pygmalionFetcher) parseBookResponses(binder *fetcher.Binder) (*character.Book, []*character.Book) {
 	// Create the book merger
-	bookMerger := character.NewBookMerger()
+	bookMerger := binder.NewBookMerger()
 
 	// Parse the book responses
-	var books []*character.Book
+	type linkedBook struct {
+		id   string
+		book *character.Book
+	}
+	var books []linkedBook
 	for _, bookResponse := range binder.Responses {
 		// Parse the book
 		var pygBook pygmalionBook
@@ -260,18 +314,18 @@ func (f *pygmalionFetcher) parseBookResponses(binder *fetcher.Binder) *character
 				Msg("Could not parse book")
 			continue
 		}
-		// Convert the pygmalionBook into a character.Book and append it to the books slice
-		books = append(books, pygBook.convert())
+		// Convert the pygmalionBook into a character.Book and append it (with its ID) to the books slice
+		books = append(books, linkedBook{id: strings.TrimSpace(bookResponse.Get("id").String()), book: pygBook.convert()})
 	}
 
 	// Sort the books by name
-	slices.SortFunc(books, func(a, b *character.Book) int {
-		return strings.Compare(string(a.Name), string(b.Name))
+	slices.SortFunc(books, func(a, b linkedBook) int {
+		return strings.Compare(string(a.book.Name), string(b.book.Name))
 	})
 
 	// Merge the books
 	for _, book := range books {
-		bookMerger.AppendBook(book)
+		bookMerger.AppendBook(book.book, fetcher.BookSource{Origin: fetcher.BookOriginLinked, ID: book.id})
 	}
 
 	// Return the merged book
@@ -11,7 +11,6 @@ import (
 	"github.com/r3dpixel/card-fetcher/models"
 	"github.com/r3dpixel/card-fetcher/source"
 	"github.com/r3dpixel/card-parser/character"
-	"github.com/r3dpixel/card-parser/png"
 	"github.com/r3dpixel/card-parser/property"
 	"github.com/r3dpixel/toolkit/reqx"
 	"github.com/r3dpixel/toolkit/sonicx"
@@ -29,6 +28,13 @@ const (
 	wyvernDateFormat string = time.RFC3339Nano                        // Date Format for WyvernChat
 )
 
+// wyvernRatings maps the WyvernChat content ratings to the normalized ratings
+var wyvernRatings = map[string]models.Rating{
+	"none":     models.RatingSFW,
+	"mature":   models.RatingSuggestive,
+	"explicit": models.RatingNSFW,
+}
+
 // WyvernChatBuilder builder for WyvernChat fetcher
 type WyvernChatBuilder struct{}
 
@@ -74,6 +80,18 @@ func (f *wyvernChatFetcher) CreateBinder(characterID string, metadataResponse st
 
 // FetchCardInfo fetches the card info from the source
 func (f *wyvernChatFetcher) FetchCardInfo(metadataBinder *fetcher.MetadataBinder) (*models.CardInfo, error) {
+	// Extract the parent of the forked character (the parent ID is also its character ID)
+	var fork *models.ForkInfo
+	if parentID := metadataBinder.GetByPath("forked_from", "id").String(); stringsx.IsNotBlank(parentID) {
+		fork = &models.ForkInfo{
+			Source:      f.SourceID(),
+			PlatformID:  strings.TrimPrefix(parentID, symbols.Underscore),
+			CharacterID: parentID,
+			URL:         f.DirectURL(parentID),
+		}
+	}
+
+	// Return the card info
 	return &models.CardInfo{
 		NormalizedURL: metadataBinder.NormalizedURL,
 		DirectURL:     f.DirectURL(metadataBinder.CharacterID),
@@ -84,7 +102,9 @@ func (f *wyvernChatFetcher) FetchCardInfo(metadataBinder *fetcher.MetadataBinder
 		Tagline:       metadataBinder.Get("tagline").String(),
 		CreateTime:    timestamp.ParseF(wyvernDateFormat, metadataBinder.Get("created_at").String(), trace.URL, metadataBinder.NormalizedURL),
 		UpdateTime:    timestamp.ParseF(wyvernDateFormat, metadataBinder.Get("updated_at").String(), trace.URL, metadataBinder.NormalizedURL),
-		IsForked:      stringsx.IsNotBlank(metadataBinder.GetByPath("forked_from", "id").RefString()),
+		IsForked:      fork != nil,
+		Fork:          fork,
+		Rating:        wyvernRatings[metadataBinder.Get("rating").String()],
 		Tags:          models.TagsFromJsonArray(metadataBinder.Get("tags"), sonicx.WrapString),
 	}, nil
 }
@@ -104,37 +124,61 @@ func (f *wyvernChatFetcher) FetchCreatorInfo(metadataBinder *fetcher.MetadataBin
 	}, nil
 }
 
+// FetchStats extracts the engagement statistics from the metadata
+func (f *wyvernChatFetcher) FetchStats(metadataBinder *fetcher.MetadataBinder) (*models.Stats, error) {
+	statisticsNode := metadataBinder.Get("entity_statistics")
+	return &models.Stats{
+		Likes:    optionalCount(statisticsNode.Get("total_likes")),
+		Views:    optionalCount(statisticsNode.Get("total_views")),
+		Messages: optionalCount(statisticsNode.Get("total_messages")),
+	}, nil
+}
+
 // FetchBookResponses fetches the book responses from the source
 func (f *wyvernChatFetcher) FetchBookResponses(metadataBinder *fetcher.MetadataBinder) (*fetcher.BookBinder, error) {
 	// Initialize the book update time as ZERO
 	bookUpdateTime := timestamp.Nano(0)
+	// Describe the lexicon (the embedded entries of the card)
+	var books []models.BookInfo
+	if lexiconEntries, _ := metadataBinder.Get("lexicon").ArrayUseNode(); len(lexiconEntries) > 0 {
+		books = append(books, models.BookInfo{Origin: models.BookOriginEmbedded, EntryCount: len(lexiconEntries)})
+	}
 	// Extract the lorebooks node
 	lorebooksNode := metadataBinder.Get("lorebooks")
 	// Extract the lorebooks array
 	array, _ := lorebooksNode.ArrayUseNode()
 	// Iterate over the lorebooks array
 	for _, lorebookNode := range array {
+		lorebook := sonicx.Of(lorebookNode)
+		updateTime := timestamp.ParseF(wyvernDateFormat, lorebook.Get("updated_at").String(), trace.URL, metadataBinder.NormalizedURL)
+		// Describe the linked book (WyvernChat has no book pages)
+		books = append(books, models.BookInfo{
+			Origin:     models.BookOriginLinked,
+			PlatformID: strings.TrimSpace(lorebook.Get("id").String()),
+			Name:       lorebook.Get("name").String(),
+			Creator:    lorebook.GetByPath("creator", "displayName").String(),
+			EntryCount: entryCount(lorebook),
+			UpdateTime: updateTime,
+		})
 		// Update the book update time with the latest book update time
-		bookUpdateTime = max(
-			bookUpdateTime,
-			timestamp.ParseF(wyvernDateFormat, sonicx.Of(lorebookNode).Get("updated_at").String(), trace.URL, metadataBinder.NormalizedURL),
-		)
+		bookUpdateTime = max(bookUpdateTime, updateTime)
 	}
 	// Return the book binder
 	return &fetcher.BookBinder{
 		UpdateTime: bookUpdateTime,
+		Books:      books,
 	}, nil
 }
 
 // FetchCharacterCard fetches the character card from the source
-func (f *wyvernChatFetcher) FetchCharacterCard(binder *fetcher.Binder) (*png.CharacterCard, error) {
-	// Extract the avatar URL
-	avatarURL := binder.Get("avatar").String()
-
-	// Fetch the character avatar from the API
-	rawCard, err := png.FromURL(f.client, avatarURL).LastVersion().Get()
+func (f *wyvernChatFetcher) FetchCharacterCard(binder *fetcher.Binder) (*fetcher.Card, error) {
+	// Fetch the character avatar from the API (generated or placeholder avatar if the download fails)
+	rawCard, fallback, err := f.fetchAvatar(binder, fetcher.AvatarChain{
+		URLs: []string{binder.Get("avatar").String()},
+		Name: binder.Get("chat_name").String(),
+	})
 	if err != nil {
-		return nil, fetcher.NewError(err, fetcher.FetchAvatarErr)
+		return nil, err
 	}
 
 	// Decode the character card
@@ -149,11 +193,15 @@ func (f *wyvernChatFetcher) FetchCharacterCard(binder *fetcher.Binder) (*png.Cha
 		return nil, fetcher.NewError(err, fetcher.MalformedCardDataErr)
 	}
 
-	// Update the character sheet fields
-	wSheet.fillIn(characterCard.Sheet)
+	// Update the character sheet fields (tracing the fields changed from the PNG)
+	pngValues := models.SheetFieldValues(characterCard.Sheet)
+	worldBooks := wSheet.fillIn(characterCard.Sheet, binder.NewBookMerger())
+	binder.TraceMerge(pngValues, characterCard.Sheet)
 
-	// Return the character card
-	return characterCard, nil
+	// Return the character card (with the books kept separate)
+	card := f.newCard(binder, rawCard, characterCard, fallback)
+	card.WorldBooks = worldBooks
+	return card, nil
 }
 
 // wyvernSheet WyvernChat sheet structure
@@ -206,8 +254,9 @@ func (w *wyvernLoreBooks) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-// fillIn fills the character sheet fields from the wyvernSheet structure
-func (w *wyvernSheet) fillIn(sheet *character.Sheet) {
+// fillIn fills the character sheet fields from the wyvernSheet structure (the books are merged with the book merger)
+// The books kept separate by the book merger are returned
+func (w *wyvernSheet) fillIn(sheet *character.Sheet, bookMerger *fetcher.BookMerger) []*character.Book {
 	// Set the sheet fields
 	sheet.Description = w.Description
 	sheet.Personality = w.Personality
@@ -250,21 +299,23 @@ func (w *wyvernSheet) fillIn(sheet *character.Sheet) {
 	}
 
 	// Merge the lorebooks and lexicon into a single book
-	bookMerger := character.NewBookMerger()
-	// Iterate over the lorebooks, convert them to books and append them to the book merger
+	// Iterate over the lorebooks (linked), convert them to books and append them to the book merger
 	for _, book := range w.Lorebooks {
-		bookMerger.AppendBook(book.convert())
+		bookMerger.AppendBook(book.convert(), fetcher.BookSource{Origin: fetcher.BookOriginLinked, ID: string(book.ID)})
 	}
-	// Iterate over the lexicon entries, convert them to entries and append them to the book merger
+	// Iterate over the lexicon entries (embedded), convert them to entries and append them to the book merger
 	for index := range w.Lexicon {
-		bookMerger.AppendEntry(w.Lexicon[index].convert())
+		bookMerger.AppendEntry(w.Lexicon[index].convert(), fetcher.BookSource{Origin: fetcher.BookOriginEmbedded})
 	}
 	// Set the character book to the book merger result
-	sheet.Content.CharacterBook = bookMerger.Build()
+	var worldBooks []*character.Book
+	sheet.Content.CharacterBook, worldBooks = bookMerger.Build()
+	return worldBooks
 }
 
 // wyvernBook WyvernChat book structure
 type wyvernBook struct {
+	ID                property.String   `json:"id"`
 	Name              property.String   `json:"name"`
 	Description       property.String   `json:"description"`
 	ScanDepth         property.Integer  `json:"scan_depth"`
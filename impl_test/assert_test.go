@@ -165,6 +165,12 @@ func (ca *CharacterAssertion) BookUpdateTime(expected int64) *CharacterAssertion
 
 func (ca *CharacterAssertion) IsForked(expected bool) *CharacterAssertion {
 	assert.Equal(ca.t, expected, ca.metadata.IsForked)
+	assert.Equal(ca.t, expected, ca.metadata.Fork != nil)
+	return ca
+}
+
+func (ca *CharacterAssertion) ForkResolvable() *CharacterAssertion {
+	assert.True(ca.t, ca.metadata.Fork.IsResolvable())
 	return ca
 }
 
@@ -111,6 +111,7 @@ func TestWyvernChatImport_Fork(t *testing.T) {
 		CharacterID("_fNYCnamezeEF431UerxqN").
 		CharacterPlatformID("fNYCnamezeEF431UerxqN").
 		IsForked(true).
+		ForkResolvable().
 		Consistent().
 		AssertImage()
 }
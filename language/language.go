@@ -0,0 +1,223 @@
+package language
+
+import (
+	"cmp"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-parser/character"
+)
+
+// Detection thresholds
+const (
+	// MinLetters is the minimum number of letters of a text for its language to be detected
+	MinLetters = 20
+	// MinShare is the minimum share of the content for a language to be reported
+	MinShare = 0.15
+	// maxTrigrams is the number of trigrams scored per text (longer texts are sampled from the start)
+	maxTrigrams = 2000
+	// evidenceWeight scales the log likelihoods per trigram before the softmax (divided by the square root of the trigram count)
+	evidenceWeight = 0.6
+)
+
+// names of the supported languages by ISO 639-1 code
+var names = map[string]string{
+	"ar": "Arabic", "de": "German", "el": "Greek", "en": "English", "es": "Spanish", "fr": "French",
+	"he": "Hebrew", "hi": "Hindi", "id": "Indonesian", "it": "Italian", "ja": "Japanese", "ko": "Korean",
+	"nl": "Dutch", "pl": "Polish", "pt": "Portuguese", "ru": "Russian", "sv": "Swedish", "th": "Thai",
+	"tr": "Turkish", "uk": "Ukrainian", "vi": "Vietnamese", "zh": "Chinese",
+}
+
+// scriptLanguages maps the scripts used by a single supported language
+var scriptLanguages = []struct {
+	script *unicode.RangeTable
+	code   string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+}
+
+// noise matches the parts of a text which are not prose (macros, HTML tags, URLs)
+var noise = regexp.MustCompile(`\{\{[^}]*\}\}|<[^>]*>|https?://\S+`)
+
+// Name returns the English name of the language (empty for unsupported codes)
+func Name(code string) string {
+	return names[code]
+}
+
+// Codes returns the codes of the supported languages (sorted)
+func Codes() []string {
+	codes := make([]string, 0, len(names))
+	for code := range names {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	return codes
+}
+
+// Detect detects the languages of a text (most confident first, nil if the text is too short)
+// Scripts used by a single language decide directly; Latin and Cyrillic texts are scored against trigram profiles
+func Detect(text string) []models.DetectedLanguage {
+	scores, letters := detect(text)
+	if letters < MinLetters {
+		return nil
+	}
+	return ranked(scores, MinShare)
+}
+
+// DetectSheet detects the languages of the description, first message and alternate greetings of the sheet
+// The confidence of a language is its share of the content; languages below MinShare are dropped
+func DetectSheet(sheet *character.Sheet) []models.DetectedLanguage {
+	if sheet == nil {
+		return nil
+	}
+
+	// Weight the languages of each field by its number of letters
+	totals := make(map[string]float64)
+	weight := 0
+	for _, field := range append([]string{string(sheet.Description), string(sheet.FirstMessage)}, sheet.AlternateGreetings...) {
+		scores, letters := detect(field)
+		if letters < MinLetters {
+			continue
+		}
+		for code, score := range scores {
+			totals[code] += score * float64(letters)
+		}
+		weight += letters
+	}
+	if weight == 0 {
+		return nil
+	}
+
+	// Return the shares of the languages
+	for code := range totals {
+		totals[code] /= float64(weight)
+	}
+	return ranked(totals, MinShare)
+}
+
+// detect scores the languages of a text (scores add up to at most 1) and counts its letters
+func detect(text string) (map[string]float64, int) {
+	text = strings.ToLower(noise.ReplaceAllString(text, " "))
+
+	// Count the letters of each script
+	var letters, latin, cyrillic, han, kana int
+	single := make(map[string]int)
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		default:
+			for _, entry := range scriptLanguages {
+				if unicode.Is(entry.script, r) {
+					single[entry.code]++
+					break
+				}
+			}
+		}
+	}
+	scores := make(map[string]float64)
+	if letters == 0 {
+		return scores, 0
+	}
+	share := func(count int) float64 { return float64(count) / float64(letters) }
+
+	// Japanese mixes kana and Han characters, Chinese only uses Han characters
+	if kana > 0 {
+		scores["ja"] = share(kana + han)
+	} else if han > 0 {
+		scores["zh"] = share(han)
+	}
+	for code, count := range single {
+		scores[code] = share(count)
+	}
+
+	// Score the Latin and Cyrillic texts against the trigram profiles
+	for _, group := range []struct {
+		count    int
+		profiles map[string]*profile
+	}{{latin, latinProfiles()}, {cyrillic, cyrillicProfiles()}} {
+		if group.count == 0 {
+			continue
+		}
+		for code, probability := range classify(text, group.profiles) {
+			scores[code] = probability * share(group.count)
+		}
+	}
+
+	// Return the scores
+	return scores, letters
+}
+
+// classify returns the probability of each profile for the text (naive Bayes over the word trigrams)
+// The overlapping trigrams are not independent: the summed log likelihoods are normalized by the trigram count,
+// so the confidence grows with the length of the text instead of saturating, and stays low for mixed texts
+func classify(text string, profiles map[string]*profile) map[string]float64 {
+	// Score the trigrams of the text
+	logLikelihoods := make(map[string]float64, len(profiles))
+	count := 0
+	for trigram := range trigrams(text) {
+		for code, p := range profiles {
+			logLikelihoods[code] += p.logProbability(trigram)
+		}
+		if count++; count >= maxTrigrams {
+			break
+		}
+	}
+
+	if count == 0 {
+		return map[string]float64{}
+	}
+
+	// Convert the normalized log likelihoods into probabilities (softmax)
+	scale := evidenceWeight / math.Sqrt(float64(count))
+	best := math.Inf(-1)
+	for _, logLikelihood := range logLikelihoods {
+		best = max(best, logLikelihood)
+	}
+	probabilities := make(map[string]float64, len(logLikelihoods))
+	sum := 0.0
+	for code, logLikelihood := range logLikelihoods {
+		probabilities[code] = math.Exp((logLikelihood - best) * scale)
+		sum += probabilities[code]
+	}
+	for code := range probabilities {
+		probabilities[code] /= sum
+	}
+	return probabilities
+}
+
+// ranked returns the languages with a score of at least the threshold (highest first, ties by code)
+func ranked(scores map[string]float64, threshold float64) []models.DetectedLanguage {
+	var languages []models.DetectedLanguage
+	for code, score := range scores {
+		if score >= threshold {
+			languages = append(languages, models.DetectedLanguage{Code: code, Confidence: score})
+		}
+	}
+	slices.SortFunc(languages, func(a, b models.DetectedLanguage) int {
+		if c := cmp.Compare(b.Confidence, a.Confidence); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Code, b.Code)
+	})
+	return languages
+}
@@ -0,0 +1,100 @@
+package language
+
+import (
+	"testing"
+
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/card-parser/property"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetect(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{name: "English", text: "{{char}} smiles warmly and offers {{user}} a cup of tea. \"You look exhausted, come and sit by the window.\"", expected: "en"},
+		{name: "Spanish", text: "{{char}} sonríe y le ofrece a {{user}} una taza de té. \"Pareces agotado, ven y siéntate junto a la ventana.\"", expected: "es"},
+		{name: "French", text: "{{char}} sourit et offre une tasse de thé à {{user}}. « Tu as l'air épuisé, viens t'asseoir près de la fenêtre. »", expected: "fr"},
+		{name: "German", text: "{{char}} lächelt und bietet {{user}} eine Tasse Tee an. \"Du siehst erschöpft aus, komm und setz dich ans Fenster.\"", expected: "de"},
+		{name: "Italian", text: "{{char}} sorride e offre a {{user}} una tazza di tè. \"Sembri esausto, vieni a sederti vicino alla finestra.\"", expected: "it"},
+		{name: "Portuguese", text: "{{char}} sorri e oferece a {{user}} uma xícara de chá. \"Você parece exausto, venha se sentar perto da janela.\"", expected: "pt"},
+		{name: "Dutch", text: "{{char}} glimlacht en biedt {{user}} een kopje thee aan. \"Je ziet er uitgeput uit, kom bij het raam zitten.\"", expected: "nl"},
+		{name: "Polish", text: "{{char}} uśmiecha się i podaje {{user}} filiżankę herbaty. \"Wyglądasz na wyczerpanego, chodź i usiądź przy oknie.\"", expected: "pl"},
+		{name: "Turkish", text: "{{char}} gülümsüyor ve {{user}} için bir fincan çay getiriyor. \"Çok yorgun görünüyorsun, gel pencerenin yanına otur.\"", expected: "tr"},
+		{name: "Russian", text: "{{char}} улыбается и предлагает {{user}} чашку чая. «Ты выглядишь уставшим, садись у окна.»", expected: "ru"},
+		{name: "Ukrainian", text: "{{char}} усміхається і пропонує {{user}} чашку чаю. «Ти виглядаєш втомленим, сідай біля вікна.»", expected: "uk"},
+		{name: "Japanese", text: "{{char}}は優しく微笑んで、{{user}}にお茶を差し出した。「疲れているみたいだね、窓のそばに座って。」", expected: "ja"},
+		{name: "Chinese", text: "{{char}}温柔地笑着，递给{{user}}一杯茶。“你看起来很累，过来坐在窗边吧。”", expected: "zh"},
+		{name: "Korean", text: "{{char}}는 따뜻하게 웃으며 {{user}}에게 차 한 잔을 건넨다. \"피곤해 보이네, 창가에 와서 앉아.\"", expected: "ko"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			languages := Detect(tc.text)
+			require.NotEmpty(t, languages)
+			assert.Equal(t, tc.expected, languages[0].Code)
+			assert.Greater(t, languages[0].Confidence, 0.5)
+		})
+	}
+
+	t.Run("should not be certain of short texts", func(t *testing.T) {
+		languages := Detect("Bonjour mon ami, comment vas-tu ?")
+		require.NotEmpty(t, languages)
+		assert.Equal(t, "fr", languages[0].Code)
+		assert.Less(t, languages[0].Confidence, 0.8)
+	})
+
+	t.Run("should not be certain of mixed texts", func(t *testing.T) {
+		languages := Detect("Elle est courageuse et loyale, mais elle peut aussi être têtue. She loves sweet food, long walks in the forest and stories about ancient heroes.")
+		require.Len(t, languages, 2)
+		assert.ElementsMatch(t, []string{"en", "fr"}, []string{languages[0].Code, languages[1].Code})
+		assert.Less(t, languages[0].Confidence, 0.8)
+		assert.Greater(t, languages[1].Confidence, MinShare)
+	})
+
+	t.Run("should be more confident of longer texts", func(t *testing.T) {
+		short := Detect("The knight smiles and offers you a cup of tea.")
+		long := Detect("The knight smiles and offers you a cup of tea. You look exhausted, so she asks you to sit by the window and tell her what happened on the road today.")
+		require.NotEmpty(t, short)
+		require.NotEmpty(t, long)
+		assert.Less(t, short[0].Confidence, long[0].Confidence)
+		assert.Less(t, long[0].Confidence, 1.0)
+	})
+
+	t.Run("should not detect short texts", func(t *testing.T) {
+		assert.Nil(t, Detect("Hello!"))
+		assert.Nil(t, Detect("{{char}} <b>{{user}}</b> https://example.com/some/long/path"))
+	})
+}
+
+func TestDetectSheet(t *testing.T) {
+	t.Run("should report the languages of the content by share", func(t *testing.T) {
+		sheet := character.DefaultSheet(character.RevisionV3)
+		sheet.Description = "A cheerful librarian who works in the old library at the edge of the town and knows every book by heart."
+		sheet.FirstMessage = "Welcome back! I saved the book you asked about last week, it is waiting for you on the front desk."
+		sheet.AlternateGreetings = property.StringArray{"おかえりなさい！先週頼まれた本を取っておきましたよ。カウンターで待っています。", "Hi"}
+
+		languages := DetectSheet(sheet)
+
+		require.Len(t, languages, 2)
+		assert.Equal(t, "en", languages[0].Code)
+		assert.Equal(t, "ja", languages[1].Code)
+		assert.InDelta(t, 1, languages[0].Confidence+languages[1].Confidence, 0.05)
+	})
+
+	t.Run("should detect nothing without content", func(t *testing.T) {
+		assert.Nil(t, DetectSheet(nil))
+		assert.Nil(t, DetectSheet(character.DefaultSheet(character.RevisionV3)))
+	})
+}
+
+func TestName(t *testing.T) {
+	assert.Equal(t, "Japanese", Name("ja"))
+	assert.Empty(t, Name("xx"))
+	assert.Contains(t, Codes(), "en")
+	for _, code := range append(append([]string{}, latinCodes...), cyrillicCodes...) {
+		assert.NotEmpty(t, Name(code), code)
+	}
+}
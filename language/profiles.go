@@ -0,0 +1,75 @@
+package language
+
+import (
+	"embed"
+	"iter"
+	"math"
+	"path"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// corpus contains a sample text per language (named by ISO 639-1 code), used to build the trigram profiles
+//
+//go:embed corpus/*.txt
+var corpus embed.FS
+
+// Languages scored by trigram profiles, grouped by script
+var (
+	latinCodes    = []string{"de", "en", "es", "fr", "id", "it", "nl", "pl", "pt", "sv", "tr", "vi"}
+	cyrillicCodes = []string{"ru", "uk"}
+)
+
+// profile represents the trigram frequencies of a language
+type profile struct {
+	counts map[string]int
+	total  int
+}
+
+// logProbability returns the smoothed log probability of the trigram in the language (add-one smoothing)
+func (p *profile) logProbability(trigram string) float64 {
+	return math.Log(float64(p.counts[trigram]+1) / float64(p.total+len(p.counts)))
+}
+
+// latinProfiles returns the profiles of the Latin script languages (built once)
+var latinProfiles = sync.OnceValue(func() map[string]*profile {
+	return loadProfiles(latinCodes)
+})
+
+// cyrillicProfiles returns the profiles of the Cyrillic script languages (built once)
+var cyrillicProfiles = sync.OnceValue(func() map[string]*profile {
+	return loadProfiles(cyrillicCodes)
+})
+
+// loadProfiles builds the profiles of the languages from the embedded corpus
+func loadProfiles(codes []string) map[string]*profile {
+	profiles := make(map[string]*profile, len(codes))
+	for _, code := range codes {
+		data, err := corpus.ReadFile(path.Join("corpus", code+".txt"))
+		if err != nil {
+			panic(err)
+		}
+		p := &profile{counts: make(map[string]int)}
+		for trigram := range trigrams(strings.ToLower(string(data))) {
+			p.counts[trigram]++
+			p.total++
+		}
+		profiles[code] = p
+	}
+	return profiles
+}
+
+// trigrams yields the character trigrams of the words of the text (words are padded with spaces)
+func trigrams(text string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for word := range strings.FieldsFuncSeq(text, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.Is(unicode.Mn, r) }) {
+			runes := []rune(" " + word + " ")
+			for index := 0; index+3 <= len(runes); index++ {
+				if !yield(string(runes[index : index+3])) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package lint
+
+import (
+	"slices"
+
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-fetcher/tokenizer"
+	"github.com/r3dpixel/card-parser/character"
+)
+
+// DefaultMaxFieldTokens is the default maximum number of estimated tokens of a single field
+const DefaultMaxFieldTokens = 2000
+
+// Finding represents a problem found in the sheet content
+type Finding struct {
+	Rule     models.RuleID   `json:"rule"`
+	Severity models.Severity `json:"severity"`
+	Field    string          `json:"field"`
+	Message  string          `json:"message"`
+	Values   map[string]any  `json:"values,omitempty"`
+}
+
+// Result represents the findings of a linter run (machine-readable, see the JSON tags)
+type Result struct {
+	Findings []Finding `json:"findings"`
+}
+
+// Has checks if the given rule was reported
+func (r *Result) Has(rule models.RuleID) bool {
+	return r != nil && slices.ContainsFunc(r.Findings, func(f Finding) bool {
+		return f.Rule == rule
+	})
+}
+
+// Count returns the number of findings with the given severity
+func (r *Result) Count(severity models.Severity) int {
+	if r == nil {
+		return 0
+	}
+	count := 0
+	for _, finding := range r.Findings {
+		if finding.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// Report converts the findings into a validation report
+func (r *Result) Report() *models.Report {
+	report := &models.Report{}
+	if r == nil {
+		return report
+	}
+	for _, finding := range r.Findings {
+		values := map[string]any{"field": finding.Field}
+		for key, value := range finding.Values {
+			values[key] = value
+		}
+		report.Add(finding.Rule, finding.Severity, finding.Message, values)
+	}
+	return report
+}
+
+// RuleConfig represents the configuration of a single rule
+type RuleConfig struct {
+	// Disabled disables the rule
+	Disabled bool
+	// Severity overrides the default severity of the rule (optional)
+	Severity models.Severity
+}
+
+// Config represents the linter configuration
+type Config struct {
+	// Rules configures the rules by ID (rules without configuration use their defaults)
+	Rules map[models.RuleID]RuleConfig
+	// MaxFieldTokens is the maximum number of estimated tokens of a single field (defaults to DefaultMaxFieldTokens)
+	MaxFieldTokens int
+	// TokenCounter estimates the number of tokens of a text (defaults to the count of tokenizer.Default)
+	TokenCounter func(text string) int
+}
+
+// rule represents a lint rule
+type rule struct {
+	id       models.RuleID
+	severity models.Severity
+	check    func(l *Linter, sheet *character.Sheet, report func(field string, message string, values map[string]any))
+}
+
+// Linter checks the content of sheets
+type Linter struct {
+	config Config
+	rules  []rule
+}
+
+// New creates a new linter with the given configuration
+func New(config Config) *Linter {
+	// Set the defaults
+	if config.MaxFieldTokens <= 0 {
+		config.MaxFieldTokens = DefaultMaxFieldTokens
+	}
+	if config.TokenCounter == nil {
+		config.TokenCounter = tokenizer.Default().Count
+	}
+
+	// Keep the enabled rules, with the configured severity
+	l := &Linter{config: config}
+	for _, r := range defaultRules {
+		ruleConfig := config.Rules[r.id]
+		if ruleConfig.Disabled {
+			continue
+		}
+		if ruleConfig.Severity != "" {
+			r.severity = ruleConfig.Severity
+		}
+		l.rules = append(l.rules, r)
+	}
+
+	// Return the linter
+	return l
+}
+
+// Rules returns the IDs of all the available rules
+func Rules() []models.RuleID {
+	ids := make([]models.RuleID, len(defaultRules))
+	for index, r := range defaultRules {
+		ids[index] = r.id
+	}
+	return ids
+}
+
+// Lint checks the content of the sheet
+func (l *Linter) Lint(sheet *character.Sheet) *Result {
+	result := &Result{Findings: []Finding{}}
+	if sheet == nil {
+		return result
+	}
+	for _, r := range l.rules {
+		r.check(l, sheet, func(field string, message string, values map[string]any) {
+			result.Findings = append(result.Findings, Finding{
+				Rule:     r.id,
+				Severity: r.severity,
+				Field:    field,
+				Message:  message,
+				Values:   values,
+			})
+		})
+	}
+	return result
+}
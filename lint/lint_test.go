@@ -0,0 +1,203 @@
+package lint
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-fetcher/tokenizer"
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/card-parser/property"
+	"github.com/stretchr/testify/assert"
+)
+
+// cleanSheet creates a sheet without any lint findings
+func cleanSheet() *character.Sheet {
+	sheet := character.DefaultSheet(character.RevisionV2)
+	sheet.Description = "{{char}} is a knight."
+	sheet.FirstMessage = "Hello {{user}}!"
+	sheet.AlternateGreetings = []string{"Good morning {{user}}."}
+	sheet.CreatorNotes = "<p>My <b>first</b> card</p> - see [the guide](https://example.com)"
+	return sheet
+}
+
+func TestLinter_Lint(t *testing.T) {
+	t.Run("should report no findings for a clean sheet", func(t *testing.T) {
+		result := New(Config{}).Lint(cleanSheet())
+
+		assert.Empty(t, result.Findings)
+		assert.True(t, result.Report().Valid())
+	})
+
+	t.Run("should report template typos", func(t *testing.T) {
+		sheet := cleanSheet()
+		sheet.Description = "{char}} meets <user> and {{ Char }}, but {{char}} is fine."
+
+		result := New(Config{}).Lint(sheet)
+
+		var placeholders []any
+		for _, finding := range result.Findings {
+			assert.Equal(t, RuleTemplateTypo, finding.Rule)
+			assert.Equal(t, "Description", finding.Field)
+			placeholders = append(placeholders, finding.Values["placeholder"])
+		}
+		assert.Equal(t, []any{"{char}}", "<user>", "{{ Char }}"}, placeholders)
+	})
+
+	t.Run("should report an empty first message as an error", func(t *testing.T) {
+		sheet := cleanSheet()
+		sheet.FirstMessage = "  "
+
+		result := New(Config{}).Lint(sheet)
+
+		assert.True(t, result.Has(RuleFirstMessageEmpty))
+		assert.Equal(t, 1, result.Count(models.SeverityError))
+		assert.False(t, result.Report().Valid())
+	})
+
+	t.Run("should report empty and duplicate greetings", func(t *testing.T) {
+		sheet := cleanSheet()
+		sheet.AlternateGreetings = []string{"Hello {{user}}!", "", "Bye", " Bye "}
+
+		result := New(Config{}).Lint(sheet)
+
+		assert.Len(t, result.Findings, 3)
+		assert.Equal(t, Finding{
+			Rule:     RuleGreetingEmpty,
+			Severity: models.SeverityWarning,
+			Field:    "AlternateGreetings[1]",
+			Message:  "alternate greeting is empty",
+		}, result.Findings[0])
+		assert.Equal(t, map[string]any{"duplicate_of": "FirstMessage"}, result.Findings[1].Values)
+		assert.Equal(t, map[string]any{"duplicate_of": "AlternateGreetings[2]"}, result.Findings[2].Values)
+	})
+
+	t.Run("should report lorebook entry problems", func(t *testing.T) {
+		sheet := cleanSheet()
+		book := character.DefaultBook()
+		noKeys := &character.BookEntry{}
+		noKeys.Name = "Castle"
+		noKeys.Content = "The castle of {{char}}."
+		noKeys.Enabled = true
+		constant := &character.BookEntry{}
+		constant.Content = "Always there."
+		constant.Constant = true
+		constant.Enabled = true
+		disabled := &character.BookEntry{}
+		disabled.Keys = []string{"sword"}
+		book.Entries = []*character.BookEntry{noKeys, constant, nil, disabled}
+		sheet.CharacterBook = book
+
+		result := New(Config{}).Lint(sheet)
+
+		assert.Len(t, result.Findings, 3)
+		assert.Equal(t, RuleBookEntryNoKeys, result.Findings[0].Rule)
+		assert.Equal(t, "CharacterBook.Entries[0].Keys", result.Findings[0].Field)
+		assert.Equal(t, map[string]any{"name": "Castle"}, result.Findings[0].Values)
+		assert.Equal(t, RuleBookEntryDisabled, result.Findings[1].Rule)
+		assert.Equal(t, models.SeverityInfo, result.Findings[1].Severity)
+		assert.Equal(t, RuleBookEntryEmpty, result.Findings[2].Rule)
+		assert.Equal(t, "CharacterBook.Entries[3].Content", result.Findings[2].Field)
+	})
+
+	t.Run("should report oversized fields", func(t *testing.T) {
+		sheet := cleanSheet()
+		sheet.Personality = property.String(strings.Repeat("word ", 100))
+
+		result := New(Config{MaxFieldTokens: 50}).Lint(sheet)
+
+		assert.Len(t, result.Findings, 1)
+		assert.Equal(t, "Personality", result.Findings[0].Field)
+		assert.Equal(t, map[string]any{"tokens": tokenizer.Default().Count(string(sheet.Personality)), "max_tokens": 50}, result.Findings[0].Values)
+	})
+
+	t.Run("should use the configured token counter", func(t *testing.T) {
+		countWords := func(text string) int {
+			return len(strings.Fields(text))
+		}
+
+		result := New(Config{MaxFieldTokens: 3, TokenCounter: countWords}).Lint(cleanSheet())
+
+		assert.Len(t, result.Findings, 2)
+		assert.Equal(t, "Description", result.Findings[0].Field)
+		assert.Equal(t, "CreatorNotes", result.Findings[1].Field)
+	})
+
+	t.Run("should report broken markup in creator notes", func(t *testing.T) {
+		sheet := cleanSheet()
+		sheet.CreatorNotes = "<div><b>bold</div></i><br><ul><li>item</ul>\n```go\ncode\n\n[link](https://example.com"
+
+		result := New(Config{}).Lint(sheet)
+
+		var messages []string
+		for _, finding := range result.Findings {
+			messages = append(messages, finding.Message)
+		}
+		assert.Equal(t, []string{
+			"unclosed HTML tag",
+			"unexpected HTML closing tag",
+			"unclosed Markdown code fence",
+			"unclosed Markdown link",
+		}, messages)
+		assert.Equal(t, map[string]any{"tag": "b"}, result.Findings[0].Values)
+		assert.Equal(t, map[string]any{"tag": "i"}, result.Findings[1].Values)
+		assert.Equal(t, map[string]any{"line": 2}, result.Findings[2].Values)
+	})
+
+	t.Run("should report placeholder typos in creator notes once", func(t *testing.T) {
+		sheet := cleanSheet()
+		sheet.CreatorNotes = "<div>Chat as <user> with {{char}}</div>"
+
+		result := New(Config{}).Lint(sheet)
+
+		if assert.Len(t, result.Findings, 1) {
+			assert.Equal(t, RuleTemplateTypo, result.Findings[0].Rule)
+			assert.Equal(t, "CreatorNotes", result.Findings[0].Field)
+			assert.Equal(t, map[string]any{"placeholder": "<user>"}, result.Findings[0].Values)
+		}
+	})
+
+	t.Run("should handle a nil sheet", func(t *testing.T) {
+		assert.Empty(t, New(Config{}).Lint(nil).Findings)
+	})
+}
+
+func TestLinter_Config(t *testing.T) {
+	sheet := cleanSheet()
+	sheet.FirstMessage = ""
+	sheet.AlternateGreetings = []string{""}
+
+	linter := New(Config{Rules: map[models.RuleID]RuleConfig{
+		RuleFirstMessageEmpty: {Severity: models.SeverityWarning},
+		RuleGreetingEmpty:     {Disabled: true},
+	}})
+	result := linter.Lint(sheet)
+
+	assert.Len(t, result.Findings, 1)
+	assert.Equal(t, RuleFirstMessageEmpty, result.Findings[0].Rule)
+	assert.Equal(t, models.SeverityWarning, result.Findings[0].Severity)
+	assert.True(t, result.Report().Valid())
+}
+
+func TestResult_JSON(t *testing.T) {
+	sheet := cleanSheet()
+	sheet.FirstMessage = ""
+
+	data, err := json.Marshal(New(Config{}).Lint(sheet))
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"findings":[{
+		"rule":"lint.first_message.empty",
+		"severity":"error",
+		"field":"FirstMessage",
+		"message":"first message is empty"
+	}]}`, string(data))
+}
+
+func TestRules(t *testing.T) {
+	rules := Rules()
+
+	assert.Len(t, rules, len(defaultRules))
+	assert.Contains(t, rules, RuleCreatorNotesMarkdown)
+}
@@ -0,0 +1,116 @@
+package lint
+
+import (
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// voidElements are the HTML elements without closing tags
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// optionalCloseElements are the HTML elements whose closing tag can be omitted
+var optionalCloseElements = map[string]bool{
+	"li": true, "p": true, "dt": true, "dd": true, "tr": true, "td": true, "th": true,
+	"thead": true, "tbody": true, "tfoot": true, "option": true,
+}
+
+// markdownLinkRegExp matches the start of an inline Markdown link or image ([text](...)
+var markdownLinkRegExp = regexp.MustCompile(`!?\[[^\[\]\n]*\]\(`)
+
+// markupProblem represents a problem found in the markup of a text
+type markupProblem struct {
+	message string
+	tag     string
+	values  map[string]any
+}
+
+// htmlProblems finds the unbalanced HTML tags of the text
+func htmlProblems(text string) []markupProblem {
+	var problems []markupProblem
+	var open []string
+
+	// Tokenize the text (plain text and Markdown are ignored)
+	tokenizer := html.NewTokenizer(strings.NewReader(text))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			// End of the text (any other error is not an HTML problem)
+			if tokenizer.Err() != io.EOF {
+				return problems
+			}
+			// Report the unclosed tags
+			for _, tag := range open {
+				if !optionalCloseElements[tag] {
+					problems = append(problems, markupProblem{message: "unclosed HTML tag", tag: tag})
+				}
+			}
+			return problems
+		case html.StartTagToken:
+			name, _ := tokenizer.TagName()
+			if tag := string(name); !voidElements[tag] {
+				open = append(open, tag)
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			tag := string(name)
+			if voidElements[tag] {
+				continue
+			}
+			// Find the matching start tag (the elements opened after it are implicitly closed)
+			index := len(open) - 1
+			for index >= 0 && open[index] != tag {
+				index--
+			}
+			if index < 0 {
+				problems = append(problems, markupProblem{message: "unexpected HTML closing tag", tag: tag})
+				continue
+			}
+			for _, unclosed := range open[index+1:] {
+				if !optionalCloseElements[unclosed] {
+					problems = append(problems, markupProblem{message: "unclosed HTML tag", tag: unclosed})
+				}
+			}
+			open = open[:index]
+		}
+	}
+}
+
+// markdownProblems finds the broken Markdown constructs of the text (unclosed code fences and links)
+func markdownProblems(text string) []markupProblem {
+	var problems []markupProblem
+
+	// Check the code fences
+	fenceOpen := false
+	fenceLine := 0
+	lines := strings.Split(text, "\n")
+	for index, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fenceOpen = !fenceOpen
+			fenceLine = index + 1
+		}
+	}
+	if fenceOpen {
+		problems = append(problems, markupProblem{message: "unclosed Markdown code fence", values: map[string]any{"line": fenceLine}})
+	}
+
+	// Check the inline links (the destination must be closed on the same line)
+	for index, line := range lines {
+		for _, location := range markdownLinkRegExp.FindAllStringIndex(line, -1) {
+			if !strings.Contains(line[location[1]:], ")") {
+				problems = append(problems, markupProblem{
+					message: "unclosed Markdown link",
+					values:  map[string]any{"line": index + 1, "link": line[location[0]:location[1]]},
+				})
+			}
+		}
+	}
+
+	// Return the problems
+	return problems
+}
@@ -0,0 +1,228 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/toolkit/stringsx"
+)
+
+// Lint rules
+const (
+	RuleTemplateTypo         models.RuleID = "lint.template.typo"
+	RuleFirstMessageEmpty    models.RuleID = "lint.first_message.empty"
+	RuleGreetingDuplicate    models.RuleID = "lint.alternate_greetings.duplicate"
+	RuleGreetingEmpty        models.RuleID = "lint.alternate_greetings.empty"
+	RuleBookEntryNoKeys      models.RuleID = "lint.character_book.entry.no_keys"
+	RuleBookEntryDisabled    models.RuleID = "lint.character_book.entry.disabled"
+	RuleBookEntryEmpty       models.RuleID = "lint.character_book.entry.empty"
+	RuleFieldOversized       models.RuleID = "lint.field.oversized"
+	RuleCreatorNotesHTML     models.RuleID = "lint.creator_notes.html"
+	RuleCreatorNotesMarkdown models.RuleID = "lint.creator_notes.markdown"
+)
+
+// templatePlaceholderRegExp matches the {{char}}/{{user}} placeholders and their common typos ({char}}, <user>, {{ bot }}, ...)
+var templatePlaceholderRegExp = regexp.MustCompile(`(?i)[{<\[]+\s*(char|user|bot)\s*[}>\]]+`)
+
+// placeholderTags are the placeholder names parsed as HTML tags in <user> typos (reported as template typos only)
+var placeholderTags = map[string]bool{"char": true, "user": true, "bot": true}
+
+// defaultRules is the list of all the lint rules, in execution order
+var defaultRules = []rule{
+	{id: RuleTemplateTypo, severity: models.SeverityWarning, check: checkTemplateTypos},
+	{id: RuleFirstMessageEmpty, severity: models.SeverityError, check: checkFirstMessage},
+	{id: RuleGreetingEmpty, severity: models.SeverityWarning, check: checkEmptyGreetings},
+	{id: RuleGreetingDuplicate, severity: models.SeverityWarning, check: checkDuplicateGreetings},
+	{id: RuleBookEntryNoKeys, severity: models.SeverityWarning, check: checkBookEntryKeys},
+	{id: RuleBookEntryDisabled, severity: models.SeverityInfo, check: checkBookEntryDisabled},
+	{id: RuleBookEntryEmpty, severity: models.SeverityWarning, check: checkBookEntryContent},
+	{id: RuleFieldOversized, severity: models.SeverityWarning, check: checkOversizedFields},
+	{id: RuleCreatorNotesHTML, severity: models.SeverityWarning, check: checkCreatorNotesHTML},
+	{id: RuleCreatorNotesMarkdown, severity: models.SeverityWarning, check: checkCreatorNotesMarkdown},
+}
+
+// textField represents a text field of the sheet
+type textField struct {
+	name  string
+	value string
+}
+
+// textFields returns all the text fields of the sheet that can contain placeholders
+func textFields(sheet *character.Sheet) []textField {
+	fields := []textField{
+		{"Description", string(sheet.Description)},
+		{"Personality", string(sheet.Personality)},
+		{"Scenario", string(sheet.Scenario)},
+		{"FirstMessage", string(sheet.FirstMessage)},
+		{"MessageExamples", string(sheet.MessageExamples)},
+		{"SystemPrompt", string(sheet.SystemPrompt)},
+		{"PostHistoryInstructions", string(sheet.PostHistoryInstructions)},
+		{"DepthPrompt.Prompt", sheet.DepthPrompt.Prompt},
+	}
+	for index, greeting := range sheet.AlternateGreetings {
+		fields = append(fields, textField{fmt.Sprintf("AlternateGreetings[%d]", index), greeting})
+	}
+	for index, greeting := range sheet.GroupGreetings {
+		fields = append(fields, textField{fmt.Sprintf("GroupGreetings[%d]", index), greeting})
+	}
+	if sheet.CharacterBook != nil {
+		for index, entry := range sheet.CharacterBook.Entries {
+			if entry != nil {
+				fields = append(fields, textField{entryField(index, "Content"), string(entry.Content)})
+			}
+		}
+	}
+	return fields
+}
+
+// entryField returns the field path of a lorebook entry
+func entryField(index int, field string) string {
+	path := fmt.Sprintf("CharacterBook.Entries[%d]", index)
+	if field != "" {
+		path += "." + field
+	}
+	return path
+}
+
+// checkTemplateTypos reports the placeholders that are not exactly {{char}} or {{user}} (creator notes included)
+func checkTemplateTypos(_ *Linter, sheet *character.Sheet, report func(string, string, map[string]any)) {
+	fields := append(textFields(sheet), textField{"CreatorNotes", string(sheet.CreatorNotes)})
+	for _, field := range fields {
+		for _, match := range templatePlaceholderRegExp.FindAllString(field.value, -1) {
+			switch strings.ToLower(match) {
+			case "{{char}}", "{{user}}":
+				continue
+			}
+			report(field.name, "malformed template placeholder", map[string]any{"placeholder": match})
+		}
+	}
+}
+
+// checkFirstMessage reports an empty first message
+func checkFirstMessage(_ *Linter, sheet *character.Sheet, report func(string, string, map[string]any)) {
+	if stringsx.IsBlank(string(sheet.FirstMessage)) {
+		report("FirstMessage", "first message is empty", nil)
+	}
+}
+
+// checkEmptyGreetings reports the empty alternate greetings
+func checkEmptyGreetings(_ *Linter, sheet *character.Sheet, report func(string, string, map[string]any)) {
+	for index, greeting := range sheet.AlternateGreetings {
+		if stringsx.IsBlank(greeting) {
+			report(fmt.Sprintf("AlternateGreetings[%d]", index), "alternate greeting is empty", nil)
+		}
+	}
+}
+
+// checkDuplicateGreetings reports the alternate greetings duplicating the first message or another greeting
+func checkDuplicateGreetings(_ *Linter, sheet *character.Sheet, report func(string, string, map[string]any)) {
+	seen := map[string]string{}
+	if firstMessage := strings.TrimSpace(string(sheet.FirstMessage)); firstMessage != "" {
+		seen[firstMessage] = "FirstMessage"
+	}
+	for index, greeting := range sheet.AlternateGreetings {
+		greeting = strings.TrimSpace(greeting)
+		if greeting == "" {
+			continue
+		}
+		field := fmt.Sprintf("AlternateGreetings[%d]", index)
+		if original, ok := seen[greeting]; ok {
+			report(field, "alternate greeting is a duplicate", map[string]any{"duplicate_of": original})
+			continue
+		}
+		seen[greeting] = field
+	}
+}
+
+// checkBookEntryKeys reports the non-constant lorebook entries without keys (they are never triggered)
+func checkBookEntryKeys(_ *Linter, sheet *character.Sheet, report func(string, string, map[string]any)) {
+	forEachEntry(sheet, func(index int, entry *character.BookEntry) {
+		if bool(entry.Constant) {
+			return
+		}
+		for _, key := range entry.Keys {
+			if stringsx.IsNotBlank(key) {
+				return
+			}
+		}
+		report(entryField(index, "Keys"), "lorebook entry has no keys and is not constant", entryValues(entry))
+	})
+}
+
+// checkBookEntryDisabled reports the disabled lorebook entries
+func checkBookEntryDisabled(_ *Linter, sheet *character.Sheet, report func(string, string, map[string]any)) {
+	forEachEntry(sheet, func(index int, entry *character.BookEntry) {
+		if !bool(entry.Enabled) {
+			report(entryField(index, "Enabled"), "lorebook entry is disabled", entryValues(entry))
+		}
+	})
+}
+
+// checkBookEntryContent reports the lorebook entries without content
+func checkBookEntryContent(_ *Linter, sheet *character.Sheet, report func(string, string, map[string]any)) {
+	forEachEntry(sheet, func(index int, entry *character.BookEntry) {
+		if stringsx.IsBlank(string(entry.Content)) {
+			report(entryField(index, "Content"), "lorebook entry has no content", entryValues(entry))
+		}
+	})
+}
+
+// checkOversizedFields reports the fields exceeding the maximum number of estimated tokens
+func checkOversizedFields(l *Linter, sheet *character.Sheet, report func(string, string, map[string]any)) {
+	fields := append(textFields(sheet), textField{"CreatorNotes", string(sheet.CreatorNotes)})
+	for _, field := range fields {
+		if tokens := l.config.TokenCounter(field.value); tokens > l.config.MaxFieldTokens {
+			report(field.name, "field exceeds the maximum number of tokens", map[string]any{
+				"tokens":     tokens,
+				"max_tokens": l.config.MaxFieldTokens,
+			})
+		}
+	}
+}
+
+// checkCreatorNotesHTML reports the unbalanced HTML tags in the creator notes (placeholder typos are left to checkTemplateTypos)
+func checkCreatorNotesHTML(_ *Linter, sheet *character.Sheet, report func(string, string, map[string]any)) {
+	for _, problem := range htmlProblems(string(sheet.CreatorNotes)) {
+		if placeholderTags[problem.tag] {
+			continue
+		}
+		report("CreatorNotes", problem.message, map[string]any{"tag": problem.tag})
+	}
+}
+
+// checkCreatorNotesMarkdown reports the broken Markdown constructs in the creator notes
+func checkCreatorNotesMarkdown(_ *Linter, sheet *character.Sheet, report func(string, string, map[string]any)) {
+	for _, problem := range markdownProblems(string(sheet.CreatorNotes)) {
+		report("CreatorNotes", problem.message, problem.values)
+	}
+}
+
+// forEachEntry iterates over the non-nil lorebook entries
+func forEachEntry(sheet *character.Sheet, consumer func(index int, entry *character.BookEntry)) {
+	if sheet.CharacterBook == nil {
+		return
+	}
+	for index, entry := range sheet.CharacterBook.Entries {
+		if entry != nil {
+			consumer(index, entry)
+		}
+	}
+}
+
+// entryValues returns the identifying values of a lorebook entry
+func entryValues(entry *character.BookEntry) map[string]any {
+	values := map[string]any{}
+	if name := strings.TrimSpace(string(entry.Name)); name != "" {
+		values["name"] = name
+	}
+	if comment := strings.TrimSpace(string(entry.Comment)); comment != "" {
+		values["comment"] = comment
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
@@ -0,0 +1,95 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// Format is the output format of the HTML conversion
+type Format string
+
+// Output formats
+const (
+	// FormatKeep keeps the HTML as is (no conversion)
+	FormatKeep Format = ""
+	// FormatMarkdown converts the HTML into Markdown (links, images and emphasis are kept)
+	FormatMarkdown Format = "markdown"
+	// FormatText converts the HTML into plain text (links and images are kept as URLs)
+	FormatText Format = "text"
+)
+
+// removedElements are the HTML elements removed with their content
+const removedElements = "script, style, noscript, template, head, iframe, object, embed"
+
+// htmlRegExp matches the HTML tags, comments and entities of a text
+var htmlRegExp = regexp.MustCompile(`(?i)</?[a-z][a-z0-9]*(\s[^<>]*)?/?>|<!--|&(#[0-9]+|#x[0-9a-f]+|[a-z][a-z0-9]*);`)
+
+// blankLinesRegExp matches the runs of blank lines
+var blankLinesRegExp = regexp.MustCompile(`\n{3,}`)
+
+// HasHTML checks if the text contains HTML tags, comments or entities
+func HasHTML(text string) bool {
+	return htmlRegExp.MatchString(text)
+}
+
+// Convert converts the HTML of the text into the format (texts without HTML are returned as is)
+func Convert(text string, format Format) string {
+	switch format {
+	case FormatMarkdown:
+		return FromHTML(text)
+	case FormatText:
+		return ToText(text)
+	default:
+		return text
+	}
+}
+
+// FromHTML converts the HTML of the text into Markdown (texts without HTML are returned as is)
+// Scripts and styles are removed, entities are decoded, and the line breaks of the text are kept (platform HTML is often mixed with Markdown)
+func FromHTML(text string) string {
+	return convert(text, true)
+}
+
+// ToText converts the HTML of the text into plain text (texts without HTML are returned as is)
+func ToText(text string) string {
+	return convert(text, false)
+}
+
+// convert converts the HTML of the text into Markdown or plain text
+func convert(text string, markdown bool) string {
+	// Texts without HTML are kept as is (Markdown stays untouched)
+	if !HasHTML(text) {
+		return text
+	}
+
+	// Parse the HTML (the parser decodes the entities and never fails on a string reader)
+	document, err := goquery.NewDocumentFromReader(strings.NewReader(text))
+	if err != nil {
+		return text
+	}
+
+	// Remove the scripts, styles and other non-content elements
+	document.Find(removedElements).Remove()
+
+	// Render the body
+	w := &writer{markdown: markdown}
+	for _, body := range document.Find("body").Nodes {
+		w.children(body)
+	}
+
+	// Collapse the blank lines
+	return strings.TrimSpace(blankLinesRegExp.ReplaceAllString(w.String(), "\n\n"))
+}
+
+// attribute returns the value of the attribute of the node (empty if missing)
+func attribute(node *html.Node, key string) string {
+	for _, attr := range node.Attr {
+		if attr.Key == key {
+			return strings.TrimSpace(attr.Val)
+		}
+	}
+	return ""
+}
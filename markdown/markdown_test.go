@@ -0,0 +1,81 @@
+package markdown
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"plain text is kept", "Just *Markdown*\n\n- item", "Just *Markdown*\n\n- item"},
+		{"entities are decoded", "Tom &amp; Jerry &#8212; &quot;friends&quot;", `Tom & Jerry — "friends"`},
+		{"paragraphs", "<p>First   paragraph</p>\n<p>Second\nline</p>", "First paragraph\n\nSecond\nline"},
+		{"line breaks", "One<br>Two<br/>\nThree<br><br>Four", "One\nTwo\nThree\n\nFour"},
+		{"emphasis", "<b>Bold</b>, <i>italic</i>, <s>gone</s> and <code>x := 1</code>", "**Bold**, *italic*, ~~gone~~ and `x := 1`"},
+		{"headings", "<h2>Title</h2><p>Text</p>", "## Title\n\nText"},
+		{"links", `<a href="https://example.com">Example</a> <a href="https://a.com">https://a.com</a> <a href="javascript:void(0)">JS</a>`, "[Example](https://example.com) https://a.com JS"},
+		{"images", `<img src="https://example.com/a.png" alt="Avatar">`, "![Avatar](https://example.com/a.png)"},
+		{"linked images", `<a href="https://example.com"><img src="a.png" alt="A"></a>`, "[![A](a.png)](https://example.com)"},
+		{"scripts and styles are removed", `<style>p { color: red }</style><p>Safe</p><script>alert(1)</script>`, "Safe"},
+		{"lists", "<ul>\n<li>One</li>\n<li>Two<ol><li>Nested</li><li>Again</li></ol></li>\n</ul>", "- One\n- Two\n  1. Nested\n  2. Again"},
+		{"blockquotes", "<blockquote><p>Quote</p><p>More</p></blockquote><p>After</p>", "> Quote\n>\n> More\n\nAfter"},
+		{"preformatted", "<pre>line 1\n  line 2</pre>", "```\nline 1\n  line 2\n```"},
+		{"divs", "<div>Line 1</div><div>Line 2</div>", "Line 1\nLine 2"},
+		{"tables", "<table><tr><th>Name</th><th>Age</th></tr><tr><td>Mina</td><td>25</td></tr></table>", "Name | Age\nMina | 25"},
+		{"horizontal rules", "Above<hr>Below", "Above\n\n---\n\nBelow"},
+		{"mixed Markdown", "**Warning**: <span style=\"color:red\">dark</span> themes\n\n*enjoy*", "**Warning**: dark themes\n\n*enjoy*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, FromHTML(tt.input))
+		})
+	}
+}
+
+func TestToText(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"emphasis is dropped", "<b>Bold</b> and <em>italic</em>", "Bold and italic"},
+		{"links keep their URL", `<a href="https://example.com">Example</a>`, "Example (https://example.com)"},
+		{"images keep their URL", `<img src="a.png" alt="Avatar"> <img src="b.png">`, "Avatar (a.png) b.png"},
+		{"headings are plain", "<h1>Title</h1>Text", "Title\n\nText"},
+		{"blockquotes are plain", "<blockquote>Quote</blockquote>", "Quote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ToText(tt.input))
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	assert.Equal(t, "<b>x</b>", Convert("<b>x</b>", FormatKeep))
+	assert.Equal(t, "**x**", Convert("<b>x</b>", FormatMarkdown))
+	assert.Equal(t, "x", Convert("<b>x</b>", FormatText))
+
+	t.Run("should be idempotent", func(t *testing.T) {
+		once := FromHTML("<p>A &lt; B &amp; <b>C</b></p>")
+		assert.Equal(t, "A < B & **C**", once)
+		assert.Equal(t, once, FromHTML(once))
+	})
+}
+
+func TestHasHTML(t *testing.T) {
+	assert.True(t, HasHTML("<p>text</p>"))
+	assert.True(t, HasHTML("a<br/>b"))
+	assert.True(t, HasHTML("&amp;"))
+	assert.True(t, HasHTML("<!-- comment -->"))
+	assert.False(t, HasHTML("a < b > c"))
+	assert.False(t, HasHTML("<3 love"))
+	assert.False(t, HasHTML("Q&A"))
+}
@@ -0,0 +1,368 @@
+package markdown
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// blockElements are the HTML elements starting a new paragraph
+var blockElements = map[string]bool{
+	"p": true, "section": true, "article": true, "header": true, "footer": true, "main": true, "aside": true,
+	"nav": true, "figure": true, "details": true, "center": true, "address": true, "form": true, "fieldset": true,
+	"dl": true, "table": true, "thead": true, "tbody": true, "tfoot": true,
+}
+
+// lineElements are the HTML elements starting a new line
+var lineElements = map[string]bool{
+	"div": true, "tr": true, "dt": true, "dd": true, "summary": true, "figcaption": true, "caption": true,
+}
+
+// layoutElements are the HTML elements whose surrounding whitespace is not content
+var layoutElements = map[string]bool{
+	"br": true, "hr": true, "ul": true, "ol": true, "li": true, "blockquote": true, "pre": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "td": true, "th": true,
+}
+
+// list is an open HTML list (ordered lists are numbered)
+type list struct {
+	ordered bool
+	index   int
+}
+
+// writer renders HTML nodes as Markdown or plain text
+type writer struct {
+	buffer    []byte
+	prefixes  []string
+	lists     []list
+	pending   int
+	started   bool
+	pre       int
+	markdown  bool
+	lineStart bool
+}
+
+// String returns the rendered text
+func (w *writer) String() string {
+	return string(w.buffer)
+}
+
+// lineBreak requests a line break before the next content (consecutive breaks add up to a blank line)
+func (w *writer) lineBreak() {
+	w.pending++
+}
+
+// line requests the next content to start on a new line
+func (w *writer) line() {
+	w.pending = max(w.pending, 1)
+}
+
+// block requests the next content to start a new paragraph
+func (w *writer) block() {
+	w.pending = max(w.pending, 2)
+}
+
+// write writes inline content (after the requested line breaks and the line prefixes)
+func (w *writer) write(content string) {
+	if content == "" {
+		return
+	}
+
+	// Write the requested line breaks (at most a blank line, nothing before the first content)
+	if w.started && w.pending > 0 {
+		w.buffer = bytes.TrimRight(w.buffer, " \t")
+		for index := range min(w.pending, 2) {
+			if index > 0 {
+				w.buffer = append(w.buffer, strings.TrimRight(w.prefix(), " ")...)
+			}
+			w.buffer = append(w.buffer, '\n')
+		}
+		w.lineStart = true
+	}
+	w.pending = 0
+
+	// Write the line prefixes (blockquotes and list indentation)
+	if w.lineStart || !w.started {
+		w.buffer = append(w.buffer, w.prefix()...)
+		w.lineStart = false
+	}
+
+	w.buffer = append(w.buffer, content...)
+	w.started = true
+}
+
+// prefix returns the prefix of the current line
+func (w *writer) prefix() string {
+	return strings.Join(w.prefixes, "")
+}
+
+// atLineStart checks if the next content starts a line (leading spaces are dropped)
+func (w *writer) atLineStart() bool {
+	return !w.started || w.pending > 0 || w.lineStart || w.buffer[len(w.buffer)-1] == ' '
+}
+
+// children renders the children of the node
+func (w *writer) children(node *html.Node) {
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		w.node(child)
+	}
+}
+
+// inline renders the children of the node on a single line (for links and emphasis)
+func (w *writer) inline(node *html.Node) string {
+	sub := &writer{markdown: w.markdown, pre: w.pre}
+	sub.children(node)
+	return strings.TrimSpace(strings.Join(strings.Fields(sub.String()), " "))
+}
+
+// node renders the node
+func (w *writer) node(node *html.Node) {
+	switch node.Type {
+	case html.TextNode:
+		w.text(node)
+	case html.ElementNode:
+		w.element(node)
+	case html.DocumentNode:
+		w.children(node)
+	}
+}
+
+// text renders a text node (spaces are collapsed, line breaks are kept)
+func (w *writer) text(node *html.Node) {
+	content := node.Data
+
+	// Preformatted text is written as is
+	if w.pre > 0 {
+		for index, line := range strings.Split(content, "\n") {
+			if index > 0 {
+				w.lineBreak()
+			}
+			w.write(line)
+		}
+		return
+	}
+
+	// Whitespace around layout elements (or at the edges of their content) is not content
+	atEdge := node.PrevSibling == nil || node.NextSibling == nil
+	if strings.TrimSpace(content) == "" && (isLayout(node.PrevSibling) || isLayout(node.NextSibling) || atEdge && isLayout(node.Parent)) {
+		return
+	}
+	// The line break following a <br> is the same break
+	if isElement(node.PrevSibling, "br") {
+		content = strings.TrimLeft(content, " \t")
+		content = strings.TrimPrefix(content, "\n")
+	}
+
+	for index, line := range strings.Split(content, "\n") {
+		if index > 0 {
+			w.lineBreak()
+		}
+		// Collapse the spaces (keeping a single space around the words)
+		words := strings.Fields(line)
+		if len(words) == 0 {
+			if line != "" && !w.atLineStart() {
+				w.write(" ")
+			}
+			continue
+		}
+		collapsed := strings.Join(words, " ")
+		if line[0] == ' ' || line[0] == '\t' {
+			if !w.atLineStart() {
+				w.write(" ")
+			}
+		}
+		w.write(collapsed)
+		if last := line[len(line)-1]; last == ' ' || last == '\t' {
+			w.write(" ")
+		}
+	}
+}
+
+// element renders an element node
+func (w *writer) element(node *html.Node) {
+	switch tag := node.Data; tag {
+	case "br":
+		w.lineBreak()
+	case "hr":
+		w.block()
+		w.write("---")
+		w.block()
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		w.block()
+		if w.markdown {
+			level, _ := strconv.Atoi(tag[1:])
+			w.write(strings.Repeat("#", level) + " ")
+		}
+		w.children(node)
+		w.block()
+	case "ul", "ol":
+		w.list(node, tag == "ol")
+	case "li":
+		w.listItem(node)
+	case "blockquote":
+		w.block()
+		if w.markdown {
+			w.prefixes = append(w.prefixes, "> ")
+			defer func() { w.prefixes = w.prefixes[:len(w.prefixes)-1] }()
+		}
+		w.children(node)
+		w.block()
+	case "pre":
+		w.preformatted(node)
+	case "code":
+		if w.pre > 0 || !w.markdown {
+			w.children(node)
+			return
+		}
+		w.wrap(node, "`")
+	case "strong", "b":
+		w.wrap(node, "**")
+	case "em", "i":
+		w.wrap(node, "*")
+	case "del", "s", "strike":
+		w.wrap(node, "~~")
+	case "a":
+		w.link(node)
+	case "img":
+		w.image(node)
+	case "td", "th":
+		if previous := previousElement(node); previous != nil && (previous.Data == "td" || previous.Data == "th") {
+			w.write(" | ")
+		}
+		w.children(node)
+	default:
+		switch {
+		case blockElements[tag]:
+			w.block()
+			w.children(node)
+			w.block()
+		case lineElements[tag]:
+			w.line()
+			w.children(node)
+			w.line()
+		default:
+			w.children(node)
+		}
+	}
+}
+
+// list renders a list (nested lists start on the next line)
+func (w *writer) list(node *html.Node, ordered bool) {
+	if len(w.lists) > 0 {
+		w.line()
+	} else {
+		w.block()
+	}
+	w.lists = append(w.lists, list{ordered: ordered})
+	w.children(node)
+	w.lists = w.lists[:len(w.lists)-1]
+	if len(w.lists) > 0 {
+		w.line()
+	} else {
+		w.block()
+	}
+}
+
+// listItem renders a list item (continuation lines are indented below the marker)
+func (w *writer) listItem(node *html.Node) {
+	marker := "- "
+	if len(w.lists) > 0 {
+		current := &w.lists[len(w.lists)-1]
+		if current.ordered {
+			current.index++
+			marker = strconv.Itoa(current.index) + ". "
+		}
+	}
+	w.line()
+	w.write(marker)
+	w.prefixes = append(w.prefixes, strings.Repeat(" ", len(marker)))
+	w.children(node)
+	w.prefixes = w.prefixes[:len(w.prefixes)-1]
+	w.line()
+}
+
+// preformatted renders a preformatted block (fenced in Markdown)
+func (w *writer) preformatted(node *html.Node) {
+	w.block()
+	if w.markdown {
+		w.write("```")
+		w.lineBreak()
+	}
+	w.pre++
+	w.children(node)
+	w.pre--
+	if w.markdown {
+		w.line()
+		w.write("```")
+	}
+	w.block()
+}
+
+// wrap renders the inline content of the node between the markers (plain text drops the markers)
+func (w *writer) wrap(node *html.Node, marker string) {
+	if !w.markdown {
+		w.children(node)
+		return
+	}
+	if content := w.inline(node); content != "" {
+		w.write(marker + content + marker)
+	}
+}
+
+// link renders a link (Markdown link, or the text followed by the URL in plain text)
+func (w *writer) link(node *html.Node) {
+	href := attribute(node, "href")
+	content := w.inline(node)
+
+	switch {
+	// Links without a target (or to scripts and anchors) keep their text only
+	case href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:"):
+		w.write(content)
+	// Links without text (or showing their URL) are written as URLs
+	case content == "" || content == href:
+		w.write(href)
+	case w.markdown:
+		w.write("[" + content + "](" + href + ")")
+	default:
+		w.write(content + " (" + href + ")")
+	}
+}
+
+// image renders an image (Markdown image, or the alternative text followed by the URL in plain text)
+func (w *writer) image(node *html.Node) {
+	src, alt := attribute(node, "src"), attribute(node, "alt")
+
+	switch {
+	case src == "":
+		w.write(alt)
+	case w.markdown:
+		w.write("![" + alt + "](" + src + ")")
+	case alt == "":
+		w.write(src)
+	default:
+		w.write(alt + " (" + src + ")")
+	}
+}
+
+// isElement checks if the node is an element with the tag
+func isElement(node *html.Node, tag string) bool {
+	return node != nil && node.Type == html.ElementNode && node.Data == tag
+}
+
+// isLayout checks if the node is an element whose surrounding whitespace is not content
+func isLayout(node *html.Node) bool {
+	return node != nil && node.Type == html.ElementNode &&
+		(blockElements[node.Data] || lineElements[node.Data] || layoutElements[node.Data])
+}
+
+// previousElement returns the previous element sibling of the node (nil if none)
+func previousElement(node *html.Node) *html.Node {
+	for sibling := node.PrevSibling; sibling != nil; sibling = sibling.PrevSibling {
+		if sibling.Type == html.ElementNode {
+			return sibling
+		}
+	}
+	return nil
+}
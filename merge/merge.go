@@ -0,0 +1,480 @@
+package merge
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/card-parser/property"
+	"github.com/r3dpixel/toolkit/sonicx"
+)
+
+// ConflictKind represents the kind of merge conflict
+type ConflictKind string
+
+const (
+	// BothModified - the value was modified differently on both sides
+	BothModified ConflictKind = "both_modified"
+	// BothAdded - the value was added differently on both sides
+	BothAdded ConflictKind = "both_added"
+	// DeletedLocally - the value was deleted locally and modified remotely
+	DeletedLocally ConflictKind = "deleted_locally"
+	// DeletedRemotely - the value was modified locally and deleted remotely
+	DeletedRemotely ConflictKind = "deleted_remotely"
+)
+
+// Conflict represents a value that could not be merged automatically (the local value is kept in the merged sheet)
+type Conflict struct {
+	Path   string
+	Kind   ConflictKind
+	Base   any
+	Local  any
+	Remote any
+}
+
+// Result represents the result of a three-way merge
+type Result struct {
+	Sheet     *character.Sheet
+	Conflicts []Conflict
+}
+
+// HasConflicts checks if the merge produced any conflicts
+func (r *Result) HasConflicts() bool {
+	return len(r.Conflicts) > 0
+}
+
+// newGreetingPrefix prefixes the keys of the greetings missing from the base
+const newGreetingPrefix = "+"
+
+// merger accumulates the conflicts of a three-way merge
+type merger struct {
+	conflicts []Conflict
+}
+
+// Sheets merges the local edits and the remote changes of a sheet, using the previously fetched version as base
+//   - fields, greetings, tags, extensions and lorebook entries are merged independently (lorebook entries field by field)
+//   - a value changed on one side only takes the changed value
+//   - a value changed differently on both sides is reported as a conflict, and the local value is kept
+//   - a nil base is treated as an empty sheet (every difference between local and remote is a conflict)
+func Sheets(base, local, remote *character.Sheet) *Result {
+	// Nothing to merge if one of the sides is missing
+	switch {
+	case local == nil && remote == nil:
+		return &Result{}
+	case local == nil:
+		return &Result{Sheet: remote}
+	case remote == nil:
+		return &Result{Sheet: local}
+	case base == nil:
+		base = &character.Sheet{}
+	}
+
+	// Merge the sheet header
+	m := &merger{}
+	merged := &character.Sheet{
+		Spec:    value(m, "Spec", base.Spec, local.Spec, remote.Spec),
+		Version: value(m, "Version", base.Version, local.Version, remote.Version),
+	}
+
+	// Merge the lists entry by entry
+	merged.AlternateGreetings = m.greetings("AlternateGreetings", base.AlternateGreetings, local.AlternateGreetings, remote.AlternateGreetings)
+	merged.GroupGreetings = m.greetings("GroupGreetings", base.GroupGreetings, local.GroupGreetings, remote.GroupGreetings)
+	merged.Tags = m.set("Tags", base.Tags, local.Tags, remote.Tags)
+
+	// Merge the extensions key by key
+	merged.Extensions = m.extensions("Extensions", base.Extensions, local.Extensions, remote.Extensions)
+
+	// Merge the lorebook
+	merged.CharacterBook = m.book("CharacterBook", base.CharacterBook, local.CharacterBook, remote.CharacterBook)
+
+	// Merge the remaining fields one by one
+	m.fields(
+		"",
+		reflect.ValueOf(&base.Content).Elem(),
+		reflect.ValueOf(&local.Content).Elem(),
+		reflect.ValueOf(&remote.Content).Elem(),
+		reflect.ValueOf(&merged.Content).Elem(),
+		"AlternateGreetings", "GroupGreetings", "Tags", "Extensions", "CharacterBook",
+	)
+
+	// Return the merged sheet
+	return &Result{Sheet: merged, Conflicts: m.conflicts}
+}
+
+// value merges a single value
+func value[T any](m *merger, path string, base, local, remote T) T {
+	switch {
+	case reflect.DeepEqual(local, remote), reflect.DeepEqual(remote, base):
+		return local
+	case reflect.DeepEqual(local, base):
+		return remote
+	default:
+		m.conflict(path, BothModified, base, local, remote)
+		return local
+	}
+}
+
+// conflict records a conflict
+func (m *merger) conflict(path string, kind ConflictKind, base, local, remote any) {
+	m.conflicts = append(m.conflicts, Conflict{Path: path, Kind: kind, Base: base, Local: local, Remote: remote})
+}
+
+// fields merges all the exported fields of the given structs (except the skipped ones) into the output struct
+func (m *merger) fields(prefix string, base, local, remote, out reflect.Value, skip ...string) {
+	structType := out.Type()
+	for index := range structType.NumField() {
+		field := structType.Field(index)
+		// Skip unexported and specially handled fields
+		if !field.IsExported() || slices.Contains(skip, field.Name) {
+			continue
+		}
+		// Merge the field value
+		merged := value(m, prefix+field.Name, base.Field(index).Interface(), local.Field(index).Interface(), remote.Field(index).Interface())
+		if merged != nil {
+			out.Field(index).Set(reflect.ValueOf(merged))
+		}
+	}
+}
+
+// greetings merges greetings entry by entry (greetings are matched by text first, then by position)
+//   - conflict paths use the index of the greeting (in the base, or in its side for new greetings)
+func (m *merger) greetings(path string, base, local, remote property.StringArray) property.StringArray {
+	merged := list(m, path, base, local, remote, listMerge[string]{keys: alignGreetings, label: greetingLabel})
+	if merged == nil {
+		return nil
+	}
+	return property.StringArray(merged)
+}
+
+// set merges the lists as sets of values (additions and removals from both sides are applied)
+func (m *merger) set(path string, base, local, remote property.StringArray) property.StringArray {
+	merged := list(m, path, base, local, remote, listMerge[string]{keys: func(_ []string, side []string) []string {
+		return side
+	}})
+	if merged == nil {
+		return nil
+	}
+	return property.StringArray(merged)
+}
+
+// extensions merges the extensions key by key
+func (m *merger) extensions(path string, base, local, remote map[string]any) map[string]any {
+	// Nothing to merge if both sides have no extensions
+	if local == nil && remote == nil {
+		return nil
+	}
+
+	// Collect all the keys in a stable order
+	keys := slices.Sorted(maps.Keys(local))
+	for key := range remote {
+		if _, ok := local[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	// Merge each key
+	merged := make(map[string]any, len(keys))
+	for _, key := range keys {
+		baseValue, inBase := base[key]
+		localValue, inLocal := local[key]
+		remoteValue, inRemote := remote[key]
+		keyPath := path + "." + key
+
+		switch {
+		case inLocal && inRemote && !inBase:
+			if !reflect.DeepEqual(localValue, remoteValue) {
+				m.conflict(keyPath, BothAdded, nil, localValue, remoteValue)
+			}
+			merged[key] = localValue
+		case inLocal && inRemote:
+			merged[key] = value(m, keyPath, baseValue, localValue, remoteValue)
+		case inLocal && !inBase:
+			merged[key] = localValue
+		case inLocal:
+			// Deleted remotely
+			if !reflect.DeepEqual(localValue, baseValue) {
+				m.conflict(keyPath, DeletedRemotely, baseValue, localValue, nil)
+				merged[key] = localValue
+			}
+		case !inBase:
+			merged[key] = remoteValue
+		default:
+			// Deleted locally
+			if !reflect.DeepEqual(remoteValue, baseValue) {
+				m.conflict(keyPath, DeletedLocally, baseValue, nil, remoteValue)
+			}
+		}
+	}
+
+	// Return the merged extensions
+	return merged
+}
+
+// book merges the lorebooks field by field and entry by entry
+func (m *merger) book(path string, base, local, remote *character.Book) *character.Book {
+	// Merge the presence of the book
+	switch {
+	case local == nil && remote == nil:
+		return nil
+	case local == nil:
+		// Deleted locally (or added remotely)
+		if base != nil && !reflect.DeepEqual(remote, base) {
+			m.conflict(path, DeletedLocally, base, nil, remote)
+			return nil
+		}
+		if base != nil {
+			return nil
+		}
+		return remote
+	case remote == nil:
+		// Deleted remotely (or added locally)
+		if base != nil && !reflect.DeepEqual(local, base) {
+			m.conflict(path, DeletedRemotely, base, local, nil)
+			return local
+		}
+		if base != nil {
+			return nil
+		}
+		return local
+	case base == nil:
+		base = &character.Book{}
+	}
+
+	// Merge the book fields
+	merged := &character.Book{}
+	merged.Extensions = m.extensions(path+".Extensions", base.Extensions, local.Extensions, remote.Extensions)
+	merged.Entries = m.entries(path+".Entries", base.Entries, local.Entries, remote.Entries)
+	m.fields(
+		path+".",
+		reflect.ValueOf(base).Elem(),
+		reflect.ValueOf(local).Elem(),
+		reflect.ValueOf(remote).Elem(),
+		reflect.ValueOf(merged).Elem(),
+		"Extensions", "Entries",
+	)
+
+	// Return the merged book
+	return merged
+}
+
+// entries merges the lorebook entries one by one (entries are matched by ID, then by position)
+func (m *merger) entries(path string, base, local, remote []*character.BookEntry) []*character.BookEntry {
+	return list(m, path, base, local, remote, listMerge[*character.BookEntry]{
+		keys: func(_ []*character.BookEntry, side []*character.BookEntry) []string {
+			return entryKeys(side)
+		},
+		merge: m.entry,
+	})
+}
+
+// entry merges a lorebook entry field by field (core and extension fields one by one, raw extensions key by key)
+func (m *merger) entry(path string, base, local, remote *character.BookEntry) *character.BookEntry {
+	// Merge the entry as a single value if it is missing on one side
+	if base == nil || local == nil || remote == nil {
+		return value(m, path, base, local, remote)
+	}
+
+	// Merge the fields of the entry
+	merged := &character.BookEntry{}
+	m.fields(
+		path+".",
+		reflect.ValueOf(&base.BookEntryCore).Elem(),
+		reflect.ValueOf(&local.BookEntryCore).Elem(),
+		reflect.ValueOf(&remote.BookEntryCore).Elem(),
+		reflect.ValueOf(&merged.BookEntryCore).Elem(),
+	)
+	m.fields(
+		path+".Extensions.",
+		reflect.ValueOf(&base.Extensions).Elem(),
+		reflect.ValueOf(&local.Extensions).Elem(),
+		reflect.ValueOf(&remote.Extensions).Elem(),
+		reflect.ValueOf(&merged.Extensions).Elem(),
+	)
+	merged.RawExtensions = m.extensions(path+".RawExtensions", base.RawExtensions, local.RawExtensions, remote.RawExtensions)
+	m.fields(
+		path+".",
+		reflect.ValueOf(base).Elem(),
+		reflect.ValueOf(local).Elem(),
+		reflect.ValueOf(remote).Elem(),
+		reflect.ValueOf(merged).Elem(),
+		"BookEntryCore", "Extensions", "RawExtensions",
+	)
+
+	// Return the merged entry
+	return merged
+}
+
+// listMerge describes how the entries of a list are matched and merged
+type listMerge[T any] struct {
+	// keys keys the entries of a side (entries with the same key are the same entry)
+	keys func(base, side []T) []string
+	// label returns the label of an entry in the conflict paths from its key and its index in its side (defaults to the key)
+	label func(key string, index int) string
+	// merge merges an entry present on all sides (defaults to merging it as a single value)
+	merge func(path string, base, local, remote T) T
+}
+
+// list merges the lists entry by entry, using the keys of the list merge to match the entries
+//   - the merged list follows the local order, with the remote additions appended at the end
+func list[T any](m *merger, path string, base, local, remote []T, rules listMerge[T]) []T {
+	// Nothing to merge if both sides are empty
+	if local == nil && remote == nil {
+		return nil
+	}
+
+	// Use the default label and merge if not set
+	if rules.label == nil {
+		rules.label = func(key string, _ int) string {
+			return key
+		}
+	}
+	if rules.merge == nil {
+		rules.merge = func(path string, base, local, remote T) T {
+			return value(m, path, base, local, remote)
+		}
+	}
+
+	// Key the entries of each side
+	baseKeys := rules.keys(base, base)
+	localKeys := rules.keys(base, local)
+	remoteKeys := rules.keys(base, remote)
+	baseIndex := indexOf(baseKeys)
+	localIndex := indexOf(localKeys)
+	remoteIndex := indexOf(remoteKeys)
+
+	// Merge the local entries in order
+	merged := make([]T, 0, max(len(local), len(remote)))
+	for index, key := range localKeys {
+		localValue := local[index]
+		entryPath := fmt.Sprintf("%s[%s]", path, rules.label(key, index))
+		baseAt, inBase := baseIndex[key]
+		remoteAt, inRemote := remoteIndex[key]
+
+		switch {
+		case !inBase && inRemote:
+			// Added on both sides
+			if !reflect.DeepEqual(localValue, remote[remoteAt]) {
+				m.conflict(entryPath, BothAdded, nil, localValue, remote[remoteAt])
+			}
+			merged = append(merged, localValue)
+		case !inBase:
+			// Added locally
+			merged = append(merged, localValue)
+		case !inRemote:
+			// Deleted remotely
+			if !reflect.DeepEqual(localValue, base[baseAt]) {
+				m.conflict(entryPath, DeletedRemotely, base[baseAt], localValue, nil)
+				merged = append(merged, localValue)
+			}
+		default:
+			merged = append(merged, rules.merge(entryPath, base[baseAt], localValue, remote[remoteAt]))
+		}
+	}
+
+	// Merge the remote entries missing locally
+	for index, key := range remoteKeys {
+		if _, inLocal := localIndex[key]; inLocal {
+			continue
+		}
+		remoteValue := remote[index]
+		baseAt, inBase := baseIndex[key]
+
+		switch {
+		case !inBase:
+			// Added remotely
+			merged = append(merged, remoteValue)
+		case !reflect.DeepEqual(remoteValue, base[baseAt]):
+			// Deleted locally, modified remotely
+			m.conflict(fmt.Sprintf("%s[%s]", path, rules.label(key, index)), DeletedLocally, base[baseAt], nil, remoteValue)
+		}
+	}
+
+	// Return the merged list
+	return merged
+}
+
+// indexOf maps each key to its position
+func indexOf(keys []string) map[string]int {
+	index := make(map[string]int, len(keys))
+	for position, key := range keys {
+		if _, ok := index[key]; !ok {
+			index[key] = position
+		}
+	}
+	return index
+}
+
+// alignGreetings keys the greetings of a side by the position of the matching base greeting
+//   - an identical base greeting is matched first (greetings moved around keep their identity)
+//   - an unmatched greeting at the position of an unmatched base greeting is considered an edit
+//   - any other greeting is considered new (keyed by its text, so identical additions on both sides match)
+func alignGreetings(base, side []string) []string {
+	keys := make([]string, len(side))
+	used := make([]bool, len(base))
+	matched := make([]bool, len(side))
+
+	// Match the identical greetings (the same position is preferred)
+	for index, greeting := range side {
+		if index < len(base) && !used[index] && base[index] == greeting {
+			keys[index], used[index], matched[index] = strconv.Itoa(index), true, true
+		}
+	}
+	for index, greeting := range side {
+		if matched[index] {
+			continue
+		}
+		for baseIndex := range base {
+			if !used[baseIndex] && base[baseIndex] == greeting {
+				keys[index], used[baseIndex], matched[index] = strconv.Itoa(baseIndex), true, true
+				break
+			}
+		}
+	}
+
+	// Match the edited greetings by position, the rest are new greetings
+	for index, greeting := range side {
+		switch {
+		case matched[index]:
+			continue
+		case index < len(base) && !used[index]:
+			keys[index], used[index] = strconv.Itoa(index), true
+		default:
+			keys[index] = newGreetingPrefix + greeting
+		}
+	}
+
+	// Return the keys
+	return keys
+}
+
+// greetingLabel labels the greetings by index (the base index of known greetings, the index in their side for new greetings)
+func greetingLabel(key string, index int) string {
+	if strings.HasPrefix(key, newGreetingPrefix) {
+		return strconv.Itoa(index)
+	}
+	return key
+}
+
+// entryKeys keys the lorebook entries by ID (entries without a unique ID are keyed by position)
+func entryKeys(entries []*character.BookEntry) []string {
+	keys := make([]string, len(entries))
+	seen := make(map[string]bool, len(entries))
+	for index, entry := range entries {
+		keys[index] = "#" + strconv.Itoa(index)
+		if entry == nil {
+			continue
+		}
+		id, err := sonicx.Config.MarshalToString(entry.ID)
+		if err != nil || id == "" || id == "null" || id == `""` || seen[id] {
+			continue
+		}
+		seen[id] = true
+		keys[index] = "id=" + id
+	}
+	return keys
+}
@@ -0,0 +1,278 @@
+package merge
+
+import (
+	"testing"
+
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/card-parser/property"
+	"github.com/stretchr/testify/assert"
+)
+
+func testSheet() *character.Sheet {
+	return &character.Sheet{
+		Content: character.Content{
+			Name:               "Name",
+			Description:        "Description",
+			Personality:        "Personality",
+			FirstMessage:       "Hello",
+			AlternateGreetings: property.StringArray{"Greeting 1", "Greeting 2", "Greeting 3"},
+			Tags:               property.StringArray{"Tag 1", "Tag 2"},
+			Extensions:         map[string]any{"key": "value"},
+			CharacterBook: &character.Book{
+				Name: "Book",
+				Entries: []*character.BookEntry{
+					{BookEntryCore: character.BookEntryCore{ID: property.Union{V: 1}, Content: "Entry 1"}},
+					{BookEntryCore: character.BookEntryCore{ID: property.Union{V: 2}, Content: "Entry 2"}},
+				},
+			},
+		},
+	}
+}
+
+func TestSheets_Fields(t *testing.T) {
+	base := testSheet()
+	local := testSheet()
+	remote := testSheet()
+
+	local.Description = "Fixed typo"
+	remote.Personality = "New personality"
+	remote.Scenario = "New scenario"
+
+	result := Sheets(base, local, remote)
+
+	assert.False(t, result.HasConflicts())
+	assert.Equal(t, property.String("Fixed typo"), result.Sheet.Description)
+	assert.Equal(t, property.String("New personality"), result.Sheet.Personality)
+	assert.Equal(t, property.String("New scenario"), result.Sheet.Scenario)
+	assert.Equal(t, property.String("Name"), result.Sheet.Name)
+}
+
+func TestSheets_Conflicts(t *testing.T) {
+	base := testSheet()
+	local := testSheet()
+	remote := testSheet()
+
+	local.FirstMessage = "Local hello"
+	remote.FirstMessage = "Remote hello"
+
+	result := Sheets(base, local, remote)
+
+	assert.True(t, result.HasConflicts())
+	assert.Equal(t, property.String("Local hello"), result.Sheet.FirstMessage, "the local value should be kept")
+	if assert.Len(t, result.Conflicts, 1) {
+		assert.Equal(t, Conflict{
+			Path:   "FirstMessage",
+			Kind:   BothModified,
+			Base:   property.String("Hello"),
+			Local:  property.String("Local hello"),
+			Remote: property.String("Remote hello"),
+		}, result.Conflicts[0])
+	}
+}
+
+func TestSheets_Greetings(t *testing.T) {
+	t.Run("should merge greetings entry by entry", func(t *testing.T) {
+		base := testSheet()
+		local := testSheet()
+		remote := testSheet()
+
+		local.AlternateGreetings[0] = "Greeting 1 (edited)"
+		remote.AlternateGreetings = property.StringArray{"Greeting 1", "Greeting 3", "Greeting 4"}
+
+		result := Sheets(base, local, remote)
+
+		assert.False(t, result.HasConflicts())
+		assert.Equal(t, property.StringArray{"Greeting 1 (edited)", "Greeting 3", "Greeting 4"}, result.Sheet.AlternateGreetings)
+	})
+
+	t.Run("should report greetings deleted remotely and edited locally", func(t *testing.T) {
+		base := testSheet()
+		local := testSheet()
+		remote := testSheet()
+
+		local.AlternateGreetings[2] = "Greeting 3 (edited)"
+		remote.AlternateGreetings = property.StringArray{"Greeting 1", "Greeting 2"}
+
+		result := Sheets(base, local, remote)
+
+		assert.Equal(t, property.StringArray{"Greeting 1", "Greeting 2", "Greeting 3 (edited)"}, result.Sheet.AlternateGreetings)
+		if assert.Len(t, result.Conflicts, 1) {
+			assert.Equal(t, "AlternateGreetings[2]", result.Conflicts[0].Path)
+			assert.Equal(t, DeletedRemotely, result.Conflicts[0].Kind)
+		}
+	})
+
+	t.Run("should keep the greetings added differently on both sides", func(t *testing.T) {
+		base := testSheet()
+		local := testSheet()
+		remote := testSheet()
+
+		local.AlternateGreetings = append(local.AlternateGreetings, "Local greeting")
+		remote.AlternateGreetings = append(remote.AlternateGreetings, "Remote greeting")
+
+		result := Sheets(base, local, remote)
+
+		assert.Equal(t, property.StringArray{"Greeting 1", "Greeting 2", "Greeting 3", "Local greeting", "Remote greeting"}, result.Sheet.AlternateGreetings)
+		assert.False(t, result.HasConflicts(), "different additions are both kept")
+		assert.Equal(t, "3", greetingLabel(newGreetingPrefix+"Local greeting", 3), "new greetings are labeled by index")
+	})
+
+	t.Run("should report greetings edited on both sides", func(t *testing.T) {
+		base := testSheet()
+		local := testSheet()
+		remote := testSheet()
+
+		local.AlternateGreetings[1] = "Local greeting"
+		remote.AlternateGreetings[1] = "Remote greeting"
+
+		result := Sheets(base, local, remote)
+
+		if assert.Len(t, result.Conflicts, 1) {
+			assert.Equal(t, "AlternateGreetings[1]", result.Conflicts[0].Path)
+			assert.Equal(t, BothModified, result.Conflicts[0].Kind)
+		}
+	})
+}
+
+func TestSheets_Tags(t *testing.T) {
+	base := testSheet()
+	local := testSheet()
+	remote := testSheet()
+
+	local.Tags = property.StringArray{"Tag 1", "Tag 2", "Local"}
+	remote.Tags = property.StringArray{"Tag 2", "Remote"}
+
+	result := Sheets(base, local, remote)
+
+	assert.False(t, result.HasConflicts())
+	assert.Equal(t, property.StringArray{"Tag 2", "Local", "Remote"}, result.Sheet.Tags)
+}
+
+func TestSheets_Extensions(t *testing.T) {
+	base := testSheet()
+	local := testSheet()
+	remote := testSheet()
+
+	local.Extensions["local"] = true
+	remote.Extensions["key"] = "changed"
+	remote.Extensions["remote"] = true
+
+	result := Sheets(base, local, remote)
+
+	assert.False(t, result.HasConflicts())
+	assert.Equal(t, map[string]any{"key": "changed", "local": true, "remote": true}, result.Sheet.Extensions)
+}
+
+func TestSheets_Book(t *testing.T) {
+	t.Run("should merge lorebook entries by ID", func(t *testing.T) {
+		base := testSheet()
+		local := testSheet()
+		remote := testSheet()
+
+		local.CharacterBook.Entries[0].Content = "Entry 1 (edited)"
+		remote.CharacterBook.Name = "Remote book"
+		remote.CharacterBook.Entries = []*character.BookEntry{
+			{BookEntryCore: character.BookEntryCore{ID: property.Union{V: 3}, Content: "Entry 3"}},
+			{BookEntryCore: character.BookEntryCore{ID: property.Union{V: 1}, Content: "Entry 1"}},
+		}
+
+		result := Sheets(base, local, remote)
+
+		assert.False(t, result.HasConflicts())
+		assert.Equal(t, property.String("Remote book"), result.Sheet.CharacterBook.Name)
+		if assert.Len(t, result.Sheet.CharacterBook.Entries, 2) {
+			assert.Equal(t, property.String("Entry 1 (edited)"), result.Sheet.CharacterBook.Entries[0].Content)
+			assert.Equal(t, property.String("Entry 3"), result.Sheet.CharacterBook.Entries[1].Content)
+		}
+	})
+
+	t.Run("should merge the fields of lorebook entries edited on both sides", func(t *testing.T) {
+		base := testSheet()
+		local := testSheet()
+		remote := testSheet()
+
+		local.CharacterBook.Entries[0].Content = "Entry 1 (edited)"
+		remote.CharacterBook.Entries[0].Keys = property.StringArray{"remote key"}
+		remote.CharacterBook.Entries[0].Extensions.Depth = 2
+		remote.CharacterBook.Entries[0].RawExtensions = map[string]any{"remote": true}
+
+		result := Sheets(base, local, remote)
+
+		assert.False(t, result.HasConflicts())
+		merged := result.Sheet.CharacterBook.Entries[0]
+		assert.Equal(t, property.String("Entry 1 (edited)"), merged.Content)
+		assert.Equal(t, property.StringArray{"remote key"}, merged.Keys)
+		assert.Equal(t, property.Integer(2), merged.Extensions.Depth)
+		assert.Equal(t, map[string]any{"remote": true}, merged.RawExtensions)
+	})
+
+	t.Run("should report the fields of lorebook entries edited differently on both sides", func(t *testing.T) {
+		base := testSheet()
+		local := testSheet()
+		remote := testSheet()
+
+		local.CharacterBook.Entries[0].Content = "Local"
+		remote.CharacterBook.Entries[0].Content = "Remote"
+
+		result := Sheets(base, local, remote)
+
+		if assert.Len(t, result.Conflicts, 1) {
+			assert.Regexp(t, `^CharacterBook\.Entries\[id=.+\]\.Content$`, result.Conflicts[0].Path)
+			assert.Equal(t, BothModified, result.Conflicts[0].Kind)
+		}
+	})
+
+	t.Run("should report lorebook entries deleted locally and modified remotely", func(t *testing.T) {
+		base := testSheet()
+		local := testSheet()
+		remote := testSheet()
+
+		local.CharacterBook.Entries = local.CharacterBook.Entries[:1]
+		remote.CharacterBook.Entries[1].Content = "Entry 2 (updated)"
+
+		result := Sheets(base, local, remote)
+
+		assert.Len(t, result.Sheet.CharacterBook.Entries, 1)
+		if assert.Len(t, result.Conflicts, 1) {
+			assert.Equal(t, DeletedLocally, result.Conflicts[0].Kind)
+			assert.Nil(t, result.Conflicts[0].Local)
+		}
+	})
+
+	t.Run("should keep the book added remotely", func(t *testing.T) {
+		base := testSheet()
+		local := testSheet()
+		remote := testSheet()
+		base.CharacterBook, local.CharacterBook = nil, nil
+
+		result := Sheets(base, local, remote)
+
+		assert.False(t, result.HasConflicts())
+		assert.Same(t, remote.CharacterBook, result.Sheet.CharacterBook)
+	})
+}
+
+func TestSheets_MissingSides(t *testing.T) {
+	sheet := testSheet()
+
+	assert.Nil(t, Sheets(nil, nil, nil).Sheet)
+	assert.Same(t, sheet, Sheets(nil, nil, sheet).Sheet)
+	assert.Same(t, sheet, Sheets(nil, sheet, nil).Sheet)
+
+	t.Run("should report every difference without a base", func(t *testing.T) {
+		local := testSheet()
+		remote := testSheet()
+		remote.Description = "Remote"
+
+		result := Sheets(nil, local, remote)
+
+		assert.Equal(t, property.String("Description"), result.Sheet.Description)
+		assert.Contains(t, result.Conflicts, Conflict{
+			Path:   "Description",
+			Kind:   BothModified,
+			Base:   property.String(""),
+			Local:  property.String("Description"),
+			Remote: property.String("Remote"),
+		})
+	})
+}
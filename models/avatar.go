@@ -0,0 +1,17 @@
+package models
+
+// AvatarFallback is the fallback that provided the avatar of a card (empty if the primary avatar was fetched)
+type AvatarFallback string
+
+// Avatar fallbacks (in the order they are tried)
+const (
+	AvatarFallbackNone        AvatarFallback = ""
+	AvatarFallbackAlternate   AvatarFallback = "alternate_url"
+	AvatarFallbackGenerated   AvatarFallback = "generated"
+	AvatarFallbackPlaceholder AvatarFallback = "placeholder"
+)
+
+// IsSynthetic checks if the avatar was not fetched from the source (generated or placeholder)
+func (f AvatarFallback) IsSynthetic() bool {
+	return f == AvatarFallbackGenerated || f == AvatarFallbackPlaceholder
+}
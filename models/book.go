@@ -0,0 +1,35 @@
+package models
+
+import "github.com/r3dpixel/toolkit/timestamp"
+
+// BookOrigin is the origin of a book of a card
+type BookOrigin string
+
+// Book origins
+const (
+	// BookOriginEmbedded is the book embedded in the card (including the loose entries, e.g. WyvernChat lexicon)
+	BookOriginEmbedded BookOrigin = "embedded"
+	// BookOriginLinked is a shared book linked to the card
+	BookOriginLinked BookOrigin = "linked"
+	// BookOriginAuxiliary is a shared book referenced in the card text (tagline, description)
+	BookOriginAuxiliary BookOrigin = "auxiliary"
+)
+
+// BookInfo describes a book of a card (shared books can be tracked and reused across cards by their platform ID)
+type BookInfo struct {
+	Origin BookOrigin
+	// PlatformID is the platform ID of the book (empty for embedded books)
+	PlatformID string
+	Name       string
+	// Creator is the name of the creator of the book (empty if the platform does not expose it)
+	Creator    string
+	EntryCount int
+	UpdateTime timestamp.Nano
+	// URL is the direct URL of the book (empty if the platform has no book pages)
+	URL string
+}
+
+// IsShared checks if the book is a shared book (linked or referenced in the card text)
+func (b *BookInfo) IsShared() bool {
+	return b.Origin != BookOriginEmbedded
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"errors"
+
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/toolkit/sonicx"
+)
+
+// ExtensionKey is the key of the metadata extension inside the sheet extensions
+const ExtensionKey = "card_fetcher"
+
+// ErrMissingExtension is returned when the sheet has no metadata extension
+var ErrMissingExtension = errors.New("sheet has no " + ExtensionKey + " extension")
+
+// ToExtension serializes the metadata into a generic JSON value (suitable for the sheet extensions)
+// The value follows the metadata JSON schema (see SchemaVersion), without the volatile fields (see Stable)
+func (m *Metadata) ToExtension() (map[string]any, error) {
+	// Encode the stable metadata (the volatile fields would make the card output unstable)
+	raw, err := sonicx.Config.Marshal(m.Stable())
+	if err != nil {
+		return nil, err
+	}
+
+	// Convert it into a generic JSON value (the same shape it has after decoding a card)
+	var value map[string]any
+	if err := sonicx.Config.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+
+	// Return the generic JSON value
+	return value, nil
+}
+
+// EmbedIn embeds the metadata inside the sheet extensions (under ExtensionKey)
+func (m *Metadata) EmbedIn(sheet *character.Sheet) error {
+	// Serialize the metadata
+	extension, err := m.ToExtension()
+	if err != nil {
+		return err
+	}
+
+	// Embed the metadata
+	if sheet.Extensions == nil {
+		sheet.Extensions = make(map[string]any)
+	}
+	sheet.Extensions[ExtensionKey] = extension
+	return nil
+}
+
+// MetadataFromSheet rebuilds the metadata embedded inside the sheet extensions (no network access)
+// Extensions written with older schema versions are migrated
+func MetadataFromSheet(sheet *character.Sheet) (*Metadata, error) {
+	// Retrieve the extension
+	if sheet == nil || sheet.Extensions == nil {
+		return nil, ErrMissingExtension
+	}
+	value, ok := sheet.Extensions[ExtensionKey]
+	if !ok || value == nil {
+		return nil, ErrMissingExtension
+	}
+
+	// Decode the extension
+	raw, err := sonicx.Config.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	metadata := &Metadata{}
+	if err := metadata.UnmarshalJSON(raw); err != nil {
+		return nil, err
+	}
+
+	// Return the metadata
+	return metadata, nil
+}
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/r3dpixel/card-fetcher/source"
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/toolkit/sonicx"
+	"github.com/r3dpixel/toolkit/timestamp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func extensionMetadata() *Metadata {
+	createTime := time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.UTC)
+	return &Metadata{
+		Source: source.ChubAI,
+		CardInfo: CardInfo{
+			NormalizedURL: "chub.ai/characters/creator/card",
+			DirectURL:     "chub.ai/characters/creator/card",
+			PlatformID:    "123",
+			CharacterID:   "creator/card",
+			Name:          "Name",
+			Title:         "Title",
+			Tagline:       "Tagline",
+			CreateTime:    timestamp.Nano(createTime.UnixNano()),
+			UpdateTime:    timestamp.Nano(createTime.Add(time.Hour).UnixNano()),
+			IsForked:      true,
+			Fork:          &ForkInfo{Source: source.ChubAI, CharacterID: "original/card", URL: "chub.ai/characters/original/card"},
+			Tags:          []Tag{{Slug: "fantasy", Name: "Fantasy"}, {Slug: "sci-fi", Name: "Sci-Fi"}},
+		},
+		CreatorInfo: CreatorInfo{
+			Nickname:   "Creator",
+			Username:   "creator",
+			PlatformID: "456",
+		},
+		BookUpdateTime: timestamp.Nano(createTime.Add(2 * time.Hour).UnixNano()),
+		GreetingsCount: 3,
+		HasBook:        true,
+	}
+}
+
+func TestMetadata_EmbedIn(t *testing.T) {
+	metadata := extensionMetadata()
+	sheet := &character.Sheet{}
+
+	require.NoError(t, metadata.EmbedIn(sheet))
+
+	extension, ok := sheet.Extensions[ExtensionKey].(map[string]any)
+	require.True(t, ok)
+	assert.EqualValues(t, SchemaVersion, extension["schema_version"])
+	assert.Equal(t, "2024-01-02T03:04:05.123456789Z", extension["card"].(map[string]any)["create_time"])
+	assert.Equal(t, "creator", extension["creator"].(map[string]any)["username"])
+	assert.Equal(t, "456", extension["creator"].(map[string]any)["platform_id"])
+}
+
+func TestMetadataFromSheet(t *testing.T) {
+	t.Run("should rebuild the embedded metadata", func(t *testing.T) {
+		metadata := extensionMetadata()
+		sheet := &character.Sheet{Content: character.Content{Extensions: map[string]any{"other": true}}}
+		require.NoError(t, metadata.EmbedIn(sheet))
+
+		rebuilt, err := MetadataFromSheet(sheet)
+
+		require.NoError(t, err)
+		assert.Equal(t, metadata, rebuilt)
+		assert.Equal(t, true, sheet.Extensions["other"], "other extensions should be kept")
+	})
+
+	t.Run("should rebuild the metadata after a JSON round trip", func(t *testing.T) {
+		metadata := extensionMetadata()
+		metadata.BookUpdateTime = 0
+		metadata.HasBook = false
+		sheet := &character.Sheet{}
+		require.NoError(t, metadata.EmbedIn(sheet))
+
+		raw, err := sonicx.Config.Marshal(sheet.Extensions)
+		require.NoError(t, err)
+		var extensions map[string]any
+		require.NoError(t, sonicx.Config.Unmarshal(raw, &extensions))
+
+		rebuilt, err := MetadataFromSheet(&character.Sheet{Content: character.Content{Extensions: extensions}})
+
+		require.NoError(t, err)
+		assert.Equal(t, metadata, rebuilt)
+	})
+
+	t.Run("should fail without the extension", func(t *testing.T) {
+		_, err := MetadataFromSheet(nil)
+		assert.ErrorIs(t, err, ErrMissingExtension)
+
+		_, err = MetadataFromSheet(&character.Sheet{})
+		assert.ErrorIs(t, err, ErrMissingExtension)
+	})
+
+	t.Run("should fail for unsupported schema versions", func(t *testing.T) {
+		sheet := &character.Sheet{Content: character.Content{Extensions: map[string]any{
+			ExtensionKey: map[string]any{"schema_version": SchemaVersion + 1},
+		}}}
+
+		_, err := MetadataFromSheet(sheet)
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail for malformed timestamps", func(t *testing.T) {
+		sheet := &character.Sheet{Content: character.Content{Extensions: map[string]any{
+			ExtensionKey: map[string]any{"schema_version": SchemaVersion, "card": map[string]any{"create_time": "yesterday"}},
+		}}}
+
+		_, err := MetadataFromSheet(sheet)
+		assert.Error(t, err)
+	})
+}
+
+func TestMetadata_Stable(t *testing.T) {
+	metadata := extensionMetadata()
+	metadata.Stats = Stats{FetchTime: 1}
+	metadata.Tokens = &TokenCounts{Description: 10}
+	metadata.Provenance = NewProvenance()
+	metadata.AvatarFallback = AvatarFallbackPlaceholder
+	metadata.Languages = []DetectedLanguage{{Code: "en", Confidence: 1}}
+
+	stable := metadata.Stable()
+
+	assert.Equal(t, Stats{}, stable.Stats)
+	assert.Nil(t, stable.Tokens)
+	assert.Nil(t, stable.Provenance)
+	assert.Equal(t, AvatarFallbackNone, stable.AvatarFallback)
+	assert.Equal(t, metadata.Languages, stable.Languages)
+	assert.NotNil(t, metadata.Tokens, "the metadata is not modified")
+
+	// The extension holds the stable metadata only
+	extension, err := metadata.ToExtension()
+	require.NoError(t, err)
+	embedded, err := stable.ToExtension()
+	require.NoError(t, err)
+	assert.Equal(t, embedded, extension)
+	assert.NotContains(t, extension, "avatar_fallback")
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"github.com/r3dpixel/card-fetcher/source"
+)
+
+// ForkInfo represents the parent of a forked card (fields are empty when the platform does not provide them)
+type ForkInfo struct {
+	// Source is the source of the parent card
+	Source source.ID
+	// PlatformID is the platform ID of the parent card
+	PlatformID string
+	// CharacterID is the character ID of the parent card (used to fetch it through the router)
+	CharacterID string
+	// URL is the direct URL of the parent card
+	URL string
+}
+
+// IsResolvable checks if the parent card can be fetched (known character ID or URL)
+func (f *ForkInfo) IsResolvable() bool {
+	return f != nil && ((f.Source != "" && f.CharacterID != "") || f.URL != "")
+}
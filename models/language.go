@@ -0,0 +1,20 @@
+package models
+
+// DetectedLanguage represents a language detected in the content of a card
+type DetectedLanguage struct {
+	// Code is the ISO 639-1 code of the language
+	Code string
+	// Confidence is the share of the content written in the language (0-1)
+	Confidence float64
+}
+
+// EnglishCode is the ISO 639-1 code of English
+const EnglishCode = "en"
+
+// PrimaryLanguage returns the code of the most confident language (empty if no language was detected)
+func (m *Metadata) PrimaryLanguage() string {
+	if len(m.Languages) == 0 {
+		return ""
+	}
+	return m.Languages[0].Code
+}
@@ -19,8 +19,14 @@ type Metadata struct {
 	CardInfo
 	CreatorInfo
 	BookUpdateTime timestamp.Nano
+	Books          []BookInfo
 	GreetingsCount int
 	HasBook        bool
+	Stats          Stats
+	Tokens         *TokenCounts
+	Languages      []DetectedLanguage
+	Provenance     *Provenance
+	AvatarFallback AvatarFallback
 }
 
 // LatestUpdateTime returns the latest update time of the card
@@ -28,46 +34,14 @@ func (m *Metadata) LatestUpdateTime() timestamp.Nano {
 	return max(m.CardInfo.UpdateTime, m.BookUpdateTime)
 }
 
-// Integrity checks if the metadata is valid
+// Integrity checks if the metadata is valid (see Validate for the violated rules)
 func (m *Metadata) Integrity() bool {
-	return stringsx.IsNotBlank(string(m.Source)) &&
-		stringsx.IsNotBlank(m.NormalizedURL) &&
-		stringsx.IsNotBlank(m.DirectURL) &&
-		stringsx.IsNotBlank(m.CardInfo.PlatformID) &&
-		stringsx.IsNotBlank(m.CharacterID) &&
-		stringsx.IsNotBlank(m.Name) &&
-		stringsx.IsNotBlank(m.Title) &&
-		m.CreateTime > 0 &&
-		m.UpdateTime > 0 &&
-		m.UpdateTime >= m.CreateTime &&
-		stringsx.IsNotBlank(m.Nickname) &&
-		stringsx.IsNotBlank(m.Username) &&
-		(strings.ToLower(m.Nickname) == anonymousIdentifier || stringsx.IsNotBlank(m.CreatorInfo.PlatformID))
+	return m.Validate().Valid()
 }
 
-// IsConsistentWith checks if the metadata is consistent with the card
+// IsConsistentWith checks if the metadata is consistent with the card (see ValidateAgainst for the violated rules)
 func (m *Metadata) IsConsistentWith(card *character.Sheet) bool {
-	if card == nil {
-		return m == nil
-	}
-	metadataTags := TagsToNames(m.CardInfo.Tags)
-
-	return m.Integrity() &&
-		card.Integrity() &&
-		string(m.Source) == string(card.SourceID) &&
-		m.CharacterID == string(card.CharacterID) &&
-		m.CardInfo.PlatformID == string(card.PlatformID) &&
-		m.DirectURL == string(card.DirectLink) &&
-		m.Title == string(card.Title) &&
-		m.Name == string(card.Name) &&
-		m.Nickname == string(card.Creator) &&
-		strings.HasPrefix(string(card.CreatorNotes), m.Tagline) &&
-		timestamp.ConvertToSeconds(m.CreateTime) == card.CreationDate &&
-		timestamp.ConvertToSeconds(m.LatestUpdateTime()) == card.ModificationDate &&
-		m.HasBook == (card.CharacterBook != nil) &&
-		((!m.HasBook && m.BookUpdateTime == 0) || (m.HasBook && m.BookUpdateTime != 0)) &&
-		m.GreetingsCount == len(card.AlternateGreetings) &&
-		slices.Equal(metadataTags, card.Tags)
+	return m.ValidateAgainst(card).Valid()
 }
 
 // Clone returns a deep copy of the Metadata struct
@@ -81,10 +55,44 @@ func (m *Metadata) Clone() *Metadata {
 	// Set the cloned tags
 	clone.Tags = tags
 
+	// Clone the statistics
+	clone.Stats = m.Stats.Clone()
+
+	// Clone the books
+	clone.Books = slices.Clone(m.Books)
+
+	// Clone the detected languages
+	clone.Languages = slices.Clone(m.Languages)
+
+	// Clone the token counts
+	clone.Tokens = m.Tokens.Clone()
+
+	// Clone the field provenance
+	clone.Provenance = m.Provenance.Clone()
+
+	// Clone the fork information
+	if m.Fork != nil {
+		fork := *m.Fork
+		clone.Fork = &fork
+	}
+
 	// Return the cloned metadata
 	return &clone
 }
 
+// Stable returns a copy of the metadata without the volatile fields, which can differ between two fetches of an unchanged card:
+// the engagement statistics, the token counts (derived from the sheet with the configured tokenizer),
+// and the field provenance and avatar fallback (describing a single fetch)
+// The stable metadata is embedded in the sheets (see ToExtension) and compared by the integration checks
+func (m *Metadata) Stable() *Metadata {
+	stable := m.Clone()
+	stable.Stats = Stats{}
+	stable.Tokens = nil
+	stable.Provenance = nil
+	stable.AvatarFallback = AvatarFallbackNone
+	return stable
+}
+
 // CardInfo struct for storing card information
 type CardInfo struct {
 	NormalizedURL string
@@ -97,6 +105,8 @@ type CardInfo struct {
 	CreateTime    timestamp.Nano
 	UpdateTime    timestamp.Nano
 	IsForked      bool
+	Fork          *ForkInfo
+	Rating        Rating
 	Tags          []Tag
 }
 
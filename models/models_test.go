@@ -499,6 +499,7 @@ func TestMetadata_Clone(t *testing.T) {
 			PlatformID: "creator-123",
 		},
 		BookUpdateTime: timestamp.Nano(time.Now().UnixNano()),
+		Books:          []BookInfo{{Origin: BookOriginLinked, PlatformID: "book-1", Name: "World", EntryCount: 3}},
 	}
 
 	clone := original.Clone()
@@ -520,4 +521,22 @@ func TestMetadata_Clone(t *testing.T) {
 		clone.Tags = append(clone.Tags, Tag{Slug: "tag-3", Name: "Tag Three"})
 		assert.NotEqual(t, len(original.Tags), len(clone.Tags), "Appending to the clone's Tags slice should not affect the original slice")
 	})
+
+	t.Run("Books slice is a separate instance", func(t *testing.T) {
+		clone.Books[0].EntryCount = 4
+		assert.Equal(t, 3, original.Books[0].EntryCount, "Modifying the clone's book should not affect the original's book")
+	})
+}
+
+func TestBookInfo_IsShared(t *testing.T) {
+	assert.False(t, (&BookInfo{Origin: BookOriginEmbedded}).IsShared())
+	assert.True(t, (&BookInfo{Origin: BookOriginLinked}).IsShared())
+	assert.True(t, (&BookInfo{Origin: BookOriginAuxiliary}).IsShared())
+}
+
+func TestMetadata_PrimaryLanguage(t *testing.T) {
+	metadata := &Metadata{}
+	assert.Empty(t, metadata.PrimaryLanguage())
+	metadata.Languages = []DetectedLanguage{{Code: "ja", Confidence: 0.8}, {Code: "en", Confidence: 0.2}}
+	assert.Equal(t, "ja", metadata.PrimaryLanguage())
 }
@@ -0,0 +1,179 @@
+package models
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/toolkit/stringsx"
+)
+
+// Origin is the origin of the value of a sheet field
+type Origin string
+
+// Origins of the sheet field values
+const (
+	OriginUnknown  Origin = ""
+	OriginPNG      Origin = "png"
+	OriginAPI      Origin = "api"
+	OriginMetadata Origin = "metadata"
+	OriginPatcher  Origin = "patcher"
+)
+
+// SheetField identifies a traced field of the sheet
+type SheetField string
+
+// Traced fields of the sheet
+const (
+	FieldName                    SheetField = "name"
+	FieldTitle                   SheetField = "title"
+	FieldDescription             SheetField = "description"
+	FieldPersonality             SheetField = "personality"
+	FieldScenario                SheetField = "scenario"
+	FieldFirstMessage            SheetField = "first_message"
+	FieldMessageExamples         SheetField = "message_examples"
+	FieldCreatorNotes            SheetField = "creator_notes"
+	FieldSystemPrompt            SheetField = "system_prompt"
+	FieldPostHistoryInstructions SheetField = "post_history_instructions"
+	FieldAlternateGreetings      SheetField = "alternate_greetings"
+	FieldTags                    SheetField = "tags"
+	FieldCreator                 SheetField = "creator"
+)
+
+// SheetFields lists the traced fields of the sheet (in sheet order)
+var SheetFields = []SheetField{
+	FieldName, FieldTitle, FieldDescription, FieldPersonality, FieldScenario, FieldFirstMessage, FieldMessageExamples,
+	FieldCreatorNotes, FieldSystemPrompt, FieldPostHistoryInstructions, FieldAlternateGreetings, FieldTags, FieldCreator,
+}
+
+// RulePNGDrift is the rule reported for the fields where the PNG is stale compared with the API (see Provenance.DriftReport)
+const RulePNGDrift RuleID = "sheet.png.drift"
+
+// listSeparator joins the values of the list fields (greetings and tags)
+const listSeparator = "\n\n"
+
+// SheetFieldValues returns the values of the traced fields of the sheet (list fields are joined with blank lines)
+func SheetFieldValues(sheet *character.Sheet) map[SheetField]string {
+	return map[SheetField]string{
+		FieldName:                    string(sheet.Name),
+		FieldTitle:                   string(sheet.Title),
+		FieldDescription:             string(sheet.Description),
+		FieldPersonality:             string(sheet.Personality),
+		FieldScenario:                string(sheet.Scenario),
+		FieldFirstMessage:            string(sheet.FirstMessage),
+		FieldMessageExamples:         string(sheet.MessageExamples),
+		FieldCreatorNotes:            string(sheet.CreatorNotes),
+		FieldSystemPrompt:            string(sheet.SystemPrompt),
+		FieldPostHistoryInstructions: string(sheet.PostHistoryInstructions),
+		FieldAlternateGreetings:      strings.Join(sheet.AlternateGreetings, listSeparator),
+		FieldTags:                    strings.Join(sheet.Tags, listSeparator),
+		FieldCreator:                 string(sheet.Creator),
+	}
+}
+
+// FieldDrift is a field where the PNG and API versions of the card disagree
+type FieldDrift struct {
+	Field SheetField
+	PNG   string
+	API   string
+}
+
+// Provenance records the origin of the sheet fields, and the fields where the PNG is stale compared with the API
+type Provenance struct {
+	Fields map[SheetField]Origin
+	Drift  []FieldDrift
+}
+
+// NewProvenance creates an empty provenance record
+func NewProvenance() *Provenance {
+	return &Provenance{Fields: map[SheetField]Origin{}}
+}
+
+// Origin returns the origin of the field (OriginUnknown if not recorded)
+func (p *Provenance) Origin(field SheetField) Origin {
+	if p == nil {
+		return OriginUnknown
+	}
+	return p.Fields[field]
+}
+
+// Set records the origin of the field
+func (p *Provenance) Set(field SheetField, origin Origin) {
+	if p.Fields == nil {
+		p.Fields = map[SheetField]Origin{}
+	}
+	p.Fields[field] = origin
+}
+
+// TraceMerge records the origin of the fields after the API fields were merged into the PNG sheet
+// The fields keeping their PNG value come from the PNG, the changed fields come from the API (drift if the PNG value was not blank)
+func (p *Provenance) TraceMerge(pngValues map[SheetField]string, sheet *character.Sheet) {
+	values := SheetFieldValues(sheet)
+	for _, field := range SheetFields {
+		pngValue, value := pngValues[field], values[field]
+		switch {
+		// Unchanged fields come from the PNG (blank fields have no origin)
+		case value == pngValue:
+			if stringsx.IsNotBlank(value) {
+				p.Set(field, OriginPNG)
+			}
+		// Changed fields come from the API
+		default:
+			p.Set(field, OriginAPI)
+			if stringsx.IsNotBlank(pngValue) {
+				p.Drift = append(p.Drift, FieldDrift{Field: field, PNG: pngValue, API: value})
+			}
+		}
+	}
+}
+
+// TraceChanges records the origin of the fields changed since the given values were taken
+func (p *Provenance) TraceChanges(previousValues map[SheetField]string, values map[SheetField]string, origin Origin) {
+	for _, field := range SheetFields {
+		if values[field] != previousValues[field] {
+			p.Set(field, origin)
+		}
+	}
+}
+
+// HasDrift checks if the PNG and API versions of the card disagree on any field
+func (p *Provenance) HasDrift() bool {
+	return p != nil && len(p.Drift) > 0
+}
+
+// DriftedFields returns the fields where the PNG and API versions of the card disagree
+func (p *Provenance) DriftedFields() []SheetField {
+	if p == nil {
+		return nil
+	}
+	fields := make([]SheetField, len(p.Drift))
+	for index, drift := range p.Drift {
+		fields[index] = drift.Field
+	}
+	return fields
+}
+
+// DriftReport returns the report listing the fields where the PNG and API versions of the card disagree (warnings)
+func (p *Provenance) DriftReport() *Report {
+	report := &Report{}
+	if p == nil {
+		return report
+	}
+	for _, drift := range p.Drift {
+		report.Add(RulePNGDrift, SeverityWarning, "PNG and API versions disagree", map[string]any{
+			"field": string(drift.Field),
+			"png":   drift.PNG,
+			"api":   drift.API,
+		})
+	}
+	return report
+}
+
+// Clone returns a deep copy of the provenance record (nil if nil)
+func (p *Provenance) Clone() *Provenance {
+	if p == nil {
+		return nil
+	}
+	return &Provenance{Fields: maps.Clone(p.Fields), Drift: slices.Clone(p.Drift)}
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvenance_TraceMerge(t *testing.T) {
+	sheet := &character.Sheet{Content: character.Content{
+		Name:         "Hero",
+		Description:  "Old description",
+		Scenario:     "",
+		FirstMessage: "Hello",
+	}}
+	pngValues := SheetFieldValues(sheet)
+
+	// Merge the API fields
+	sheet.Description = "New description"
+	sheet.Scenario = "A castle"
+
+	provenance := NewProvenance()
+	provenance.TraceMerge(pngValues, sheet)
+
+	assert.Equal(t, OriginPNG, provenance.Origin(FieldName))
+	assert.Equal(t, OriginPNG, provenance.Origin(FieldFirstMessage))
+	assert.Equal(t, OriginAPI, provenance.Origin(FieldDescription))
+	assert.Equal(t, OriginAPI, provenance.Origin(FieldScenario))
+	assert.Equal(t, OriginUnknown, provenance.Origin(FieldPersonality))
+
+	// Only the fields with a PNG value drift
+	assert.True(t, provenance.HasDrift())
+	assert.Equal(t, []SheetField{FieldDescription}, provenance.DriftedFields())
+	assert.Equal(t, []FieldDrift{{Field: FieldDescription, PNG: "Old description", API: "New description"}}, provenance.Drift)
+}
+
+func TestProvenance_TraceChanges(t *testing.T) {
+	provenance := NewProvenance()
+	provenance.Set(FieldCreator, OriginPNG)
+
+	previous := map[SheetField]string{FieldCreator: "Raw", FieldName: "Hero"}
+	current := map[SheetField]string{FieldCreator: "Creator", FieldName: "Hero"}
+	provenance.TraceChanges(previous, current, OriginMetadata)
+
+	assert.Equal(t, OriginMetadata, provenance.Origin(FieldCreator))
+	assert.Equal(t, OriginUnknown, provenance.Origin(FieldName))
+}
+
+func TestProvenance_DriftReport(t *testing.T) {
+	t.Run("should report a warning per drifted field", func(t *testing.T) {
+		provenance := &Provenance{Drift: []FieldDrift{{Field: FieldScenario, PNG: "Old", API: "New"}}}
+		report := provenance.DriftReport()
+
+		assert.True(t, report.Valid())
+		assert.Equal(t, []RuleID{RulePNGDrift}, report.Rules())
+		assert.Equal(t, "scenario", report.Violations[0].Values["field"])
+	})
+
+	t.Run("should handle nil provenance", func(t *testing.T) {
+		var provenance *Provenance
+		assert.False(t, provenance.HasDrift())
+		assert.Equal(t, OriginUnknown, provenance.Origin(FieldName))
+		assert.Empty(t, provenance.DriftReport().Violations)
+		assert.Nil(t, provenance.Clone())
+	})
+}
+
+func TestProvenance_JSON(t *testing.T) {
+	metadata := &Metadata{Source: "test-source", Provenance: &Provenance{
+		Fields: map[SheetField]Origin{FieldDescription: OriginAPI, FieldCreator: OriginMetadata},
+		Drift:  []FieldDrift{{Field: FieldDescription, PNG: "Old", API: "New"}},
+	}}
+
+	data, err := metadata.MarshalJSON()
+	assert.NoError(t, err)
+
+	decoded := &Metadata{}
+	assert.NoError(t, decoded.UnmarshalJSON(data))
+	assert.Equal(t, metadata.Provenance, decoded.Provenance)
+
+	t.Run("should not embed the provenance in the sheet", func(t *testing.T) {
+		extension, err := metadata.ToExtension()
+		assert.NoError(t, err)
+		assert.NotContains(t, extension, "provenance")
+	})
+
+	t.Run("should deep copy the provenance", func(t *testing.T) {
+		clone := metadata.Clone()
+		clone.Provenance.Set(FieldName, OriginPatcher)
+		assert.Equal(t, OriginUnknown, metadata.Provenance.Origin(FieldName))
+	})
+}
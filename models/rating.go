@@ -0,0 +1,69 @@
+package models
+
+import (
+	"strings"
+)
+
+// Rating represents the normalized content rating of a card
+type Rating string
+
+// Content ratings (ordered from the mildest to the most extreme, RatingUnknown if undetermined)
+const (
+	RatingUnknown    Rating = ""
+	RatingSFW        Rating = "sfw"
+	RatingSuggestive Rating = "suggestive"
+	RatingNSFW       Rating = "nsfw"
+	RatingNSFL       Rating = "nsfl"
+)
+
+// ratingLevels orders the content ratings
+var ratingLevels = map[Rating]int{
+	RatingUnknown:    0,
+	RatingSFW:        1,
+	RatingSuggestive: 2,
+	RatingNSFW:       3,
+	RatingNSFL:       4,
+}
+
+// ParseRating parses a content rating (case-insensitive, RatingUnknown for unknown values)
+func ParseRating(value string) Rating {
+	rating := Rating(strings.ToLower(strings.TrimSpace(value)))
+	if _, ok := ratingLevels[rating]; !ok {
+		return RatingUnknown
+	}
+	return rating
+}
+
+// Level returns the level of the rating (0 for RatingUnknown, higher is more extreme)
+func (r Rating) Level() int {
+	return ratingLevels[r]
+}
+
+// IsKnown checks if the rating was determined
+func (r Rating) IsKnown() bool {
+	return r.Level() > 0
+}
+
+// Max returns the most extreme of both ratings
+func (r Rating) Max(other Rating) Rating {
+	if other.Level() > r.Level() {
+		return other
+	}
+	return r
+}
+
+// String returns the display name of the rating
+func (r Rating) String() string {
+	switch r {
+	case RatingSFW:
+		return "SFW"
+	case RatingSuggestive:
+		return "Suggestive"
+	case RatingNSFW:
+		return "NSFW"
+	case RatingNSFL:
+		return "NSFL"
+	default:
+		return "Unknown"
+	}
+}
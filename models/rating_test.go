@@ -0,0 +1,42 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/r3dpixel/toolkit/sonicx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRating(t *testing.T) {
+	assert.Equal(t, RatingNSFW, ParseRating(" NSFW "))
+	assert.Equal(t, RatingSuggestive, ParseRating("suggestive"))
+	assert.Equal(t, RatingUnknown, ParseRating("mature"))
+	assert.Equal(t, RatingUnknown, ParseRating(""))
+}
+
+func TestRating_Max(t *testing.T) {
+	assert.Equal(t, RatingNSFW, RatingSFW.Max(RatingNSFW))
+	assert.Equal(t, RatingNSFL, RatingNSFL.Max(RatingSuggestive))
+	assert.Equal(t, RatingSFW, RatingUnknown.Max(RatingSFW))
+	assert.Equal(t, RatingSFW, RatingSFW.Max(RatingUnknown))
+	assert.False(t, RatingUnknown.IsKnown())
+	assert.Equal(t, "Suggestive", RatingSuggestive.String())
+	assert.Equal(t, "Unknown", RatingUnknown.String())
+}
+
+func TestRating_JSON(t *testing.T) {
+	metadata := extensionMetadata()
+	metadata.Rating = RatingSuggestive
+
+	data, err := sonicx.Config.Marshal(metadata)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"rating":"suggestive"`)
+
+	decoded := &Metadata{}
+	require.NoError(t, sonicx.Config.Unmarshal(data, decoded))
+	assert.Equal(t, metadata, decoded)
+
+	require.NoError(t, decoded.UnmarshalJSON([]byte(`{"schema_version": 2, "card": {"rating": "unheard-of"}}`)))
+	assert.Equal(t, RatingUnknown, decoded.Rating)
+}
@@ -1,131 +1,28 @@
 package models
 
-var StandardTags = map[Slug]string{
-	"foxgirl":                             "Fox Girl",
-	"monstergirl":                         "Monster Girl",
-	"ai":                                  "AI",
-	"aiassistant":                         "AI Assistant",
-	"alternategreetings":                  "Alternate Greetings",
-	"ntrbait":                             "NTR Bait",
-	"ntrrevenge":                          "NTR Revenge",
-	"comic":                               "Comic Book",
-	"waiter":                              "Waiter/Waitress",
-	"massuese":                            "Masseuse",
-	"masseuse":                            "Masseuse",
-	"first":                               "First Person",
-	"second":                              "Second Person",
-	"third":                               "Third Person",
-	"plist":                               "Plist",
-	"smut":                                "Smut",
-	"cnc":                                 "Consensual Non-Consent",
-	"cbt":                                 "Cock And Ball Torture",
-	"watersports":                         "Water Sports",
-	"featuredcreator":                     "Featured CreatorInfo",
-	"vtuber":                              "VTuber",
-	"wellintentionedextremist":            "Well Intentioned Extremist",
-	"parentplay":                          "Parent Play",
-	"sizeplay":                            "Size Play",
-	"analplay":                            "Anal Play",
-	"raceplay":                            "Race Play",
-	"freeuse":                             "Free Use",
-	"wetandmessy":                         "Wet And Messy",
-	"facesitting":                         "Face Sitting",
-	"breastmilk":                          "Breast Milk",
-	"fearplay":                            "Fear Play",
-	"praisekink":                          "Praise Kink",
-	"orgasmcontrol":                       "Orgasm Control",
-	"masterslave":                         "Master-Slave",
-	"roleplaying":                         "Role-Playing",
-	"sensorydeprivation":                  "Sensory Deprivation",
-	"temperatureplay":                     "Temperature Play",
-	"electroplay":                         "Electro Play",
-	"petplay":                             "Pet Play",
-	"scentplay":                           "Scent Play",
-	"waxplay":                             "Wax Play",
-	"knifeplay":                           "Knife Play",
-	"bloodplay":                           "Blood Play",
-	"reverseharem":                        "Reverse Harem",
-	"exoticdancer":                        "Exotic Dancer",
-	"pornstar":                            "Porn Star",
-	"earplay":                             "Ear Play",
-	"impactplay":                          "Impact Play",
-	"breathplay":                          "Breath Play",
-	"prisonguard":                         "Prison Guard",
-	"testsubject":                         "Test Subject",
-	"bountyhunter":                        "Bounty Hunter",
-	"conartist":                           "Con Artist",
-	"trickstermentor":                     "Trickster Mentor",
-	"reluctanthero":                       "Reluctant Hero",
-	"brokenbird":                          "Broken Bird",
-	"gentlegiant":                         "Gentle Giant",
-	"chaoticneutral":                      "Chaotic Neutral",
-	"eternaloptimist":                     "Eternal Optimist",
-	"lovablerogue":                        "Lovable Rogue",
-	"ghosthunter":                         "Ghost Hunter",
-	"monsterhunter":                       "Monster Hunter",
-	"wishfulfillment":                     "Wish Fulfillment",
-	"sliceoflife":                         "Slice Of Life",
-	"tvshow":                              "TV Show",
-	"videogame":                           "Video Game",
-	"kpop":                                "K-Pop",
-	"reverserape":                         "Reverse Rape",
-	"bdsm":                                "BDSM",
-	"nsfw":                                "NSFW",
-	"oc":                                  "OC",
-	"roleplay":                            "Role-Play",
-	"fempov":                              "Fem POV",
-	"malepov":                             "Male POV",
-	"milf":                                "MILF",
-	"ntr":                                 "NTR",
-	"sfwnsfw":                             "SFW <-> NSFW",
-	"antintr":                             "Anti-NTR",
-	"nsfl":                                "NSFL",
-	"sillytavern":                         "Silly Tavern",
-	"thebasement":                         "The Basement",
-	"possiblentr":                         "Possible NTR",
-	"thickmilf":                           "Thick MILF",
-	"sfw":                                 "SFW",
-	"wlw":                                 "WLW",
-	"jed":                                 "JED",
-	"missteacher":                         "Miss Teacher",
-	"nonhumancharacter":                   "Non-Human Character",
-	"possiblentrifyoutryreallyreallyhard": "Possible NTR If You Try Really Really Hard",
-	"ntravoidable":                        "NTR Avoidable",
-	"femboypov":                           "Femboy POV",
-	"nonenglish":                          "Non-English",
-	"shotapov":                            "Shota POV",
-	"duelmonsters":                        "Duel Monsters",
-	"milfwife":                            "Milf Wife",
-	"risuai":                              "RisuAI",
-	"bbw":                                 "BBW",
-	"cai":                                 "CAI",
-	"harmonytown":                         "Harmony Town",
-	"ptsd":                                "PTSD",
-	"reversentr":                          "Reverse NTR",
-	"sph":                                 "SPH",
-	"gf":                                  "GF",
-	"xml":                                 "XML",
-	"darkskinned":                         "Dark Skinned",
-	"futapov":                             "Futa POV",
-	"rnuclearrevenge":                     "R/NuclearRevenge",
-	"yta":                                 "YTA",
-	`"ntr"`:                               `"NTR"`,
-	"anypov":                              "Any POV",
-	"canbeanypovbutmadewithmalepovinmind": "Can Be Any POV But Made With Male POV In Mind",
-	"possiblebdsm":                        "Possible BDSM",
-	"oai":                                 "OAI",
-	"alichat":                             "Ali:Chat",
-	"slightdom":                           "Slight Dom",
+// StandardTags maps the slugs of the built-in taxonomy (canonical slugs and aliases) to their canonical names
+//
+// Deprecated: resolve the tags with ResolveTag or a Taxonomy. StandardTags is derived from DefaultTaxonomy and ignores SetTaxonomy.
+// The former table is kept, except for the entries remapped by the taxonomy:
+//   - "massuese" is an alias of "masseuse" (same name, ResolveTag returns the canonical slug)
+//   - "featuredcreator" is named "Featured Creator" (was the misspelled "Featured CreatorInfo")
+//   - the quoted `"ntr"` entry is dropped (slugs never contain quotes, `"ntr"` resolves to NTR)
+var StandardTags = standardTags()
+
+// standardTags builds StandardTags from the built-in taxonomy
+func standardTags() map[Slug]string {
+	tags := make(map[Slug]string)
+	for _, entry := range DefaultTaxonomy().Entries() {
+		tags[entry.Slug] = entry.Name
+		for _, alias := range entry.Aliases {
+			tags[SanitizeSlug(alias)] = entry.Name
+		}
+	}
+	return tags
 }
 
-// ResolveTag resolves a tag string into a Tag struct.
+// ResolveTag resolves a tag string into a Tag struct (using the current taxonomy, see SetTaxonomy)
+// Known tags and their aliases resolve to the canonical tag, other tags are sanitized
 func ResolveTag(stringTag string) Tag {
-	// Compute the slug by sanitizing the string
-	slug := SanitizeSlug(stringTag)
-	// Check if the tag is a standard tag, and return it if so
-	if standardName, ok := StandardTags[slug]; ok {
-		return Tag{Slug: slug, Name: standardName}
-	}
-	// Otherwise, return the sanitized tag
-	return Tag{Slug: slug, Name: SanitizeName(stringTag)}
+	return CurrentTaxonomy().Resolve(stringTag)
 }
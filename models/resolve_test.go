@@ -117,6 +117,16 @@ func TestResolveTag(t *testing.T) {
 			input:    "MILF",
 			expected: Tag{Slug: "milf", Name: "MILF"},
 		},
+		{
+			name:     "Alias - resolves to the canonical tag",
+			input:    "Massuese",
+			expected: Tag{Slug: "masseuse", Name: "Masseuse"},
+		},
+		{
+			name:     "Standard tag - featured creator",
+			input:    "Featured Creator",
+			expected: Tag{Slug: "featuredcreator", Name: "Featured Creator"},
+		},
 		{
 			name:     "Standard tag - with underscores",
 			input:    "well_intentioned_extremist",
@@ -131,3 +141,15 @@ func TestResolveTag(t *testing.T) {
 		})
 	}
 }
+
+func TestStandardTags(t *testing.T) {
+	assert.Equal(t, "Role-Playing", StandardTags["roleplaying"])
+	assert.Equal(t, "Role-Play", StandardTags["roleplay"])
+	assert.Equal(t, "Masseuse", StandardTags["massuese"])
+	assert.Equal(t, "Featured Creator", StandardTags["featuredcreator"])
+
+	// Every entry resolves to its name
+	for slug, name := range StandardTags {
+		assert.Equal(t, name, ResolveTag(string(slug)).Name, slug)
+	}
+}
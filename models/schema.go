@@ -0,0 +1,646 @@
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/r3dpixel/card-fetcher/source"
+	"github.com/r3dpixel/toolkit/sonicx"
+	"github.com/r3dpixel/toolkit/timestamp"
+)
+
+// SchemaVersion is the current version of the metadata JSON schema
+//   - version 1: flat layout (creator fields prefixed by `creator_`), used by the first card_fetcher extensions
+//   - version 2: card and creator information nested in separate objects
+//   - version 3: optional fork, rating, stats, tokens, languages, provenance, avatar_fallback and books
+const SchemaVersion = 3
+
+// schemaVersionKey is the key holding the schema version in a metadata JSON document
+const schemaVersionKey = "schema_version"
+
+// ErrUnsupportedSchema is returned when a metadata JSON document has an unknown schema version
+var ErrUnsupportedSchema = errors.New("unsupported metadata schema version")
+
+// Migration upgrades a metadata JSON document from a schema version to the next one
+type Migration func(document map[string]any) (map[string]any, error)
+
+// migrations registered by source schema version
+var (
+	migrations   = map[int]Migration{1: migrateV1, 2: migrateV2}
+	migrationsMu sync.RWMutex
+)
+
+// RegisterMigration registers the migration upgrading the documents of the given schema version to the next one
+// (overrides the built-in migration of that version, if any)
+func RegisterMigration(fromVersion int, migration Migration) {
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+	migrations[fromVersion] = migration
+}
+
+// metadataJSON is the JSON form of the metadata (schema version 3)
+type metadataJSON struct {
+	SchemaVersion  int             `json:"schema_version"`
+	Source         string          `json:"source"`
+	Card           cardInfoJSON    `json:"card"`
+	Creator        creatorInfoJSON `json:"creator"`
+	BookUpdateTime string          `json:"book_update_time,omitempty"`
+	Books          []bookJSON      `json:"books,omitempty"`
+	GreetingsCount int             `json:"greetings_count"`
+	HasBook        bool            `json:"has_book"`
+	Stats          *statsJSON      `json:"stats,omitempty"`
+	Tokens         *tokensJSON     `json:"tokens,omitempty"`
+	Languages      []languageJSON  `json:"languages,omitempty"`
+	Provenance     *provenanceJSON `json:"provenance,omitempty"`
+	AvatarFallback string          `json:"avatar_fallback,omitempty"`
+}
+
+// cardInfoJSON is the JSON form of the card information
+type cardInfoJSON struct {
+	NormalizedURL string    `json:"normalized_url"`
+	DirectURL     string    `json:"direct_url"`
+	PlatformID    string    `json:"platform_id"`
+	CharacterID   string    `json:"character_id"`
+	Name          string    `json:"name"`
+	Title         string    `json:"title"`
+	Tagline       string    `json:"tagline"`
+	CreateTime    string    `json:"create_time,omitempty"`
+	UpdateTime    string    `json:"update_time,omitempty"`
+	IsForked      bool      `json:"is_forked"`
+	Fork          *forkJSON `json:"fork,omitempty"`
+	Rating        string    `json:"rating,omitempty"`
+	Tags          []Tag     `json:"tags"`
+}
+
+// forkJSON is the JSON form of the fork information
+type forkJSON struct {
+	Source      string `json:"source,omitempty"`
+	PlatformID  string `json:"platform_id,omitempty"`
+	CharacterID string `json:"character_id,omitempty"`
+	URL         string `json:"url,omitempty"`
+}
+
+// bookJSON is the JSON form of a book of the card
+type bookJSON struct {
+	Origin     string `json:"origin"`
+	PlatformID string `json:"platform_id,omitempty"`
+	Name       string `json:"name"`
+	Creator    string `json:"creator,omitempty"`
+	EntryCount int    `json:"entry_count"`
+	UpdateTime string `json:"update_time,omitempty"`
+	URL        string `json:"url,omitempty"`
+}
+
+// statsJSON is the JSON form of the engagement statistics
+type statsJSON struct {
+	Likes       *int64   `json:"likes,omitempty"`
+	Downloads   *int64   `json:"downloads,omitempty"`
+	Views       *int64   `json:"views,omitempty"`
+	Chats       *int64   `json:"chats,omitempty"`
+	Messages    *int64   `json:"messages,omitempty"`
+	Rating      *float64 `json:"rating,omitempty"`
+	RatingCount *int64   `json:"rating_count,omitempty"`
+	FetchTime   string   `json:"fetch_time,omitempty"`
+}
+
+// tokensJSON is the JSON form of the token counts (permanent and total are derived, ignored when decoding)
+type tokensJSON struct {
+	Description  int   `json:"description"`
+	Personality  int   `json:"personality"`
+	Scenario     int   `json:"scenario"`
+	SystemPrompt int   `json:"system_prompt"`
+	Examples     int   `json:"examples"`
+	FirstMessage int   `json:"first_message"`
+	Greetings    []int `json:"greetings"`
+	BookConstant int   `json:"book_constant"`
+	BookKeyed    int   `json:"book_keyed"`
+	Permanent    int   `json:"permanent"`
+	Total        int   `json:"total"`
+}
+
+// languageJSON is the JSON form of a detected language
+type languageJSON struct {
+	Code       string  `json:"code"`
+	Confidence float64 `json:"confidence"`
+}
+
+// provenanceJSON is the JSON form of the field provenance
+type provenanceJSON struct {
+	Fields map[string]string `json:"fields,omitempty"`
+	Drift  []driftJSON       `json:"drift,omitempty"`
+}
+
+// driftJSON is the JSON form of a field drift
+type driftJSON struct {
+	Field string `json:"field"`
+	PNG   string `json:"png"`
+	API   string `json:"api"`
+}
+
+// creatorInfoJSON is the JSON form of the creator information
+type creatorInfoJSON struct {
+	Nickname   string `json:"nickname"`
+	Username   string `json:"username"`
+	PlatformID string `json:"platform_id"`
+}
+
+// MarshalJSON encodes the metadata using the current schema version
+func (m Metadata) MarshalJSON() ([]byte, error) {
+	return sonicx.Config.Marshal(m.toJSON())
+}
+
+// UnmarshalJSON decodes the metadata, migrating older schema versions
+func (m *Metadata) UnmarshalJSON(data []byte) error {
+	// Decode the generic document
+	var document map[string]any
+	if err := sonicx.Config.Unmarshal(data, &document); err != nil {
+		return err
+	}
+
+	// Migrate the document to the current schema version
+	document, err := migrate(document)
+	if err != nil {
+		return err
+	}
+
+	// Decode the current schema version
+	raw, err := sonicx.Config.Marshal(document)
+	if err != nil {
+		return err
+	}
+	var decoded metadataJSON
+	if err := sonicx.Config.Unmarshal(raw, &decoded); err != nil {
+		return err
+	}
+
+	// Convert the decoded form
+	return m.fromJSON(&decoded)
+}
+
+// MarshalJSON encodes the card information (timestamps as RFC 3339)
+func (c CardInfo) MarshalJSON() ([]byte, error) {
+	return sonicx.Config.Marshal(c.toJSON())
+}
+
+// UnmarshalJSON decodes the card information (timestamps as RFC 3339)
+func (c *CardInfo) UnmarshalJSON(data []byte) error {
+	var decoded cardInfoJSON
+	if err := sonicx.Config.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	return c.fromJSON(&decoded)
+}
+
+// MarshalJSON encodes the creator information
+func (c CreatorInfo) MarshalJSON() ([]byte, error) {
+	return sonicx.Config.Marshal(creatorInfoJSON(c))
+}
+
+// UnmarshalJSON decodes the creator information
+func (c *CreatorInfo) UnmarshalJSON(data []byte) error {
+	var decoded creatorInfoJSON
+	if err := sonicx.Config.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*c = CreatorInfo(decoded)
+	return nil
+}
+
+// EncodeJSONL writes the metadata as JSON lines (one document per line)
+func EncodeJSONL(w io.Writer, metadata ...*Metadata) error {
+	for _, item := range metadata {
+		data, err := sonicx.Config.Marshal(item)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(append(data, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DecodeJSONL reads the metadata from JSON lines (blank lines are skipped)
+func DecodeJSONL(r io.Reader) ([]*Metadata, error) {
+	var result []*Metadata
+
+	// Scan the lines (metadata documents can exceed the default token size)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		metadata := &Metadata{}
+		if err := metadata.UnmarshalJSON(data); err != nil {
+			return result, fmt.Errorf("line %d: %w", line, err)
+		}
+		result = append(result, metadata)
+	}
+
+	// Return the metadata
+	return result, scanner.Err()
+}
+
+// toJSON converts the metadata into its JSON form
+func (m *Metadata) toJSON() metadataJSON {
+	return metadataJSON{
+		SchemaVersion:  SchemaVersion,
+		Source:         string(m.Source),
+		Card:           m.CardInfo.toJSON(),
+		Creator:        creatorInfoJSON(m.CreatorInfo),
+		BookUpdateTime: formatNano(m.BookUpdateTime),
+		Books:          booksToJSON(m.Books),
+		GreetingsCount: m.GreetingsCount,
+		HasBook:        m.HasBook,
+		Stats:          m.Stats.toJSON(),
+		Tokens:         m.Tokens.toJSON(),
+		Languages:      languagesToJSON(m.Languages),
+		Provenance:     m.Provenance.toJSON(),
+		AvatarFallback: string(m.AvatarFallback),
+	}
+}
+
+// booksToJSON converts the books into their JSON form
+func booksToJSON(books []BookInfo) []bookJSON {
+	var result []bookJSON
+	for _, book := range books {
+		result = append(result, bookJSON{
+			Origin:     string(book.Origin),
+			PlatformID: book.PlatformID,
+			Name:       book.Name,
+			Creator:    book.Creator,
+			EntryCount: book.EntryCount,
+			UpdateTime: formatNano(book.UpdateTime),
+			URL:        book.URL,
+		})
+	}
+	return result
+}
+
+// booksFromJSON converts the JSON form into the books
+func booksFromJSON(decoded []bookJSON) ([]BookInfo, error) {
+	var books []BookInfo
+	for _, book := range decoded {
+		updateTime, err := parseNano(book.UpdateTime)
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, BookInfo{
+			Origin:     BookOrigin(book.Origin),
+			PlatformID: book.PlatformID,
+			Name:       book.Name,
+			Creator:    book.Creator,
+			EntryCount: book.EntryCount,
+			UpdateTime: updateTime,
+			URL:        book.URL,
+		})
+	}
+	return books, nil
+}
+
+// toJSON converts the field provenance into its JSON form (nil if not traced)
+func (p *Provenance) toJSON() *provenanceJSON {
+	if p == nil {
+		return nil
+	}
+	result := &provenanceJSON{Fields: make(map[string]string, len(p.Fields))}
+	for field, origin := range p.Fields {
+		result.Fields[string(field)] = string(origin)
+	}
+	for _, drift := range p.Drift {
+		result.Drift = append(result.Drift, driftJSON{Field: string(drift.Field), PNG: drift.PNG, API: drift.API})
+	}
+	return result
+}
+
+// provenanceFromJSON converts the JSON form into the field provenance
+func provenanceFromJSON(decoded *provenanceJSON) *Provenance {
+	if decoded == nil {
+		return nil
+	}
+	result := NewProvenance()
+	for field, origin := range decoded.Fields {
+		result.Fields[SheetField(field)] = Origin(origin)
+	}
+	for _, drift := range decoded.Drift {
+		result.Drift = append(result.Drift, FieldDrift{Field: SheetField(drift.Field), PNG: drift.PNG, API: drift.API})
+	}
+	return result
+}
+
+// languagesToJSON converts the detected languages into their JSON form
+func languagesToJSON(languages []DetectedLanguage) []languageJSON {
+	if len(languages) == 0 {
+		return nil
+	}
+	result := make([]languageJSON, len(languages))
+	for index, language := range languages {
+		result[index] = languageJSON(language)
+	}
+	return result
+}
+
+// languagesFromJSON converts the JSON form into the detected languages
+func languagesFromJSON(decoded []languageJSON) []DetectedLanguage {
+	if len(decoded) == 0 {
+		return nil
+	}
+	result := make([]DetectedLanguage, len(decoded))
+	for index, language := range decoded {
+		result[index] = DetectedLanguage(language)
+	}
+	return result
+}
+
+// toJSON converts the token counts into their JSON form (nil if not counted)
+func (t *TokenCounts) toJSON() *tokensJSON {
+	if t == nil {
+		return nil
+	}
+	return &tokensJSON{
+		Description:  t.Description,
+		Personality:  t.Personality,
+		Scenario:     t.Scenario,
+		SystemPrompt: t.SystemPrompt,
+		Examples:     t.Examples,
+		FirstMessage: t.FirstMessage,
+		Greetings:    t.Greetings,
+		BookConstant: t.BookConstant,
+		BookKeyed:    t.BookKeyed,
+		Permanent:    t.Permanent(),
+		Total:        t.Total(),
+	}
+}
+
+// tokenCountsFromJSON converts the JSON form into the token counts
+func tokenCountsFromJSON(decoded *tokensJSON) *TokenCounts {
+	if decoded == nil {
+		return nil
+	}
+	return &TokenCounts{
+		Description:  decoded.Description,
+		Personality:  decoded.Personality,
+		Scenario:     decoded.Scenario,
+		SystemPrompt: decoded.SystemPrompt,
+		Examples:     decoded.Examples,
+		FirstMessage: decoded.FirstMessage,
+		Greetings:    decoded.Greetings,
+		BookConstant: decoded.BookConstant,
+		BookKeyed:    decoded.BookKeyed,
+	}
+}
+
+// toJSON converts the statistics into their JSON form (nil if nothing was fetched)
+func (s *Stats) toJSON() *statsJSON {
+	if s.IsEmpty() && s.FetchTime == 0 {
+		return nil
+	}
+	return &statsJSON{
+		Likes:       s.Likes,
+		Downloads:   s.Downloads,
+		Views:       s.Views,
+		Chats:       s.Chats,
+		Messages:    s.Messages,
+		Rating:      s.Rating,
+		RatingCount: s.RatingCount,
+		FetchTime:   formatNano(s.FetchTime),
+	}
+}
+
+// fromJSON converts the JSON form into the statistics
+func (s *Stats) fromJSON(decoded *statsJSON) error {
+	if decoded == nil {
+		*s = Stats{}
+		return nil
+	}
+	fetchTime, err := parseNano(decoded.FetchTime)
+	if err != nil {
+		return err
+	}
+	*s = Stats{
+		Likes:       decoded.Likes,
+		Downloads:   decoded.Downloads,
+		Views:       decoded.Views,
+		Chats:       decoded.Chats,
+		Messages:    decoded.Messages,
+		Rating:      decoded.Rating,
+		RatingCount: decoded.RatingCount,
+		FetchTime:   fetchTime,
+	}
+	return nil
+}
+
+// fromJSON converts the JSON form into the metadata
+func (m *Metadata) fromJSON(decoded *metadataJSON) error {
+	bookUpdateTime, err := parseNano(decoded.BookUpdateTime)
+	if err != nil {
+		return err
+	}
+	books, err := booksFromJSON(decoded.Books)
+	if err != nil {
+		return err
+	}
+	var cardInfo CardInfo
+	if err := cardInfo.fromJSON(&decoded.Card); err != nil {
+		return err
+	}
+	var stats Stats
+	if err := stats.fromJSON(decoded.Stats); err != nil {
+		return err
+	}
+	*m = Metadata{
+		Source:         source.ID(decoded.Source),
+		CardInfo:       cardInfo,
+		CreatorInfo:    CreatorInfo(decoded.Creator),
+		BookUpdateTime: bookUpdateTime,
+		Books:          books,
+		GreetingsCount: decoded.GreetingsCount,
+		HasBook:        decoded.HasBook,
+		Stats:          stats,
+		Tokens:         tokenCountsFromJSON(decoded.Tokens),
+		Languages:      languagesFromJSON(decoded.Languages),
+		Provenance:     provenanceFromJSON(decoded.Provenance),
+		AvatarFallback: AvatarFallback(decoded.AvatarFallback),
+	}
+	return nil
+}
+
+// toJSON converts the card information into its JSON form
+func (c *CardInfo) toJSON() cardInfoJSON {
+	return cardInfoJSON{
+		NormalizedURL: c.NormalizedURL,
+		DirectURL:     c.DirectURL,
+		PlatformID:    c.PlatformID,
+		CharacterID:   c.CharacterID,
+		Name:          c.Name,
+		Title:         c.Title,
+		Tagline:       c.Tagline,
+		CreateTime:    formatNano(c.CreateTime),
+		UpdateTime:    formatNano(c.UpdateTime),
+		IsForked:      c.IsForked,
+		Fork:          c.forkToJSON(),
+		Rating:        string(c.Rating),
+		Tags:          c.Tags,
+	}
+}
+
+// forkToJSON converts the fork information into its JSON form
+func (c *CardInfo) forkToJSON() *forkJSON {
+	if c.Fork == nil {
+		return nil
+	}
+	return &forkJSON{
+		Source:      string(c.Fork.Source),
+		PlatformID:  c.Fork.PlatformID,
+		CharacterID: c.Fork.CharacterID,
+		URL:         c.Fork.URL,
+	}
+}
+
+// fromJSON converts the JSON form into the card information
+func (c *CardInfo) fromJSON(decoded *cardInfoJSON) error {
+	createTime, err := parseNano(decoded.CreateTime)
+	if err != nil {
+		return err
+	}
+	updateTime, err := parseNano(decoded.UpdateTime)
+	if err != nil {
+		return err
+	}
+	*c = CardInfo{
+		NormalizedURL: decoded.NormalizedURL,
+		DirectURL:     decoded.DirectURL,
+		PlatformID:    decoded.PlatformID,
+		CharacterID:   decoded.CharacterID,
+		Name:          decoded.Name,
+		Title:         decoded.Title,
+		Tagline:       decoded.Tagline,
+		CreateTime:    createTime,
+		UpdateTime:    updateTime,
+		IsForked:      decoded.IsForked || decoded.Fork != nil,
+		Rating:        ParseRating(decoded.Rating),
+		Tags:          decoded.Tags,
+	}
+	// Forked cards always have fork information (possibly without a known parent)
+	switch {
+	case decoded.Fork != nil:
+		c.Fork = &ForkInfo{
+			Source:      source.ID(decoded.Fork.Source),
+			PlatformID:  decoded.Fork.PlatformID,
+			CharacterID: decoded.Fork.CharacterID,
+			URL:         decoded.Fork.URL,
+		}
+	case decoded.IsForked:
+		c.Fork = &ForkInfo{}
+	}
+	return nil
+}
+
+// migrate upgrades the document to the current schema version
+func migrate(document map[string]any) (map[string]any, error) {
+	migrationsMu.RLock()
+	defer migrationsMu.RUnlock()
+
+	for {
+		// Read the schema version
+		version, ok := schemaVersionOf(document)
+		if !ok {
+			return nil, fmt.Errorf("%w: %v", ErrUnsupportedSchema, document[schemaVersionKey])
+		}
+
+		// Return the document if it is up to date
+		switch {
+		case version == SchemaVersion:
+			return document, nil
+		case version > SchemaVersion:
+			return nil, fmt.Errorf("%w: %d", ErrUnsupportedSchema, version)
+		}
+
+		// Apply the migration to the next version
+		migration, ok := migrations[version]
+		if !ok {
+			return nil, fmt.Errorf("%w: %d (no migration)", ErrUnsupportedSchema, version)
+		}
+		migrated, err := migration(document)
+		if err != nil {
+			return nil, err
+		}
+		migrated[schemaVersionKey] = version + 1
+		document = migrated
+	}
+}
+
+// schemaVersionOf reads the schema version of the document (JSON numbers can be decoded with different types)
+func schemaVersionOf(document map[string]any) (int, bool) {
+	switch version := document[schemaVersionKey].(type) {
+	case int:
+		return version, true
+	case int64:
+		return int(version), true
+	case float64:
+		return int(version), version == float64(int(version))
+	case json.Number:
+		parsed, err := version.Int64()
+		return int(parsed), err == nil
+	default:
+		return 0, false
+	}
+}
+
+// migrateV1 migrates the flat layout (version 1) to the nested layout (version 2)
+func migrateV1(document map[string]any) (map[string]any, error) {
+	card := make(map[string]any)
+	for _, key := range []string{
+		"normalized_url", "direct_url", "platform_id", "character_id", "name", "title",
+		"tagline", "create_time", "update_time", "is_forked", "tags",
+	} {
+		if value, ok := document[key]; ok {
+			card[key] = value
+		}
+	}
+	return map[string]any{
+		"source": document["source"],
+		"card":   card,
+		"creator": map[string]any{
+			"nickname":    document["creator_nickname"],
+			"username":    document["creator_username"],
+			"platform_id": document["creator_platform_id"],
+		},
+		"book_update_time": document["book_update_time"],
+		"greetings_count":  document["greetings_count"],
+		"has_book":         document["has_book"],
+	}, nil
+}
+
+// migrateV2 migrates version 2 to version 3 (the added fields are optional, version 2 documents have none of them)
+func migrateV2(document map[string]any) (map[string]any, error) {
+	return document, nil
+}
+
+// formatNano formats the nanosecond timestamp as RFC 3339 (zero timestamps are empty)
+func formatNano(nano timestamp.Nano) string {
+	if nano == 0 {
+		return ""
+	}
+	return time.Unix(0, int64(nano)).UTC().Format(time.RFC3339Nano)
+}
+
+// parseNano parses the RFC 3339 timestamp into nanoseconds (empty timestamps are zero)
+func parseNano(value string) (timestamp.Nano, error) {
+	if value == "" {
+		return 0, nil
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return 0, err
+	}
+	return timestamp.Nano(parsed.UnixNano()), nil
+}
@@ -0,0 +1,204 @@
+package models
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/r3dpixel/toolkit/sonicx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadata_JSON(t *testing.T) {
+	t.Run("should round trip", func(t *testing.T) {
+		for _, metadata := range []*Metadata{
+			extensionMetadata(),
+			{},
+			{Source: "source", CardInfo: CardInfo{Tags: []Tag{}}},
+			{Source: "source", AvatarFallback: AvatarFallbackGenerated},
+			{Source: "source", Books: []BookInfo{{Origin: BookOriginEmbedded, EntryCount: 2}, {Origin: BookOriginLinked, PlatformID: "789", UpdateTime: 1}}},
+		} {
+			data, err := sonicx.Config.Marshal(metadata)
+			require.NoError(t, err)
+
+			decoded := &Metadata{}
+			require.NoError(t, sonicx.Config.Unmarshal(data, decoded))
+			assert.Equal(t, metadata, decoded)
+		}
+	})
+
+	t.Run("should use explicit field names and RFC 3339 timestamps", func(t *testing.T) {
+		data, err := sonicx.Config.Marshal(extensionMetadata())
+		require.NoError(t, err)
+
+		var document map[string]any
+		require.NoError(t, sonicx.Config.Unmarshal(data, &document))
+
+		assert.EqualValues(t, SchemaVersion, document["schema_version"])
+		assert.Equal(t, "ChubAI", document["source"])
+		assert.Equal(t, "2024-01-02T05:04:05.123456789Z", document["book_update_time"])
+		card := document["card"].(map[string]any)
+		assert.Equal(t, "123", card["platform_id"])
+		assert.Equal(t, "2024-01-02T03:04:05.123456789Z", card["create_time"])
+		assert.Equal(t, []any{map[string]any{"slug": "fantasy", "name": "Fantasy"}, map[string]any{"slug": "sci-fi", "name": "Sci-Fi"}}, card["tags"])
+		creator := document["creator"].(map[string]any)
+		assert.Equal(t, "456", creator["platform_id"])
+	})
+
+	t.Run("should describe the books", func(t *testing.T) {
+		metadata := extensionMetadata()
+		metadata.Books = []BookInfo{
+			{Origin: BookOriginEmbedded, EntryCount: 2},
+			{
+				Origin: BookOriginLinked, PlatformID: "789", Name: "World", Creator: "creator", EntryCount: 12,
+				UpdateTime: metadata.BookUpdateTime, URL: "chub.ai/lorebooks/creator/world",
+			},
+		}
+		data, err := sonicx.Config.Marshal(metadata)
+		require.NoError(t, err)
+
+		var document map[string]any
+		require.NoError(t, sonicx.Config.Unmarshal(data, &document))
+
+		assert.Equal(t, []any{
+			map[string]any{"origin": "embedded", "name": "", "entry_count": float64(2)},
+			map[string]any{
+				"origin": "linked", "platform_id": "789", "name": "World", "creator": "creator", "entry_count": float64(12),
+				"update_time": "2024-01-02T05:04:05.123456789Z", "url": "chub.ai/lorebooks/creator/world",
+			},
+		}, document["books"])
+	})
+
+	t.Run("should omit zero timestamps", func(t *testing.T) {
+		data, err := sonicx.Config.Marshal(&Metadata{})
+		require.NoError(t, err)
+
+		assert.NotContains(t, string(data), "create_time")
+		assert.NotContains(t, string(data), "book_update_time")
+	})
+
+	t.Run("should reject unsupported schema versions", func(t *testing.T) {
+		for _, document := range []string{`{}`, `{"schema_version": "2"}`, `{"schema_version": 1.5}`, `{"schema_version": 99}`, `{"schema_version": 0}`} {
+			err := (&Metadata{}).UnmarshalJSON([]byte(document))
+			assert.ErrorIs(t, err, ErrUnsupportedSchema, document)
+		}
+	})
+
+	t.Run("should reject malformed timestamps", func(t *testing.T) {
+		err := (&Metadata{}).UnmarshalJSON([]byte(`{"schema_version": 2, "book_update_time": "yesterday"}`))
+		assert.Error(t, err)
+	})
+}
+
+func TestMetadata_Migration(t *testing.T) {
+	t.Run("should migrate the flat layout", func(t *testing.T) {
+		document := `{
+			"schema_version": 1,
+			"source": "ChubAI",
+			"normalized_url": "chub.ai/characters/creator/card",
+			"direct_url": "chub.ai/characters/creator/card",
+			"platform_id": "123",
+			"character_id": "creator/card",
+			"name": "Name",
+			"title": "Title",
+			"tagline": "Tagline",
+			"create_time": "2024-01-02T03:04:05.123456789Z",
+			"update_time": "2024-01-02T04:04:05.123456789Z",
+			"is_forked": true,
+			"tags": [{"slug": "fantasy", "name": "Fantasy"}, {"slug": "sci-fi", "name": "Sci-Fi"}],
+			"creator_nickname": "Creator",
+			"creator_username": "creator",
+			"creator_platform_id": "456",
+			"book_update_time": "2024-01-02T05:04:05.123456789Z",
+			"greetings_count": 3,
+			"has_book": true
+		}`
+
+		decoded := &Metadata{}
+		require.NoError(t, decoded.UnmarshalJSON([]byte(document)))
+		// The flat layout has no fork information (the parent is unknown)
+		expected := extensionMetadata()
+		expected.Fork = &ForkInfo{}
+		assert.Equal(t, expected, decoded)
+	})
+
+	t.Run("should migrate version 2 without the version 3 fields", func(t *testing.T) {
+		decoded := &Metadata{}
+		require.NoError(t, decoded.UnmarshalJSON([]byte(`{"schema_version": 2, "source": "ChubAI", "card": {"name": "Name"}, "has_book": true}`)))
+
+		assert.Equal(t, &Metadata{Source: "ChubAI", CardInfo: CardInfo{Name: "Name"}, HasBook: true}, decoded)
+	})
+
+	// One case per field added in version 3 (kept from the version 2 documents written before the version bump)
+	for _, test := range []struct {
+		field    string
+		document string
+		expected func(metadata *Metadata) any
+		value    any
+	}{
+		{"fork", `"card": {"is_forked": true, "fork": {"url": "chub.ai/characters/original/card"}}`, func(m *Metadata) any { return m.Fork }, &ForkInfo{URL: "chub.ai/characters/original/card"}},
+		{"rating", `"card": {"rating": "nsfw"}`, func(m *Metadata) any { return m.Rating }, RatingNSFW},
+		{"stats", `"stats": {"likes": 3}`, func(m *Metadata) any { return *m.Stats.Likes }, int64(3)},
+		{"tokens", `"tokens": {"description": 12}`, func(m *Metadata) any { return m.Tokens.Description }, 12},
+		{"languages", `"languages": [{"code": "en", "confidence": 0.9}]`, func(m *Metadata) any { return m.Languages }, []DetectedLanguage{{Code: "en", Confidence: 0.9}}},
+		{"provenance", `"provenance": {"fields": {"name": "api"}}`, func(m *Metadata) any { return m.Provenance.Fields[FieldName] }, OriginAPI},
+		{"avatar_fallback", `"avatar_fallback": "generated"`, func(m *Metadata) any { return m.AvatarFallback }, AvatarFallbackGenerated},
+		{"books", `"books": [{"origin": "linked", "name": "World", "entry_count": 2}]`, func(m *Metadata) any { return m.Books }, []BookInfo{{Origin: BookOriginLinked, Name: "World", EntryCount: 2}}},
+	} {
+		t.Run("should migrate and decode the "+test.field, func(t *testing.T) {
+			fromV2 := &Metadata{}
+			require.NoError(t, fromV2.UnmarshalJSON([]byte(`{"schema_version": 2, `+test.document+`}`)))
+			assert.Equal(t, test.value, test.expected(fromV2))
+
+			fromV3 := &Metadata{}
+			require.NoError(t, fromV3.UnmarshalJSON([]byte(`{"schema_version": 3, `+test.document+`}`)))
+			assert.Equal(t, test.value, test.expected(fromV3))
+		})
+	}
+
+	t.Run("should use the registered migrations", func(t *testing.T) {
+		original := migrations[1]
+		t.Cleanup(func() { RegisterMigration(1, original) })
+
+		RegisterMigration(1, func(document map[string]any) (map[string]any, error) {
+			migrated, err := original(document)
+			if err != nil {
+				return nil, err
+			}
+			migrated["source"] = "migrated"
+			return migrated, nil
+		})
+
+		decoded := &Metadata{}
+		require.NoError(t, decoded.UnmarshalJSON([]byte(`{"schema_version": 1, "source": "chub"}`)))
+		assert.Equal(t, "migrated", string(decoded.Source))
+	})
+}
+
+func TestJSONL(t *testing.T) {
+	first := extensionMetadata()
+	second := &Metadata{Source: "other", CardInfo: CardInfo{Name: "Other"}}
+
+	var buffer bytes.Buffer
+	require.NoError(t, EncodeJSONL(&buffer, first, second))
+	assert.Equal(t, 2, strings.Count(buffer.String(), "\n"))
+
+	t.Run("should round trip", func(t *testing.T) {
+		decoded, err := DecodeJSONL(bytes.NewReader(buffer.Bytes()))
+		require.NoError(t, err)
+		assert.Equal(t, []*Metadata{first, second}, decoded)
+	})
+
+	t.Run("should skip blank lines", func(t *testing.T) {
+		decoded, err := DecodeJSONL(strings.NewReader("\n" + buffer.String() + "\n  \n"))
+		require.NoError(t, err)
+		assert.Len(t, decoded, 2)
+	})
+
+	t.Run("should report the failing line", func(t *testing.T) {
+		decoded, err := DecodeJSONL(strings.NewReader(buffer.String() + "{not json}\n"))
+		assert.ErrorContains(t, err, "line 3")
+		assert.Len(t, decoded, 2)
+	})
+}
@@ -0,0 +1,273 @@
+package models
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Transliterator converts a text into Latin script (runes it does not know are returned unchanged)
+type Transliterator func(text string) string
+
+// slugTransliterator is the transliterator used by SanitizeSlug (nil disables transliteration)
+var slugTransliterator atomic.Pointer[Transliterator]
+
+// specialFolds are the Latin letters without a canonical decomposition, folded to their base letters
+var specialFolds = map[rune]string{
+	'ß': "ss", 'ẞ': "ss", 'æ': "ae", 'Æ': "ae", 'œ': "oe", 'Œ': "oe", 'ø': "o", 'Ø': "o",
+	'ł': "l", 'Ł': "l", 'đ': "d", 'Đ': "d", 'ð': "d", 'Ð': "d", 'þ': "th", 'Þ': "th", 'ı': "i",
+}
+
+// SetSlugTransliterator sets the transliterator used by SanitizeSlug (nil disables transliteration, the default)
+func SetSlugTransliterator(transliterator Transliterator) {
+	if transliterator == nil {
+		slugTransliterator.Store(nil)
+		return
+	}
+	slugTransliterator.Store(&transliterator)
+}
+
+// foldSlug normalizes the Unicode forms of a slug (NFKC, optional transliteration, Latin diacritics folding)
+// ASCII slugs are returned unchanged
+func foldSlug(slug string) string {
+	// Fast path (ASCII slugs are already folded)
+	if isASCII(slug) {
+		return slug
+	}
+
+	// Normalize compatibility forms (full-width, ligatures, ...)
+	slug = norm.NFKC.String(slug)
+
+	// Transliterate to Latin script (if enabled)
+	if transliterator := slugTransliterator.Load(); transliterator != nil {
+		slug = (*transliterator)(slug)
+	}
+
+	// Fold the diacritics of Latin letters (the marks of other scripts are meaningful, e.g. Japanese dakuten)
+	var result strings.Builder
+	result.Grow(len(slug))
+	latinBase := false
+	for _, r := range norm.NFD.String(slug) {
+		if unicode.Is(unicode.Mn, r) {
+			if !latinBase {
+				result.WriteRune(r)
+			}
+			continue
+		}
+		latinBase = unicode.Is(unicode.Latin, r)
+		if folded, ok := specialFolds[r]; ok {
+			result.WriteString(folded)
+			continue
+		}
+		result.WriteRune(r)
+	}
+
+	// Recompose the remaining characters
+	return norm.NFC.String(result.String())
+}
+
+// isASCII checks if the string contains only ASCII characters
+func isASCII(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// SlugCollision represents distinct tag names sharing the same slug
+type SlugCollision struct {
+	Slug  Slug
+	Names []string
+}
+
+// DetectSlugCollisions finds the distinct tag names that sanitize to the same slug (sorted by slug)
+// Names differing only by case, whitespace or Unicode form are the same tag and do not collide
+func DetectSlugCollisions(names []string) []SlugCollision {
+	// Group the distinct names by slug
+	groups := make(map[Slug][]string)
+	seen := make(map[string]bool)
+	for _, name := range names {
+		slug := SanitizeSlug(name)
+		key := strings.ToLower(strings.Join(strings.Fields(norm.NFKC.String(name)), " "))
+		if slug == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		groups[slug] = append(groups[slug], name)
+	}
+
+	// Keep the slugs with more than one name
+	var collisions []SlugCollision
+	for slug, group := range groups {
+		if len(group) > 1 {
+			collisions = append(collisions, SlugCollision{Slug: slug, Names: group})
+		}
+	}
+	slices.SortFunc(collisions, func(a, b SlugCollision) int {
+		return cmp.Compare(a.Slug, b.Slug)
+	})
+
+	// Return the collisions
+	return collisions
+}
+
+// DefaultTransliterator transliterates Cyrillic, Japanese kana and Hangul to Latin script
+// Han characters have no reading without a dictionary and are left unchanged (chain a dedicated transliterator if needed)
+func DefaultTransliterator(text string) string {
+	return TransliterateHangul(TransliterateKana(TransliterateCyrillic(text)))
+}
+
+// ChainTransliterators combines transliterators (applied in order)
+func ChainTransliterators(transliterators ...Transliterator) Transliterator {
+	return func(text string) string {
+		for _, transliterator := range transliterators {
+			text = transliterator(text)
+		}
+		return text
+	}
+}
+
+// cyrillicLatin maps the lowercase Cyrillic letters to Latin (Russian, Ukrainian and Belarusian letters)
+var cyrillicLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh", 'з': "z", 'и': "i",
+	'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+	'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "",
+	'э': "e", 'ю': "yu", 'я': "ya", 'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g", 'ў': "u",
+}
+
+// TransliterateCyrillic transliterates Cyrillic letters to Latin
+func TransliterateCyrillic(text string) string {
+	var result strings.Builder
+	result.Grow(len(text))
+	for _, r := range text {
+		if latin, ok := cyrillicLatin[unicode.ToLower(r)]; ok {
+			result.WriteString(latin)
+			continue
+		}
+		result.WriteRune(r)
+	}
+	return result.String()
+}
+
+// kanaLatin maps the hiragana to Latin (Hepburn romanization, katakana are mapped to hiragana first)
+var kanaLatin = map[rune]string{
+	'あ': "a", 'い': "i", 'う': "u", 'え': "e", 'お': "o",
+	'か': "ka", 'き': "ki", 'く': "ku", 'け': "ke", 'こ': "ko", 'が': "ga", 'ぎ': "gi", 'ぐ': "gu", 'げ': "ge", 'ご': "go",
+	'さ': "sa", 'し': "shi", 'す': "su", 'せ': "se", 'そ': "so", 'ざ': "za", 'じ': "ji", 'ず': "zu", 'ぜ': "ze", 'ぞ': "zo",
+	'た': "ta", 'ち': "chi", 'つ': "tsu", 'て': "te", 'と': "to", 'だ': "da", 'ぢ': "ji", 'づ': "zu", 'で': "de", 'ど': "do",
+	'な': "na", 'に': "ni", 'ぬ': "nu", 'ね': "ne", 'の': "no",
+	'は': "ha", 'ひ': "hi", 'ふ': "fu", 'へ': "he", 'ほ': "ho", 'ば': "ba", 'び': "bi", 'ぶ': "bu", 'べ': "be", 'ぼ': "bo",
+	'ぱ': "pa", 'ぴ': "pi", 'ぷ': "pu", 'ぺ': "pe", 'ぽ': "po",
+	'ま': "ma", 'み': "mi", 'む': "mu", 'め': "me", 'も': "mo",
+	'や': "ya", 'ゆ': "yu", 'よ': "yo",
+	'ら': "ra", 'り': "ri", 'る': "ru", 'れ': "re", 'ろ': "ro",
+	'わ': "wa", 'ゐ': "i", 'ゑ': "e", 'を': "o", 'ん': "n", 'ゔ': "vu",
+	'ぁ': "a", 'ぃ': "i", 'ぅ': "u", 'ぇ': "e", 'ぉ': "o", 'ゎ': "wa",
+}
+
+// smallKanaVowels maps the small ya/yu/yo to the vowels of the combined syllables
+var smallKanaVowels = map[rune]string{'ゃ': "a", 'ゅ': "u", 'ょ': "o"}
+
+// TransliterateKana transliterates hiragana and katakana to Latin (Hepburn romanization)
+func TransliterateKana(text string) string {
+	var result strings.Builder
+	result.Grow(len(text))
+	geminate := false
+	lastVowel := ""
+	for _, r := range text {
+		// Map katakana to hiragana
+		if r >= 'ァ' && r <= 'ヶ' {
+			r -= 'ァ' - 'ぁ'
+		}
+
+		var latin string
+		switch {
+		case r == 'っ':
+			// Small tsu doubles the next consonant
+			geminate = true
+			continue
+		case r == 'ー':
+			// Long vowel mark repeats the previous vowel
+			latin = lastVowel
+		case smallKanaVowels[r] != "" && lastVowel == "i":
+			// Combine with the previous syllable (ki + ya = kya, shi + ya = sha)
+			current := result.String()
+			base := strings.TrimSuffix(current, "i")
+			result.Reset()
+			result.WriteString(base)
+			if strings.HasSuffix(base, "sh") || strings.HasSuffix(base, "ch") || strings.HasSuffix(base, "j") {
+				latin = smallKanaVowels[r]
+			} else {
+				latin = "y" + smallKanaVowels[r]
+			}
+		default:
+			var ok bool
+			if latin, ok = kanaLatin[r]; !ok {
+				if r == 'ゃ' || r == 'ゅ' || r == 'ょ' {
+					latin = "y" + smallKanaVowels[r]
+				} else {
+					// Not a kana
+					geminate = false
+					lastVowel = ""
+					result.WriteRune(r)
+					continue
+				}
+			}
+		}
+
+		// Double the consonant after a small tsu (chi becomes tchi)
+		if geminate && latin != "" && !strings.ContainsRune("aiueon", rune(latin[0])) {
+			if strings.HasPrefix(latin, "ch") {
+				result.WriteByte('t')
+			} else {
+				result.WriteByte(latin[0])
+			}
+		}
+		geminate = false
+
+		// Write the syllable and remember its vowel
+		result.WriteString(latin)
+		if latin != "" {
+			lastVowel = latin[len(latin)-1:]
+		}
+	}
+	return result.String()
+}
+
+// Hangul romanization tables (Revised Romanization, without the sound change rules)
+var (
+	hangulInitials = []string{"g", "kk", "n", "d", "tt", "r", "m", "b", "pp", "s", "ss", "", "j", "jj", "ch", "k", "t", "p", "h"}
+	hangulVowels   = []string{"a", "ae", "ya", "yae", "eo", "e", "yeo", "ye", "o", "wa", "wae", "oe", "yo", "u", "wo", "we", "wi", "yu", "eu", "ui", "i"}
+	hangulFinals   = []string{"", "k", "k", "k", "n", "n", "n", "t", "l", "k", "m", "l", "l", "l", "p", "l", "m", "p", "p", "t", "t", "ng", "t", "t", "k", "t", "p", "t"}
+)
+
+// Hangul syllables block
+const (
+	hangulFirst = 0xAC00
+	hangulLast  = 0xD7A3
+)
+
+// TransliterateHangul transliterates Hangul syllables to Latin (Revised Romanization)
+func TransliterateHangul(text string) string {
+	var result strings.Builder
+	result.Grow(len(text))
+	for _, r := range text {
+		if r < hangulFirst || r > hangulLast {
+			result.WriteRune(r)
+			continue
+		}
+		// Decompose the syllable into its initial, vowel and final
+		index := int(r - hangulFirst)
+		result.WriteString(hangulInitials[index/(21*28)])
+		result.WriteString(hangulVowels[(index%(21*28))/28])
+		result.WriteString(hangulFinals[index%28])
+	}
+	return result.String()
+}
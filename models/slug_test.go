@@ -0,0 +1,81 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeSlug_Unicode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected Slug
+	}{
+		{"ASCII tags are unchanged", "Fox-Girl_2", "foxgirl2"},
+		{"diacritics are folded", "Mädchen", "madchen"},
+		{"composed and decomposed forms match", "Café", "cafe"},
+		{"letters without decomposition are folded", "Straße Øl", "strasseol"},
+		{"full-width forms are normalized", "ＮＳＦＷ", "nsfw"},
+		{"ligatures are normalized", "ﬁre", "fire"},
+		{"half-width katakana are normalized", "ｶﾀｶﾅ", "カタカナ"},
+		{"Japanese marks are kept", "がぎ", "がぎ"},
+		{"non-Latin scripts are kept without transliteration", "Девушка", "девушка"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, SanitizeSlug(tc.input))
+		})
+	}
+}
+
+func TestSanitizeSlug_Transliteration(t *testing.T) {
+	SetSlugTransliterator(DefaultTransliterator)
+	defer SetSlugTransliterator(nil)
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected Slug
+	}{
+		{"ASCII tags are unchanged", "Monster Girl", "monstergirl"},
+		{"Cyrillic", "Девушка-Лиса", "devushkalisa"},
+		{"Ukrainian", "Їжак", "yizhak"},
+		{"hiragana", "ひらがな", "hiragana"},
+		{"katakana with long vowel", "ラーメン", "raamen"},
+		{"combined syllables", "きょうしゃ", "kyousha"},
+		{"small tsu", "マッチャ", "matcha"},
+		{"half-width katakana", "ｶﾀｶﾅ", "katakana"},
+		{"Hangul", "한국어", "hangukeo"},
+		{"Han characters are kept", "恧恨", "恧恨"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, SanitizeSlug(tc.input))
+		})
+	}
+
+	t.Run("custom transliterators can be chained", func(t *testing.T) {
+		han := func(text string) string {
+			if text == "狐" {
+				return "kitsune"
+			}
+			return text
+		}
+		SetSlugTransliterator(ChainTransliterators(DefaultTransliterator, han))
+
+		assert.Equal(t, Slug("kitsune"), SanitizeSlug("狐"))
+	})
+}
+
+func TestDetectSlugCollisions(t *testing.T) {
+	collisions := DetectSlugCollisions([]string{"Mädchen", "madchen", "MÄDCHEN", "Fox Girl", "fox-girl", "FOX GIRL", "Unique", "★"})
+
+	assert.Equal(t, []SlugCollision{
+		{Slug: "foxgirl", Names: []string{"Fox Girl", "fox-girl"}},
+		{Slug: "madchen", Names: []string{"Mädchen", "madchen"}},
+	}, collisions)
+	assert.Empty(t, DetectSlugCollisions([]string{"Tag", " tag ", "ＴＡＧ"}))
+}
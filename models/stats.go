@@ -0,0 +1,66 @@
+package models
+
+import (
+	"github.com/r3dpixel/toolkit/timestamp"
+)
+
+// MaxRating is the upper bound of the normalized rating (ratings are normalized to 0-5)
+const MaxRating = 5.0
+
+// Stats represents the engagement statistics of a card (nil fields are not provided by the platform)
+type Stats struct {
+	// Likes is the number of likes, stars or favorites
+	Likes *int64
+	// Downloads is the number of downloads
+	Downloads *int64
+	// Views is the number of views
+	Views *int64
+	// Chats is the number of chats started with the character
+	Chats *int64
+	// Messages is the number of messages sent to the character
+	Messages *int64
+	// Rating is the average rating, normalized to 0-MaxRating
+	Rating *float64
+	// RatingCount is the number of ratings
+	RatingCount *int64
+	// FetchTime is the time the statistics were fetched (to track popularity over time)
+	FetchTime timestamp.Nano
+}
+
+// IsEmpty checks if the platform provided no statistics
+func (s *Stats) IsEmpty() bool {
+	return s.Likes == nil && s.Downloads == nil && s.Views == nil && s.Chats == nil &&
+		s.Messages == nil && s.Rating == nil && s.RatingCount == nil
+}
+
+// Clone returns a deep copy of the statistics
+func (s *Stats) Clone() Stats {
+	return Stats{
+		Likes:       clonePointer(s.Likes),
+		Downloads:   clonePointer(s.Downloads),
+		Views:       clonePointer(s.Views),
+		Chats:       clonePointer(s.Chats),
+		Messages:    clonePointer(s.Messages),
+		Rating:      clonePointer(s.Rating),
+		RatingCount: clonePointer(s.RatingCount),
+		FetchTime:   s.FetchTime,
+	}
+}
+
+// NormalizeRating converts a rating on the given scale (0-scale) to the normalized scale (0-MaxRating)
+func NormalizeRating(rating, scale float64) *float64 {
+	if scale <= 0 {
+		return nil
+	}
+	normalized := min(max(rating, 0), scale) / scale * MaxRating
+	return &normalized
+}
+
+// clonePointer returns a pointer to a copy of the value (nil stays nil)
+func clonePointer[T any](value *T) *T {
+	if value == nil {
+		return nil
+	}
+	clone := *value
+	return &clone
+}
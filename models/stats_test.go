@@ -0,0 +1,98 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/toolkit/sonicx"
+	"github.com/r3dpixel/toolkit/timestamp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats_IsEmpty(t *testing.T) {
+	assert.True(t, (&Stats{}).IsEmpty())
+	assert.True(t, (&Stats{FetchTime: 1}).IsEmpty())
+	assert.False(t, (&Stats{RatingCount: new(int64(0))}).IsEmpty())
+}
+
+func TestStats_Clone(t *testing.T) {
+	original := Stats{Likes: new(int64(120)), Rating: new(4.5), FetchTime: 1}
+	clone := original.Clone()
+
+	assert.Equal(t, original, clone)
+	*clone.Likes = 0
+	assert.EqualValues(t, 120, *original.Likes, "Modifying the clone should not affect the original")
+	assert.Nil(t, clone.Downloads)
+}
+
+func TestNormalizeRating(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rating   float64
+		scale    float64
+		expected *float64
+	}{
+		{name: "same scale", rating: 4, scale: 5, expected: new(4.0)},
+		{name: "ten point scale", rating: 7, scale: 10, expected: new(3.5)},
+		{name: "percentage", rating: 90, scale: 100, expected: new(4.5)},
+		{name: "clamped above the scale", rating: 12, scale: 10, expected: new(5.0)},
+		{name: "clamped below zero", rating: -1, scale: 5, expected: new(0.0)},
+		{name: "invalid scale", rating: 4, scale: 0, expected: nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, NormalizeRating(tc.rating, tc.scale))
+		})
+	}
+}
+
+func TestStats_JSON(t *testing.T) {
+	metadata := extensionMetadata()
+	metadata.Stats = Stats{
+		Likes:       new(int64(120)),
+		Views:       new(int64(4500)),
+		Rating:      new(4.5),
+		RatingCount: new(int64(0)),
+		FetchTime:   timestamp.Nano(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC).UnixNano()),
+	}
+
+	t.Run("should round trip", func(t *testing.T) {
+		data, err := sonicx.Config.Marshal(metadata)
+		require.NoError(t, err)
+
+		decoded := &Metadata{}
+		require.NoError(t, sonicx.Config.Unmarshal(data, decoded))
+		assert.Equal(t, metadata, decoded)
+	})
+
+	t.Run("should omit the missing statistics", func(t *testing.T) {
+		data, err := sonicx.Config.Marshal(metadata)
+		require.NoError(t, err)
+
+		var document map[string]any
+		require.NoError(t, sonicx.Config.Unmarshal(data, &document))
+		assert.Equal(t, map[string]any{
+			"likes":        float64(120),
+			"views":        float64(4500),
+			"rating":       4.5,
+			"rating_count": float64(0),
+			"fetch_time":   "2024-02-01T00:00:00Z",
+		}, document["stats"])
+
+		data, err = sonicx.Config.Marshal(&Metadata{})
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "stats")
+	})
+
+	t.Run("should not embed the statistics in the sheet", func(t *testing.T) {
+		sheet := &character.Sheet{}
+		require.NoError(t, metadata.EmbedIn(sheet))
+
+		assert.NotContains(t, sheet.Extensions[ExtensionKey], "stats")
+		metadata, err := MetadataFromSheet(sheet)
+		require.NoError(t, err)
+		assert.Equal(t, extensionMetadata(), metadata)
+	})
+}
@@ -20,8 +20,8 @@ type Slug = string
 
 // Tag struct representing a tag
 type Tag struct {
-	Slug Slug
-	Name string
+	Slug Slug   `json:"slug"`
+	Name string `json:"name"`
 }
 
 // TagsToNames get a slice of tag names from a slice of tags
@@ -88,7 +88,10 @@ func TagsFromJsonArray(array *sonicx.Wrap, extractor func(result *sonicx.Wrap) s
 }
 
 // SanitizeSlug sanitizes the given tag to be used as a slug (removes non-ASCII, '-', '_', whitespace and lowers all characters)
+// Non-ASCII tags are NFKC normalized, their Latin diacritics are folded, and they are transliterated if enabled (see SetSlugTransliterator)
 func SanitizeSlug(slug Slug) Slug {
+	// Fold the Unicode forms (ASCII tags are unchanged)
+	slug = foldSlug(slug)
 	// Remove non-ASCII, symbols, and whitespace, and lower all characters
 	return strings.ToLower(stringsx.Remove(slug, symbols.SymbolsWhiteSpaceRegExp))
 }
@@ -0,0 +1,297 @@
+package models
+
+import (
+	"cmp"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/r3dpixel/toolkit/sonicx"
+	"github.com/r3dpixel/toolkit/stringsx"
+)
+
+// TagCategory represents the category of a canonical tag
+type TagCategory string
+
+// Tag categories (tags without a category use TagCategoryNone)
+const (
+	TagCategoryNone     TagCategory = ""
+	TagCategoryGenre    TagCategory = "genre"
+	TagCategoryPOV      TagCategory = "pov"
+	TagCategoryKink     TagCategory = "kink"
+	TagCategoryRating   TagCategory = "content_rating"
+	TagCategoryPlatform TagCategory = "platform"
+	TagCategoryLanguage TagCategory = "language"
+)
+
+// ErrInvalidTaxonomy is returned when a taxonomy has inconsistent entries
+var ErrInvalidTaxonomy = errors.New("invalid tag taxonomy")
+
+// defaultTaxonomyData is the built-in taxonomy data file
+//
+//go:embed taxonomy.json
+var defaultTaxonomyData []byte
+
+// defaultTaxonomy parses the built-in taxonomy (executed once and cached)
+var defaultTaxonomy = sync.OnceValue(func() *Taxonomy {
+	taxonomy, err := ParseTaxonomy(defaultTaxonomyData)
+	if err != nil {
+		panic(err)
+	}
+	return taxonomy
+})
+
+// currentTaxonomy is the taxonomy used by ResolveTag (nil means the default taxonomy)
+var currentTaxonomy atomic.Pointer[Taxonomy]
+
+// TaxonomyEntry represents a canonical tag of the taxonomy
+type TaxonomyEntry struct {
+	// Slug is the canonical slug of the tag
+	Slug Slug `json:"slug"`
+	// Name is the canonical name of the tag
+	Name string `json:"name"`
+	// Category is the category of the tag (optional)
+	Category TagCategory `json:"category,omitempty"`
+	// Aliases are the alternative spellings of the tag (sanitized as slugs)
+	Aliases []string `json:"aliases,omitempty"`
+	// Parent is the slug of the parent tag (optional)
+	Parent Slug `json:"parent,omitempty"`
+}
+
+// taxonomyFile represents the taxonomy data file
+type taxonomyFile struct {
+	Tags []TaxonomyEntry `json:"tags"`
+}
+
+// Taxonomy represents a set of canonical tags with aliases, categories and a hierarchy
+type Taxonomy struct {
+	entries  map[Slug]TaxonomyEntry
+	aliases  map[Slug]Slug
+	children map[Slug][]Slug
+}
+
+// NewTaxonomy creates a taxonomy from the given entries
+// Slugs, aliases and parents are sanitized; duplicates, unknown parents and cycles are rejected
+func NewTaxonomy(entries []TaxonomyEntry) (*Taxonomy, error) {
+	t := &Taxonomy{
+		entries:  make(map[Slug]TaxonomyEntry, len(entries)),
+		aliases:  make(map[Slug]Slug),
+		children: make(map[Slug][]Slug),
+	}
+
+	// Register the canonical tags
+	for _, entry := range entries {
+		entry.Slug = SanitizeSlug(entry.Slug)
+		entry.Parent = SanitizeSlug(entry.Parent)
+		if entry.Slug == "" || stringsx.IsBlank(entry.Name) {
+			return nil, fmt.Errorf("%w: tag %q has a blank slug or name", ErrInvalidTaxonomy, entry.Name)
+		}
+		if _, ok := t.entries[entry.Slug]; ok {
+			return nil, fmt.Errorf("%w: duplicate tag %q", ErrInvalidTaxonomy, entry.Slug)
+		}
+		t.entries[entry.Slug] = entry
+	}
+
+	// Register the aliases (an alias cannot shadow a canonical tag or another alias)
+	for slug, entry := range t.entries {
+		for _, alias := range entry.Aliases {
+			alias = SanitizeSlug(alias)
+			if _, ok := t.entries[alias]; ok {
+				return nil, fmt.Errorf("%w: alias %q of %q is a canonical tag", ErrInvalidTaxonomy, alias, slug)
+			}
+			if other, ok := t.aliases[alias]; ok && other != slug {
+				return nil, fmt.Errorf("%w: alias %q is used by %q and %q", ErrInvalidTaxonomy, alias, other, slug)
+			}
+			t.aliases[alias] = slug
+		}
+	}
+
+	// Register the hierarchy
+	for slug, entry := range t.entries {
+		if entry.Parent == "" {
+			continue
+		}
+		if _, ok := t.entries[entry.Parent]; !ok {
+			return nil, fmt.Errorf("%w: unknown parent %q of %q", ErrInvalidTaxonomy, entry.Parent, slug)
+		}
+		t.children[entry.Parent] = append(t.children[entry.Parent], slug)
+	}
+	for _, children := range t.children {
+		slices.Sort(children)
+	}
+
+	// Reject cycles (the ancestors of a tag can never include the tag itself)
+	for slug := range t.entries {
+		visited := map[Slug]bool{slug: true}
+		for parent := t.entries[slug].Parent; parent != ""; parent = t.entries[parent].Parent {
+			if visited[parent] {
+				return nil, fmt.Errorf("%w: cycle through %q", ErrInvalidTaxonomy, slug)
+			}
+			visited[parent] = true
+		}
+	}
+
+	// Return the taxonomy
+	return t, nil
+}
+
+// ParseTaxonomy parses a taxonomy from JSON data ({"tags": [{"slug", "name", "category", "aliases", "parent"}]})
+func ParseTaxonomy(data []byte) (*Taxonomy, error) {
+	var file taxonomyFile
+	if err := sonicx.Config.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTaxonomy, err)
+	}
+	return NewTaxonomy(file.Tags)
+}
+
+// LoadTaxonomy loads a taxonomy from a JSON reader (a data file, an API response body, ...)
+func LoadTaxonomy(reader io.Reader) (*Taxonomy, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTaxonomy(data)
+}
+
+// LoadTaxonomyFile loads a taxonomy from a JSON data file
+func LoadTaxonomyFile(path string) (*Taxonomy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTaxonomy(data)
+}
+
+// DefaultTaxonomy returns the built-in taxonomy
+func DefaultTaxonomy() *Taxonomy {
+	return defaultTaxonomy()
+}
+
+// CurrentTaxonomy returns the taxonomy used by ResolveTag
+func CurrentTaxonomy() *Taxonomy {
+	if taxonomy := currentTaxonomy.Load(); taxonomy != nil {
+		return taxonomy
+	}
+	return defaultTaxonomy()
+}
+
+// SetTaxonomy replaces the taxonomy used by ResolveTag (nil restores the built-in taxonomy)
+func SetTaxonomy(taxonomy *Taxonomy) {
+	currentTaxonomy.Store(taxonomy)
+}
+
+// Canonical returns the canonical slug of a slug or alias
+func (t *Taxonomy) Canonical(slug Slug) (Slug, bool) {
+	slug = SanitizeSlug(slug)
+	if _, ok := t.entries[slug]; ok {
+		return slug, true
+	}
+	canonical, ok := t.aliases[slug]
+	return canonical, ok
+}
+
+// Entry returns the taxonomy entry of a slug or alias
+func (t *Taxonomy) Entry(slug Slug) (TaxonomyEntry, bool) {
+	canonical, ok := t.Canonical(slug)
+	if !ok {
+		return TaxonomyEntry{}, false
+	}
+	return t.entries[canonical], true
+}
+
+// Entries returns all the canonical tags, sorted by slug
+func (t *Taxonomy) Entries() []TaxonomyEntry {
+	entries := make([]TaxonomyEntry, 0, len(t.entries))
+	for _, entry := range t.entries {
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b TaxonomyEntry) int {
+		return cmp.Compare(a.Slug, b.Slug)
+	})
+	return entries
+}
+
+// Resolve resolves a tag string into a Tag (canonical tags and aliases resolve to the canonical tag)
+func (t *Taxonomy) Resolve(stringTag string) Tag {
+	// Check if the tag is known, and return the canonical tag if so
+	if entry, ok := t.Entry(stringTag); ok {
+		return Tag{Slug: entry.Slug, Name: entry.Name}
+	}
+	// Otherwise, return the sanitized tag
+	return Tag{Slug: SanitizeSlug(stringTag), Name: SanitizeName(stringTag)}
+}
+
+// Canonicalize replaces an already resolved tag with its canonical tag (unknown tags are returned unchanged)
+func (t *Taxonomy) Canonicalize(tag Tag) Tag {
+	if entry, ok := t.Entry(tag.Slug); ok {
+		return Tag{Slug: entry.Slug, Name: entry.Name}
+	}
+	return tag
+}
+
+// Category returns the category of a tag (TagCategoryNone for unknown tags)
+func (t *Taxonomy) Category(slug Slug) TagCategory {
+	entry, _ := t.Entry(slug)
+	return entry.Category
+}
+
+// Parent returns the canonical slug of the parent tag
+func (t *Taxonomy) Parent(slug Slug) (Slug, bool) {
+	entry, _ := t.Entry(slug)
+	return entry.Parent, entry.Parent != ""
+}
+
+// Ancestors returns the canonical slugs of all the ancestors of a tag (closest first)
+func (t *Taxonomy) Ancestors(slug Slug) []Slug {
+	var ancestors []Slug
+	for parent, ok := t.Parent(slug); ok; parent, ok = t.Parent(parent) {
+		ancestors = append(ancestors, parent)
+	}
+	return ancestors
+}
+
+// Children returns the canonical slugs of the direct children of a tag
+func (t *Taxonomy) Children(slug Slug) []Slug {
+	canonical, ok := t.Canonical(slug)
+	if !ok {
+		return nil
+	}
+	return slices.Clone(t.children[canonical])
+}
+
+// Descendants returns the canonical slugs of all the descendants of a tag (depth first)
+func (t *Taxonomy) Descendants(slug Slug) []Slug {
+	var descendants []Slug
+	for _, child := range t.Children(slug) {
+		descendants = append(descendants, child)
+		descendants = append(descendants, t.Descendants(child)...)
+	}
+	return descendants
+}
+
+// Matches checks if a tag matches the query tag (same canonical tag, or a descendant of it)
+func (t *Taxonomy) Matches(tag Tag, query string) bool {
+	// Resolve both sides to their canonical slugs
+	querySlug := t.Resolve(query).Slug
+	tagSlug := t.Canonicalize(tag).Slug
+	if querySlug == "" {
+		return false
+	}
+	// Check the tag itself and its ancestors
+	return tagSlug == querySlug || slices.Contains(t.Ancestors(tagSlug), querySlug)
+}
+
+// Group groups the tags by category (tags keep their relative order)
+func (t *Taxonomy) Group(tags []Tag) map[TagCategory][]Tag {
+	groups := make(map[TagCategory][]Tag)
+	for _, tag := range tags {
+		category := t.Category(tag.Slug)
+		groups[category] = append(groups[category], tag)
+	}
+	return groups
+}
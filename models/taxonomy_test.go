@@ -0,0 +1,122 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultTaxonomy(t *testing.T) {
+	taxonomy := DefaultTaxonomy()
+
+	t.Run("should load the built-in data file", func(t *testing.T) {
+		assert.NotEmpty(t, taxonomy.Entries())
+		assert.Equal(t, TagCategoryRating, taxonomy.Category("NSFW"))
+		assert.Equal(t, TagCategoryPOV, taxonomy.Category("first person"))
+		assert.Equal(t, TagCategoryPlatform, taxonomy.Category("ChubAI"))
+		assert.Equal(t, TagCategoryNone, taxonomy.Category("unknown"))
+	})
+
+	t.Run("should resolve aliases to canonical tags", func(t *testing.T) {
+		assert.Equal(t, Tag{Slug: "masseuse", Name: "Masseuse"}, taxonomy.Resolve("massuese"))
+		assert.Equal(t, Tag{Slug: "roleplaying", Name: "Role-Playing"}, taxonomy.Resolve("Role-Playing"))
+		assert.True(t, taxonomy.Matches(taxonomy.Resolve("Role-Playing"), "roleplay"))
+		assert.Equal(t, Tag{Slug: "masseuse", Name: "Masseuse"}, taxonomy.Canonicalize(Tag{Slug: "massuese", Name: "Massuese"}))
+		assert.Equal(t, Tag{Slug: "custom", Name: "Custom"}, taxonomy.Canonicalize(Tag{Slug: "custom", Name: "Custom"}))
+	})
+
+	t.Run("should expose the hierarchy", func(t *testing.T) {
+		parent, ok := taxonomy.Parent("ntrbait")
+		assert.True(t, ok)
+		assert.Equal(t, "ntr", parent)
+		assert.Equal(t, []Slug{"possiblentr", "ntr"}, taxonomy.Ancestors("possiblentrifyoutryreallyreallyhard"))
+		assert.Contains(t, taxonomy.Children("ntr"), "possiblentr")
+		assert.NotContains(t, taxonomy.Children("ntr"), "possiblentrifyoutryreallyreallyhard")
+		assert.Contains(t, taxonomy.Descendants("ntr"), "possiblentrifyoutryreallyreallyhard")
+	})
+
+	t.Run("should keep canonical slugs unique", func(t *testing.T) {
+		for _, entry := range taxonomy.Entries() {
+			assert.Equal(t, SanitizeSlug(entry.Slug), entry.Slug)
+			assert.Equal(t, entry.Name, strings.TrimSpace(entry.Name))
+		}
+	})
+}
+
+func TestTaxonomy_Search(t *testing.T) {
+	taxonomy, err := NewTaxonomy([]TaxonomyEntry{
+		{Slug: "monstergirl", Name: "Monster Girl", Aliases: []string{"monster girls"}},
+		{Slug: "foxgirl", Name: "Fox Girl", Aliases: []string{"kitsune"}, Parent: "monstergirl"},
+		{Slug: "fantasy", Name: "Fantasy", Category: TagCategoryGenre},
+	})
+	require.NoError(t, err)
+
+	t.Run("should match the tag and its descendants", func(t *testing.T) {
+		assert.True(t, taxonomy.Matches(Tag{Slug: "kitsune", Name: "Kitsune"}, "Monster Girls"))
+		assert.True(t, taxonomy.Matches(Tag{Slug: "foxgirl", Name: "Fox Girl"}, "fox girl"))
+		assert.False(t, taxonomy.Matches(Tag{Slug: "monstergirl", Name: "Monster Girl"}, "foxgirl"))
+		assert.False(t, taxonomy.Matches(Tag{Slug: "fantasy", Name: "Fantasy"}, ""))
+	})
+
+	t.Run("should group tags by category", func(t *testing.T) {
+		tags := []Tag{{Slug: "fantasy", Name: "Fantasy"}, {Slug: "foxgirl", Name: "Fox Girl"}, {Slug: "custom", Name: "Custom"}}
+
+		groups := taxonomy.Group(tags)
+
+		assert.Equal(t, map[TagCategory][]Tag{
+			TagCategoryGenre: {tags[0]},
+			TagCategoryNone:  {tags[1], tags[2]},
+		}, groups)
+	})
+}
+
+func TestNewTaxonomy_Invalid(t *testing.T) {
+	testCases := []struct {
+		name    string
+		entries []TaxonomyEntry
+	}{
+		{"blank name", []TaxonomyEntry{{Slug: "tag"}}},
+		{"duplicate slug", []TaxonomyEntry{{Slug: "tag", Name: "Tag"}, {Slug: "TAG", Name: "Tag"}}},
+		{"alias shadows a tag", []TaxonomyEntry{{Slug: "a", Name: "A", Aliases: []string{"b"}}, {Slug: "b", Name: "B"}}},
+		{"alias used twice", []TaxonomyEntry{{Slug: "a", Name: "A", Aliases: []string{"c"}}, {Slug: "b", Name: "B", Aliases: []string{"c"}}}},
+		{"unknown parent", []TaxonomyEntry{{Slug: "a", Name: "A", Parent: "b"}}},
+		{"cycle", []TaxonomyEntry{{Slug: "a", Name: "A", Parent: "b"}, {Slug: "b", Name: "B", Parent: "a"}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewTaxonomy(tc.entries)
+			assert.ErrorIs(t, err, ErrInvalidTaxonomy)
+		})
+	}
+}
+
+func TestLoadTaxonomyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "taxonomy.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"tags": [{"slug": "scifi", "name": "Sci-Fi", "category": "genre", "aliases": ["science fiction"]}]}`), 0o644))
+
+	taxonomy, err := LoadTaxonomyFile(path)
+	require.NoError(t, err)
+
+	t.Run("should resolve with the loaded taxonomy", func(t *testing.T) {
+		assert.Equal(t, Tag{Slug: "scifi", Name: "Sci-Fi"}, taxonomy.Resolve("Science Fiction"))
+		assert.Equal(t, TagCategoryGenre, taxonomy.Category("scifi"))
+	})
+
+	t.Run("should replace the taxonomy used by ResolveTag", func(t *testing.T) {
+		SetTaxonomy(taxonomy)
+		defer SetTaxonomy(nil)
+
+		assert.Equal(t, Tag{Slug: "scifi", Name: "Sci-Fi"}, ResolveTag("science-fiction"))
+		assert.Equal(t, Tag{Slug: "nsfw", Name: "Nsfw"}, ResolveTag("nsfw"))
+	})
+
+	t.Run("should reject malformed data", func(t *testing.T) {
+		_, err := LoadTaxonomy(strings.NewReader(`{"tags": [`))
+		assert.ErrorIs(t, err, ErrInvalidTaxonomy)
+	})
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"slices"
+)
+
+// TokenCounts represents the token estimates of the prompt fields of a card
+type TokenCounts struct {
+	// Description is the number of tokens of the description
+	Description int
+	// Personality is the number of tokens of the personality
+	Personality int
+	// Scenario is the number of tokens of the scenario
+	Scenario int
+	// SystemPrompt is the number of tokens of the system prompt and post-history instructions
+	SystemPrompt int
+	// Examples is the number of tokens of the example messages
+	Examples int
+	// FirstMessage is the number of tokens of the first message
+	FirstMessage int
+	// Greetings are the numbers of tokens of the alternate greetings
+	Greetings []int
+	// BookConstant is the number of tokens of the enabled constant lorebook entries (always inserted)
+	BookConstant int
+	// BookKeyed is the number of tokens of the enabled keyed lorebook entries (inserted when triggered)
+	BookKeyed int
+}
+
+// Permanent returns the number of tokens sent with every message (description, personality, scenario, system prompt, constant lorebook entries)
+func (t *TokenCounts) Permanent() int {
+	return t.Description + t.Personality + t.Scenario + t.SystemPrompt + t.BookConstant
+}
+
+// Total returns the number of tokens of all the prompt fields (the upper bound of the card context usage)
+func (t *TokenCounts) Total() int {
+	total := t.Permanent() + t.Examples + t.FirstMessage + t.BookKeyed
+	for _, greeting := range t.Greetings {
+		total += greeting
+	}
+	return total
+}
+
+// Clone returns a deep copy of the token counts
+func (t *TokenCounts) Clone() *TokenCounts {
+	if t == nil {
+		return nil
+	}
+	clone := *t
+	clone.Greetings = slices.Clone(t.Greetings)
+	return &clone
+}
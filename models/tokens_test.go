@@ -0,0 +1,66 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/toolkit/sonicx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenCounts(t *testing.T) {
+	counts := &TokenCounts{
+		Description:  300,
+		Personality:  50,
+		Scenario:     40,
+		SystemPrompt: 10,
+		Examples:     200,
+		FirstMessage: 120,
+		Greetings:    []int{80, 90},
+		BookConstant: 100,
+		BookKeyed:    500,
+	}
+
+	assert.Equal(t, 500, counts.Permanent())
+	assert.Equal(t, 1490, counts.Total())
+	assert.Zero(t, (&TokenCounts{}).Total())
+
+	clone := counts.Clone()
+	assert.Equal(t, counts, clone)
+	clone.Greetings[0] = 0
+	assert.Equal(t, 80, counts.Greetings[0], "Modifying the clone should not affect the original")
+	assert.Nil(t, (*TokenCounts)(nil).Clone())
+}
+
+func TestTokenCounts_JSON(t *testing.T) {
+	metadata := extensionMetadata()
+	metadata.Tokens = &TokenCounts{Description: 300, FirstMessage: 120, Greetings: []int{80}, BookConstant: 100}
+
+	t.Run("should round trip", func(t *testing.T) {
+		data, err := sonicx.Config.Marshal(metadata)
+		require.NoError(t, err)
+
+		decoded := &Metadata{}
+		require.NoError(t, sonicx.Config.Unmarshal(data, decoded))
+		assert.Equal(t, metadata, decoded)
+	})
+
+	t.Run("should include the permanent and total tokens", func(t *testing.T) {
+		data, err := sonicx.Config.Marshal(metadata)
+		require.NoError(t, err)
+
+		var document map[string]any
+		require.NoError(t, sonicx.Config.Unmarshal(data, &document))
+		tokens := document["tokens"].(map[string]any)
+		assert.EqualValues(t, 400, tokens["permanent"])
+		assert.EqualValues(t, 600, tokens["total"])
+	})
+
+	t.Run("should not embed the token counts in the sheet", func(t *testing.T) {
+		sheet := &character.Sheet{}
+		require.NoError(t, metadata.EmbedIn(sheet))
+
+		assert.NotContains(t, sheet.Extensions[ExtensionKey], "tokens")
+	})
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"github.com/r3dpixel/card-fetcher/source"
+	"github.com/r3dpixel/toolkit/timestamp"
+)
+
+// Tombstone struct for storing the record of a card removed from its source
+type Tombstone struct {
+	Source        source.ID
+	NormalizedURL string
+	LastKnown     *Metadata
+	RemovedTime   timestamp.Nano
+	LastCheckTime timestamp.Nano
+	Confirmations int
+}
+
+// IsConfirmed checks if the removal was confirmed at least the given number of times
+func (t *Tombstone) IsConfirmed(confirmations int) bool {
+	return t.Confirmations >= max(confirmations, 1)
+}
+
+// Clone returns a deep copy of the Tombstone struct
+func (t *Tombstone) Clone() *Tombstone {
+	// Clone tombstone
+	clone := *t
+
+	// Clone the last known metadata
+	if t.LastKnown != nil {
+		clone.LastKnown = t.LastKnown.Clone()
+	}
+
+	// Return the cloned tombstone
+	return &clone
+}
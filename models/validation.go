@@ -0,0 +1,251 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/toolkit/stringsx"
+	"github.com/r3dpixel/toolkit/timestamp"
+)
+
+// Severity represents the severity of a violated rule
+type Severity string
+
+// Severities
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// RuleID represents the identifier of a validation rule
+type RuleID string
+
+// Validation rules
+const (
+	RuleSourceBlank              RuleID = "metadata.source.blank"
+	RuleNormalizedURLBlank       RuleID = "metadata.normalized_url.blank"
+	RuleDirectURLBlank           RuleID = "metadata.direct_url.blank"
+	RuleCardPlatformIDBlank      RuleID = "metadata.card.platform_id.blank"
+	RuleCharacterIDBlank         RuleID = "metadata.character_id.blank"
+	RuleNameBlank                RuleID = "metadata.name.blank"
+	RuleTitleBlank               RuleID = "metadata.title.blank"
+	RuleCreateTimeMissing        RuleID = "metadata.create_time.missing"
+	RuleUpdateTimeMissing        RuleID = "metadata.update_time.missing"
+	RuleUpdateBeforeCreate       RuleID = "metadata.update_time.before_create_time"
+	RuleNicknameBlank            RuleID = "metadata.creator.nickname.blank"
+	RuleUsernameBlank            RuleID = "metadata.creator.username.blank"
+	RuleCreatorPlatformIDBlank   RuleID = "metadata.creator.platform_id.blank"
+	RuleBookUpdateTimeMismatch   RuleID = "metadata.book_update_time.mismatch"
+	RuleForkMismatch             RuleID = "metadata.fork.mismatch"
+	RuleMetadataMissing          RuleID = "metadata.missing"
+	RuleSheetMissing             RuleID = "sheet.missing"
+	RuleSheetIntegrity           RuleID = "sheet.integrity"
+	RuleSourceMismatch           RuleID = "sheet.source_id.mismatch"
+	RuleCharacterIDMismatch      RuleID = "sheet.character_id.mismatch"
+	RulePlatformIDMismatch       RuleID = "sheet.platform_id.mismatch"
+	RuleDirectLinkMismatch       RuleID = "sheet.direct_link.mismatch"
+	RuleTitleMismatch            RuleID = "sheet.title.mismatch"
+	RuleNameMismatch             RuleID = "sheet.name.mismatch"
+	RuleCreatorMismatch          RuleID = "sheet.creator.mismatch"
+	RuleTaglineMismatch          RuleID = "sheet.creator_notes.tagline_mismatch"
+	RuleCreationDateMismatch     RuleID = "sheet.creation_date.mismatch"
+	RuleModificationDateMismatch RuleID = "sheet.modification_date.mismatch"
+	RuleHasBookMismatch          RuleID = "sheet.character_book.mismatch"
+	RuleGreetingsCountMismatch   RuleID = "sheet.alternate_greetings.count_mismatch"
+	RuleTagsMismatch             RuleID = "sheet.tags.mismatch"
+)
+
+// Violation represents a violated validation rule
+type Violation struct {
+	Rule     RuleID
+	Severity Severity
+	Message  string
+	Values   map[string]any
+}
+
+// String returns a readable representation of the violation
+func (v Violation) String() string {
+	if len(v.Values) == 0 {
+		return fmt.Sprintf("[%s] %s: %s", v.Severity, v.Rule, v.Message)
+	}
+	return fmt.Sprintf("[%s] %s: %s %v", v.Severity, v.Rule, v.Message, v.Values)
+}
+
+// Report represents the result of a validation (the list of violated rules)
+type Report struct {
+	Violations []Violation
+}
+
+// Valid checks if the report has no error violations (warnings are allowed)
+func (r *Report) Valid() bool {
+	return r == nil || !slices.ContainsFunc(r.Violations, func(v Violation) bool {
+		return v.Severity == SeverityError
+	})
+}
+
+// Has checks if the given rule was violated
+func (r *Report) Has(rule RuleID) bool {
+	return r != nil && slices.ContainsFunc(r.Violations, func(v Violation) bool {
+		return v.Rule == rule
+	})
+}
+
+// Rules returns the identifiers of the violated rules
+func (r *Report) Rules() []RuleID {
+	if r == nil {
+		return nil
+	}
+	rules := make([]RuleID, len(r.Violations))
+	for index, violation := range r.Violations {
+		rules[index] = violation.Rule
+	}
+	return rules
+}
+
+// Add records a violation
+func (r *Report) Add(rule RuleID, severity Severity, message string, values map[string]any) {
+	r.Violations = append(r.Violations, Violation{Rule: rule, Severity: severity, Message: message, Values: values})
+}
+
+// Merge records all the violations of the other report
+func (r *Report) Merge(other *Report) {
+	if other != nil {
+		r.Violations = append(r.Violations, other.Violations...)
+	}
+}
+
+// String returns a readable representation of the report (one violation per line)
+func (r *Report) String() string {
+	if r == nil || len(r.Violations) == 0 {
+		return "valid"
+	}
+	lines := make([]string, len(r.Violations))
+	for index, violation := range r.Violations {
+		lines[index] = violation.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// check records an error violation if the condition does not hold
+func (r *Report) check(condition bool, rule RuleID, message string, values map[string]any) {
+	if !condition {
+		r.Add(rule, SeverityError, message, values)
+	}
+}
+
+// Validate checks the integrity of the metadata
+func (m *Metadata) Validate() *Report {
+	report := &Report{}
+	report.check(stringsx.IsNotBlank(string(m.Source)), RuleSourceBlank, "source is blank", nil)
+	report.check(stringsx.IsNotBlank(m.NormalizedURL), RuleNormalizedURLBlank, "normalized URL is blank", nil)
+	report.check(stringsx.IsNotBlank(m.DirectURL), RuleDirectURLBlank, "direct URL is blank", nil)
+	report.check(stringsx.IsNotBlank(m.CardInfo.PlatformID), RuleCardPlatformIDBlank, "card platform ID is blank", nil)
+	report.check(stringsx.IsNotBlank(m.CharacterID), RuleCharacterIDBlank, "character ID is blank", nil)
+	report.check(stringsx.IsNotBlank(m.Name), RuleNameBlank, "name is blank", nil)
+	report.check(stringsx.IsNotBlank(m.Title), RuleTitleBlank, "title is blank", nil)
+	report.check(m.CreateTime > 0, RuleCreateTimeMissing, "create time is missing", map[string]any{"create_time": m.CreateTime})
+	report.check(m.UpdateTime > 0, RuleUpdateTimeMissing, "update time is missing", map[string]any{"update_time": m.UpdateTime})
+	report.check(
+		m.UpdateTime >= m.CreateTime,
+		RuleUpdateBeforeCreate,
+		"update time is before create time",
+		map[string]any{"create_time": m.CreateTime, "update_time": m.UpdateTime},
+	)
+	report.check(stringsx.IsNotBlank(m.Nickname), RuleNicknameBlank, "creator nickname is blank", nil)
+	report.check(stringsx.IsNotBlank(m.Username), RuleUsernameBlank, "creator username is blank", nil)
+	report.check(
+		strings.ToLower(m.Nickname) == anonymousIdentifier || stringsx.IsNotBlank(m.CreatorInfo.PlatformID),
+		RuleCreatorPlatformIDBlank,
+		"creator platform ID is blank (and the creator is not anonymous)",
+		map[string]any{"nickname": m.Nickname},
+	)
+	report.check(
+		m.IsForked == (m.Fork != nil),
+		RuleForkMismatch,
+		"fork information must be set if and only if the card is forked",
+		map[string]any{"is_forked": m.IsForked, "fork": m.Fork},
+	)
+	return report
+}
+
+// ValidateAgainst checks the integrity of the metadata and its consistency with the card
+func (m *Metadata) ValidateAgainst(card *character.Sheet) *Report {
+	report := &Report{}
+
+	// Both or none must be missing
+	switch {
+	case card == nil && m == nil:
+		return report
+	case card == nil:
+		report.Add(RuleSheetMissing, SeverityError, "sheet is missing", nil)
+		return report
+	case m == nil:
+		report.Add(RuleMetadataMissing, SeverityError, "metadata is missing", nil)
+		return report
+	}
+
+	// Check the integrity of both
+	report.Merge(m.Validate())
+	report.check(card.Integrity(), RuleSheetIntegrity, "sheet integrity check failed", nil)
+
+	// Check the consistency of the meta fields
+	report.check(string(m.Source) == string(card.SourceID), RuleSourceMismatch, "source ID mismatch", mismatch(m.Source, card.SourceID))
+	report.check(m.CharacterID == string(card.CharacterID), RuleCharacterIDMismatch, "character ID mismatch", mismatch(m.CharacterID, card.CharacterID))
+	report.check(m.CardInfo.PlatformID == string(card.PlatformID), RulePlatformIDMismatch, "platform ID mismatch", mismatch(m.CardInfo.PlatformID, card.PlatformID))
+	report.check(m.DirectURL == string(card.DirectLink), RuleDirectLinkMismatch, "direct link mismatch", mismatch(m.DirectURL, card.DirectLink))
+
+	// Check the consistency of the patched fields
+	report.check(m.Title == string(card.Title), RuleTitleMismatch, "title mismatch", mismatch(m.Title, card.Title))
+	report.check(m.Name == string(card.Name), RuleNameMismatch, "name mismatch", mismatch(m.Name, card.Name))
+	report.check(m.Nickname == string(card.Creator), RuleCreatorMismatch, "creator mismatch", mismatch(m.Nickname, card.Creator))
+	report.check(
+		strings.HasPrefix(string(card.CreatorNotes), m.Tagline),
+		RuleTaglineMismatch,
+		"creator notes do not start with the tagline",
+		map[string]any{"tagline": m.Tagline},
+	)
+
+	// Check the consistency of the timestamps
+	report.check(
+		timestamp.ConvertToSeconds(m.CreateTime) == card.CreationDate,
+		RuleCreationDateMismatch,
+		"creation date mismatch",
+		mismatch(timestamp.ConvertToSeconds(m.CreateTime), card.CreationDate),
+	)
+	report.check(
+		timestamp.ConvertToSeconds(m.LatestUpdateTime()) == card.ModificationDate,
+		RuleModificationDateMismatch,
+		"modification date mismatch",
+		mismatch(timestamp.ConvertToSeconds(m.LatestUpdateTime()), card.ModificationDate),
+	)
+
+	// Check the consistency of the book and greetings
+	report.check(m.HasBook == (card.CharacterBook != nil), RuleHasBookMismatch, "character book presence mismatch", mismatch(m.HasBook, card.CharacterBook != nil))
+	report.check(
+		(!m.HasBook && m.BookUpdateTime == 0) || (m.HasBook && m.BookUpdateTime != 0),
+		RuleBookUpdateTimeMismatch,
+		"book update time must be set if and only if the card has a book",
+		map[string]any{"has_book": m.HasBook, "book_update_time": m.BookUpdateTime},
+	)
+	report.check(
+		m.GreetingsCount == len(card.AlternateGreetings),
+		RuleGreetingsCountMismatch,
+		"alternate greetings count mismatch",
+		mismatch(m.GreetingsCount, len(card.AlternateGreetings)),
+	)
+
+	// Check the consistency of the tags
+	metadataTags := TagsToNames(m.CardInfo.Tags)
+	report.check(slices.Equal(metadataTags, card.Tags), RuleTagsMismatch, "tags mismatch", mismatch(metadataTags, []string(card.Tags)))
+
+	// Return the report
+	return report
+}
+
+// mismatch returns the values of a mismatch violation
+func mismatch(metadataValue, sheetValue any) map[string]any {
+	return map[string]any{"metadata": metadataValue, "sheet": sheetValue}
+}
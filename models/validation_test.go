@@ -0,0 +1,109 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/toolkit/timestamp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadata_Validate(t *testing.T) {
+	t.Run("should report no violations for valid metadata", func(t *testing.T) {
+		metadata, _ := createConsistentPair()
+		report := metadata.Validate()
+
+		assert.True(t, report.Valid())
+		assert.Empty(t, report.Violations)
+		assert.Equal(t, "valid", report.String())
+	})
+
+	t.Run("should report every violated rule with the offending values", func(t *testing.T) {
+		metadata, _ := createConsistentPair()
+		metadata.Username = " "
+		metadata.UpdateTime = metadata.CreateTime - 1
+
+		report := metadata.Validate()
+
+		assert.False(t, report.Valid())
+		assert.Equal(t, []RuleID{RuleUpdateBeforeCreate, RuleUsernameBlank}, report.Rules())
+		assert.Equal(t, SeverityError, report.Violations[0].Severity)
+		assert.Equal(t, map[string]any{
+			"create_time": metadata.CreateTime,
+			"update_time": metadata.UpdateTime,
+		}, report.Violations[0].Values)
+		assert.Contains(t, report.String(), string(RuleUsernameBlank))
+	})
+
+	t.Run("should report forked cards without fork information", func(t *testing.T) {
+		metadata, _ := createConsistentPair()
+		metadata.IsForked = true
+
+		assert.Equal(t, []RuleID{RuleForkMismatch}, metadata.Validate().Rules())
+
+		metadata.Fork = &ForkInfo{}
+		assert.True(t, metadata.Validate().Valid())
+	})
+
+	t.Run("should allow blank creator platform ID for anonymous creators", func(t *testing.T) {
+		metadata, _ := createConsistentPair()
+		metadata.Nickname = character.AnonymousCreator
+		metadata.CreatorInfo.PlatformID = ""
+
+		assert.False(t, metadata.Validate().Has(RuleCreatorPlatformIDBlank))
+	})
+}
+
+func TestMetadata_ValidateAgainst(t *testing.T) {
+	t.Run("should report no violations for consistent data", func(t *testing.T) {
+		metadata, card := createConsistentPair()
+		assert.Empty(t, metadata.ValidateAgainst(card).Violations)
+	})
+
+	t.Run("should report the mismatched fields", func(t *testing.T) {
+		metadata, card := createConsistentPair()
+		card.Tags = []string{"Fantasy"}
+		card.ModificationDate++
+
+		report := metadata.ValidateAgainst(card)
+
+		assert.Equal(t, []RuleID{RuleModificationDateMismatch, RuleTagsMismatch}, report.Rules())
+		assert.Equal(t, mismatch([]string{"Fantasy", "Adventure"}, []string{"Fantasy"}), report.Violations[1].Values)
+		assert.Equal(t, mismatch(timestamp.ConvertToSeconds(metadata.LatestUpdateTime()), card.ModificationDate), report.Violations[0].Values)
+	})
+
+	t.Run("should include the metadata integrity violations", func(t *testing.T) {
+		metadata, card := createConsistentPair()
+		metadata.Title = ""
+		card.Title = ""
+
+		report := metadata.ValidateAgainst(card)
+
+		assert.Equal(t, []RuleID{RuleTitleBlank}, report.Rules())
+	})
+
+	t.Run("should report missing sides", func(t *testing.T) {
+		metadata, card := createConsistentPair()
+		var nilMetadata *Metadata
+
+		assert.Equal(t, []RuleID{RuleSheetMissing}, metadata.ValidateAgainst(nil).Rules())
+		assert.Equal(t, []RuleID{RuleMetadataMissing}, nilMetadata.ValidateAgainst(card).Rules())
+		assert.True(t, nilMetadata.ValidateAgainst(nil).Valid())
+	})
+}
+
+func TestReport(t *testing.T) {
+	var nilReport *Report
+	assert.True(t, nilReport.Valid())
+	assert.False(t, nilReport.Has(RuleNameBlank))
+	assert.Nil(t, nilReport.Rules())
+
+	report := &Report{}
+	report.Add("custom.warning", SeverityWarning, "only a warning", nil)
+	assert.True(t, report.Valid(), "warnings should not invalidate the report")
+	assert.True(t, report.Has("custom.warning"))
+
+	report.Merge(&Report{Violations: []Violation{{Rule: RuleNameBlank, Severity: SeverityError}}})
+	assert.False(t, report.Valid())
+	assert.Equal(t, "[warning] custom.warning: only a warning\n[error] metadata.name.blank: ", report.String())
+}
@@ -0,0 +1,343 @@
+package refresh
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/r3dpixel/card-fetcher/export"
+	"github.com/r3dpixel/card-fetcher/fetcher"
+	"github.com/r3dpixel/card-fetcher/router"
+	"github.com/r3dpixel/card-fetcher/source"
+	"github.com/r3dpixel/card-fetcher/task"
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/card-parser/png"
+	"github.com/r3dpixel/toolkit/jsonx"
+	"github.com/r3dpixel/toolkit/stringsx"
+)
+
+const (
+	// DefaultBackupSuffix is the suffix appended to the backup files
+	DefaultBackupSuffix = ".bak"
+	// jsonExtension is the extension of the JSON card files
+	jsonExtension = ".json"
+)
+
+// Status represents the outcome of refreshing a card file
+type Status string
+
+// Refresh statuses
+const (
+	Refreshed     Status = "REFRESHED"
+	Outdated      Status = "OUTDATED"
+	UpToDate      Status = "UP TO DATE"
+	NoProvenance  Status = "NO PROVENANCE"
+	UnknownSource Status = "UNKNOWN SOURCE"
+	Removed       Status = "REMOVED"
+	Failed        Status = "FAILED"
+)
+
+// Options for configuring a refresher
+type Options struct {
+	// Recursive scans the subdirectories too
+	Recursive bool
+	// DryRun only checks for newer versions (outdated cards are reported, no files are written)
+	DryRun bool
+	// Force writes the fetched version even if it is not newer than the local one
+	Force bool
+	// DisableBackup disables the backup of the overwritten files
+	DisableBackup bool
+	// BackupDir is the directory for the backup files, keeping the path of each card relative to the refreshed directory
+	// (defaults to the directory of each card)
+	BackupDir string
+	// BackupSuffix is the suffix appended to the backup files (defaults to DefaultBackupSuffix, numbered if a backup exists)
+	BackupSuffix string
+	// Version is the specification version of the written cards (export.VersionKeep keeps the version of the sheet)
+	Version export.Version
+}
+
+// Report represents the outcome of refreshing a single card file
+type Report struct {
+	Path          string
+	Status        Status
+	SourceID      source.ID
+	CharacterID   string
+	NormalizedURL string
+	BackupPath    string
+	Err           error
+}
+
+// Refresher refreshes card files from the provenance embedded by the patcher (source, character ID, direct link)
+type Refresher struct {
+	router *router.Router
+	opts   Options
+}
+
+// New creates a new refresher using the given router to build the tasks
+func New(r *router.Router, opts Options) *Refresher {
+	// Use the default backup suffix if not set
+	if opts.BackupSuffix == "" {
+		opts.BackupSuffix = DefaultBackupSuffix
+	}
+	return &Refresher{router: r, opts: opts}
+}
+
+// Dir refreshes all the card files (PNG and JSON) in the given directory
+func (r *Refresher) Dir(dir string) ([]Report, error) {
+	// Collect the card files
+	paths, err := r.scan(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Refresh each card file
+	reports := make([]Report, len(paths))
+	for index, path := range paths {
+		reports[index] = r.file(dir, path)
+	}
+
+	// Return the reports
+	return reports, nil
+}
+
+// File refreshes a single card file (PNG or JSON)
+func (r *Refresher) File(path string) Report {
+	return r.file(filepath.Dir(path), path)
+}
+
+// file refreshes a card file of the given directory (the backups keep the path relative to the directory)
+func (r *Refresher) file(dir string, path string) Report {
+	report := Report{Path: path}
+
+	// Read the local card
+	localSheet, err := readSheet(path)
+	if err != nil {
+		return report.fail(err)
+	}
+
+	// Read the provenance of the card
+	report.SourceID = source.ID(localSheet.SourceID)
+	report.CharacterID = string(localSheet.CharacterID)
+	directLink := string(localSheet.DirectLink)
+	if stringsx.IsBlank(string(report.SourceID)) && stringsx.IsBlank(report.CharacterID) && stringsx.IsBlank(directLink) {
+		report.Status = NoProvenance
+		return report
+	}
+
+	// Build the task through the router
+	cardTask, ok := r.taskOf(report.SourceID, report.CharacterID, directLink)
+	if !ok {
+		report.Status = UnknownSource
+		return report
+	}
+	report.SourceID = cardTask.SourceID()
+	report.NormalizedURL = cardTask.NormalizedURL()
+
+	// Fetch the remote card
+	_, remoteCard, err := cardTask.FetchAll()
+	if err != nil {
+		if fetcher.GetErrCode(err) == fetcher.RemovedErr {
+			report.Status = Removed
+			report.Err = err
+			return report
+		}
+		return report.fail(err)
+	}
+
+	// Skip the cards that are not outdated
+	if !r.opts.Force && remoteCard.ModificationDate <= localSheet.ModificationDate {
+		report.Status = UpToDate
+		return report
+	}
+
+	// Only report the outdated cards in dry run mode
+	if r.opts.DryRun {
+		report.Status = Outdated
+		return report
+	}
+
+	// Back up the local card
+	if !r.opts.DisableBackup {
+		if report.BackupPath, err = r.backup(dir, path); err != nil {
+			return report.fail(err)
+		}
+	}
+
+	// Write the remote card over the local card
+	if err := writeCard(path, remoteCard, r.opts.Version); err != nil {
+		return report.fail(err)
+	}
+
+	// Return the report
+	report.Status = Refreshed
+	return report
+}
+
+// taskOf builds the task for the given provenance (source and character ID first, direct link as fallback)
+func (r *Refresher) taskOf(sourceID source.ID, characterID string, directLink string) (task.Task, bool) {
+	if cardTask, ok := r.router.TaskOfSource(sourceID, characterID); ok {
+		return cardTask, true
+	}
+	if stringsx.IsBlank(directLink) {
+		return nil, false
+	}
+	return r.router.TaskOf(directLink)
+}
+
+// scan collects the card files in the given directory, sorted by path
+func (r *Refresher) scan(dir string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		// Do not descend into subdirectories unless recursive
+		if entry.IsDir() {
+			if path != dir && !r.opts.Recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// Collect the card files
+		if isCardFile(path) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// backup copies the given file of the given directory to its backup path
+// (the path relative to the directory is kept under BackupDir, existing backups are never overwritten)
+func (r *Refresher) backup(dir string, path string) (string, error) {
+	// Read the original file
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	// Compute the backup path (next to the card, or at the same relative path under BackupDir)
+	backupPath := path
+	if r.opts.BackupDir != "" {
+		relativePath, err := filepath.Rel(dir, path)
+		if err != nil || !filepath.IsLocal(relativePath) {
+			relativePath = filepath.Base(path)
+		}
+		backupPath = filepath.Join(r.opts.BackupDir, relativePath)
+	}
+	if err := os.MkdirAll(filepath.Dir(backupPath), 0o755); err != nil {
+		return "", err
+	}
+
+	// Write the backup file, numbering it if a previous backup exists (card.png.bak, card.png.1.bak, ...)
+	for number := 0; ; number++ {
+		numberedPath := backupPath + r.opts.BackupSuffix
+		if number > 0 {
+			numberedPath = fmt.Sprintf("%s.%d%s", backupPath, number, r.opts.BackupSuffix)
+		}
+		file, err := os.OpenFile(numberedPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if _, err := file.Write(data); err != nil {
+			_ = file.Close()
+			return "", err
+		}
+		return numberedPath, file.Close()
+	}
+}
+
+// fail marks the report as failed with the given error
+func (r Report) fail(err error) Report {
+	r.Status = Failed
+	r.Err = err
+	return r
+}
+
+// isCardFile checks if the given path is a card file (PNG or JSON)
+func isCardFile(path string) bool {
+	extension := strings.ToLower(filepath.Ext(path))
+	return extension == png.Extension || extension == jsonExtension
+}
+
+// readSheet reads the sheet of a card file (PNG or JSON)
+func readSheet(path string) (*character.Sheet, error) {
+	// Read the file
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the JSON sheet
+	if strings.ToLower(filepath.Ext(path)) == jsonExtension {
+		return character.FromBytes(data)
+	}
+
+	// Parse the PNG card
+	rawCard, err := png.FromBytes(data).First().Get()
+	if err != nil {
+		return nil, err
+	}
+	characterCard, err := rawCard.Decode()
+	if err != nil {
+		return nil, err
+	}
+	if characterCard == nil || characterCard.Sheet == nil {
+		return nil, errors.New("card has no character sheet")
+	}
+
+	// Return the sheet
+	return characterCard.Sheet, nil
+}
+
+// writeCard writes the card to the given path, in the format of the file (PNG or JSON)
+// Cards are converted to the version if one is selected (see export.Options)
+func writeCard(path string, characterCard *png.CharacterCard, version export.Version) error {
+	isJSON := strings.ToLower(filepath.Ext(path)) == jsonExtension
+
+	// Convert and write the card in the selected version
+	if version != export.VersionKeep {
+		return writeConverted(path, characterCard, isJSON, export.Options{Version: version})
+	}
+
+	// Write the JSON sheet
+	if isJSON {
+		return characterCard.Sheet.ToFile(path, jsonx.Options{Pretty: true, Indent: "  "})
+	}
+
+	// Encode and write the PNG card
+	rawCard, err := characterCard.Encode()
+	if err != nil {
+		return err
+	}
+	return rawCard.ToFile(path)
+}
+
+// writeConverted writes the card converted with the export options (JSON sheets are indented like the sheet files)
+func writeConverted(path string, characterCard *png.CharacterCard, isJSON bool, options export.Options) error {
+	if !isJSON {
+		data, err := export.PNG(characterCard, options)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, data, 0o644)
+	}
+
+	cardJSON, err := export.JSON(characterCard.Sheet, options)
+	if err != nil {
+		return err
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, cardJSON, "", "  "); err != nil {
+		return err
+	}
+	return os.WriteFile(path, indented.Bytes(), 0o644)
+}
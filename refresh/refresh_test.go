@@ -0,0 +1,233 @@
+package refresh
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/imroc/req/v3"
+	"github.com/r3dpixel/card-fetcher/fetcher"
+	"github.com/r3dpixel/card-fetcher/impl"
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-fetcher/router"
+	"github.com/r3dpixel/card-fetcher/source"
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/card-parser/png"
+	"github.com/r3dpixel/card-parser/property"
+	"github.com/r3dpixel/toolkit/reqx"
+	"github.com/r3dpixel/toolkit/timestamp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testSource     = source.ID("test-source")
+	testUpdateTime = time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func testRouter(mockData impl.MockData) *router.Router {
+	r := router.New(reqx.Options{})
+	r.RegisterFetchers(impl.NewMockFetcher(impl.MockConfig{
+		MockSourceID:  testSource,
+		MockDomain:    "example.com",
+		MockDirectURL: "example.com/char",
+		IsUp:          true,
+	}, mockData))
+	return r
+}
+
+func aliveData() impl.MockData {
+	response := &req.Response{}
+	response.SetBodyString(`{}`)
+	return impl.MockData{
+		Response: response,
+		CardInfo: &models.CardInfo{
+			Name:        "Remote",
+			Title:       "Remote",
+			CharacterID: "123",
+			UpdateTime:  timestamp.Nano(testUpdateTime.UnixNano()),
+		},
+		CreatorInfo:   &models.CreatorInfo{Nickname: "Creator"},
+		CharacterCard: &png.CharacterCard{Sheet: character.DefaultSheet(character.RevisionV2)},
+	}
+}
+
+func writeSheet(t *testing.T, dir string, name string, sheet *character.Sheet) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, sheet.ToFile(path))
+	return path
+}
+
+func localSheet(modificationDate time.Time) *character.Sheet {
+	sheet := character.DefaultSheet(character.RevisionV2)
+	sheet.Name = "Local"
+	sheet.SourceID = property.String(testSource)
+	sheet.CharacterID = "123"
+	sheet.DirectLink = "example.com/char/123"
+	sheet.ModificationDate = timestamp.Seconds(modificationDate.Unix())
+	return sheet
+}
+
+func TestRefresher_File(t *testing.T) {
+	t.Run("should refresh outdated cards and keep a backup", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeSheet(t, dir, "card.json", localSheet(testUpdateTime.Add(-time.Hour)))
+
+		report := New(testRouter(aliveData()), Options{}).File(path)
+
+		assert.Equal(t, Refreshed, report.Status)
+		assert.NoError(t, report.Err)
+		assert.Equal(t, testSource, report.SourceID)
+		assert.Equal(t, "example.com/123", report.NormalizedURL)
+		assert.Equal(t, path+DefaultBackupSuffix, report.BackupPath)
+
+		backup, err := os.ReadFile(report.BackupPath)
+		require.NoError(t, err)
+		backupSheet, err := character.FromBytes(backup)
+		require.NoError(t, err)
+		assert.Equal(t, property.String("Local"), backupSheet.Name)
+
+		refreshed, err := os.ReadFile(path)
+		require.NoError(t, err)
+		refreshedSheet, err := character.FromBytes(refreshed)
+		require.NoError(t, err)
+		assert.Equal(t, property.String("Remote"), refreshedSheet.Name)
+	})
+
+	t.Run("should skip up to date cards", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeSheet(t, dir, "card.json", localSheet(testUpdateTime))
+
+		report := New(testRouter(aliveData()), Options{}).File(path)
+
+		assert.Equal(t, UpToDate, report.Status)
+		assert.NoFileExists(t, path+DefaultBackupSuffix)
+	})
+
+	t.Run("should only report outdated cards in dry run mode", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeSheet(t, dir, "card.json", localSheet(testUpdateTime.Add(-time.Hour)))
+
+		report := New(testRouter(aliveData()), Options{DryRun: true}).File(path)
+
+		assert.Equal(t, Outdated, report.Status)
+		assert.NoFileExists(t, path+DefaultBackupSuffix)
+	})
+
+	t.Run("should report cards without provenance", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeSheet(t, dir, "card.json", character.DefaultSheet(character.RevisionV2))
+
+		report := New(testRouter(aliveData()), Options{}).File(path)
+
+		assert.Equal(t, NoProvenance, report.Status)
+	})
+
+	t.Run("should report cards with an unknown source", func(t *testing.T) {
+		dir := t.TempDir()
+		sheet := localSheet(testUpdateTime)
+		sheet.SourceID = "unknown"
+		sheet.DirectLink = "unknown.com/char/123"
+		path := writeSheet(t, dir, "card.json", sheet)
+
+		report := New(testRouter(aliveData()), Options{}).File(path)
+
+		assert.Equal(t, UnknownSource, report.Status)
+		assert.Equal(t, source.ID("unknown"), report.SourceID)
+	})
+
+	t.Run("should fall back to the direct link", func(t *testing.T) {
+		dir := t.TempDir()
+		sheet := localSheet(testUpdateTime)
+		sheet.SourceID = ""
+		path := writeSheet(t, dir, "card.json", sheet)
+
+		report := New(testRouter(aliveData()), Options{}).File(path)
+
+		assert.Equal(t, UpToDate, report.Status)
+		assert.Equal(t, testSource, report.SourceID)
+	})
+
+	t.Run("should report removed cards", func(t *testing.T) {
+		dir := t.TempDir()
+		path := writeSheet(t, dir, "card.json", localSheet(testUpdateTime))
+
+		report := New(testRouter(impl.MockData{
+			Response: &req.Response{Response: &http.Response{StatusCode: http.StatusNotFound}},
+		}), Options{}).File(path)
+
+		assert.Equal(t, Removed, report.Status)
+		assert.Equal(t, fetcher.RemovedErr, fetcher.GetErrCode(report.Err))
+	})
+
+	t.Run("should report unreadable cards", func(t *testing.T) {
+		report := New(testRouter(aliveData()), Options{}).File(filepath.Join(t.TempDir(), "missing.json"))
+
+		assert.Equal(t, Failed, report.Status)
+		assert.Error(t, report.Err)
+	})
+}
+
+func TestRefresher_Dir(t *testing.T) {
+	dir := t.TempDir()
+	writeSheet(t, dir, "a.json", localSheet(testUpdateTime.Add(-time.Hour)))
+	writeSheet(t, dir, "b.json", character.DefaultSheet(character.RevisionV2))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a card"), 0o644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "nested"), 0o755))
+	writeSheet(t, filepath.Join(dir, "nested"), "c.json", localSheet(testUpdateTime))
+
+	t.Run("should only scan the card files of the directory", func(t *testing.T) {
+		reports, err := New(testRouter(aliveData()), Options{DryRun: true}).Dir(dir)
+
+		require.NoError(t, err)
+		if assert.Len(t, reports, 2) {
+			assert.Equal(t, Outdated, reports[0].Status)
+			assert.Equal(t, NoProvenance, reports[1].Status)
+		}
+	})
+
+	t.Run("should scan the subdirectories if recursive", func(t *testing.T) {
+		reports, err := New(testRouter(aliveData()), Options{DryRun: true, Recursive: true}).Dir(dir)
+
+		require.NoError(t, err)
+		if assert.Len(t, reports, 3) {
+			assert.Equal(t, UpToDate, reports[2].Status)
+		}
+	})
+
+	t.Run("should fail for missing directories", func(t *testing.T) {
+		_, err := New(testRouter(aliveData()), Options{}).Dir(filepath.Join(dir, "missing"))
+		assert.Error(t, err)
+	})
+
+	t.Run("should keep the relative paths of the backups and never overwrite them", func(t *testing.T) {
+		cardDir := t.TempDir()
+		backupDir := t.TempDir()
+		for _, folder := range []string{"first", "second"} {
+			require.NoError(t, os.Mkdir(filepath.Join(cardDir, folder), 0o755))
+			writeSheet(t, filepath.Join(cardDir, folder), "card.json", localSheet(testUpdateTime.Add(-time.Hour)))
+		}
+		refresher := New(testRouter(aliveData()), Options{Recursive: true, Force: true, BackupDir: backupDir})
+
+		reports, err := refresher.Dir(cardDir)
+		require.NoError(t, err)
+		if assert.Len(t, reports, 2) {
+			assert.Equal(t, filepath.Join(backupDir, "first", "card.json"+DefaultBackupSuffix), reports[0].BackupPath)
+			assert.Equal(t, filepath.Join(backupDir, "second", "card.json"+DefaultBackupSuffix), reports[1].BackupPath)
+		}
+
+		reports, err = refresher.Dir(cardDir)
+		require.NoError(t, err)
+		if assert.Len(t, reports, 2) {
+			assert.Equal(t, filepath.Join(backupDir, "first", "card.json.1"+DefaultBackupSuffix), reports[0].BackupPath)
+		}
+
+		backup, err := os.ReadFile(filepath.Join(backupDir, "first", "card.json"+DefaultBackupSuffix))
+		require.NoError(t, err)
+		backupSheet, err := character.FromBytes(backup)
+		require.NoError(t, err)
+		assert.Equal(t, property.String("Local"), backupSheet.Name, "the first backup should be kept")
+	})
+}
@@ -0,0 +1,118 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-fetcher/task"
+)
+
+// DefaultForkDepth is the default maximum number of parents followed when building a lineage
+const DefaultForkDepth = 16
+
+// ErrForkCycle is returned when the parent chain of a card loops back onto itself
+var ErrForkCycle = errors.New("fork cycle detected")
+
+// ForkNode represents a card in a fork tree
+type ForkNode struct {
+	Metadata *models.Metadata
+	Children []*ForkNode
+}
+
+// ForkLineage follows the parent chain of the card (the card first, its farthest known ancestor last)
+// The chain stops at the original card, at a parent the platform does not identify, or after maxDepth parents (0 uses DefaultForkDepth)
+// If a parent cannot be fetched, the partial lineage is returned with the error
+func (r *Router) ForkLineage(url string, maxDepth int) ([]*models.Metadata, error) {
+	// Set the default depth
+	if maxDepth <= 0 {
+		maxDepth = DefaultForkDepth
+	}
+
+	// Create the task of the card
+	current, ok := r.TaskOf(url)
+	if !ok {
+		return nil, fmt.Errorf("no fetcher matches %s", url)
+	}
+
+	// Follow the parents
+	var lineage []*models.Metadata
+	visited := map[string]bool{}
+	for {
+		// Detect cycles (a card cannot be its own ancestor)
+		if visited[current.NormalizedURL()] {
+			return lineage, fmt.Errorf("%w: %s", ErrForkCycle, current.NormalizedURL())
+		}
+		visited[current.NormalizedURL()] = true
+
+		// Fetch the metadata of the card
+		metadata, err := current.FetchMetadata()
+		if err != nil {
+			return lineage, err
+		}
+		lineage = append(lineage, metadata)
+
+		// Stop at the original card, at an unknown parent, or at the maximum depth
+		if !metadata.Fork.IsResolvable() || len(lineage) > maxDepth {
+			return lineage, nil
+		}
+
+		// Create the task of the parent
+		if current, ok = r.taskOfFork(metadata.Fork); !ok {
+			return lineage, fmt.Errorf("no fetcher for the parent of %s", metadata.NormalizedURL)
+		}
+	}
+}
+
+// ForkTree builds the fork trees of the given cards (the roots are the farthest known ancestors)
+// Cards sharing ancestors are merged into the same tree; lineage errors are joined and the partial trees are returned
+func (r *Router) ForkTree(urls ...string) ([]*ForkNode, error) {
+	var roots []*ForkNode
+	var errs []error
+	nodes := map[string]*ForkNode{}
+
+	for _, url := range urls {
+		// Build the lineage of the card
+		lineage, err := r.ForkLineage(url, 0)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		// Insert the lineage from the root down to the card
+		var parent *ForkNode
+		for index := len(lineage) - 1; index >= 0; index-- {
+			metadata := lineage[index]
+			node, ok := nodes[metadata.NormalizedURL]
+			if !ok {
+				node = &ForkNode{Metadata: metadata}
+				nodes[metadata.NormalizedURL] = node
+				if parent == nil {
+					roots = append(roots, node)
+				}
+			}
+			if parent != nil && !slices.Contains(parent.Children, node) {
+				// A card previously seen as a root may have gained a known parent
+				parent.Children = append(parent.Children, node)
+				roots = slices.DeleteFunc(roots, func(root *ForkNode) bool { return root == node })
+			}
+			parent = node
+		}
+	}
+
+	// Return the trees
+	return roots, errors.Join(errs...)
+}
+
+// taskOfFork creates the task of the parent card
+func (r *Router) taskOfFork(fork *models.ForkInfo) (task.Task, bool) {
+	if fork.Source != "" && fork.CharacterID != "" {
+		if parentTask, ok := r.TaskOfSource(fork.Source, fork.CharacterID); ok {
+			return parentTask, true
+		}
+	}
+	if fork.URL != "" {
+		return r.TaskOf(fork.URL)
+	}
+	return nil, false
+}
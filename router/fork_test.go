@@ -0,0 +1,118 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/imroc/req/v3"
+	"github.com/r3dpixel/card-fetcher/fetcher"
+	"github.com/r3dpixel/card-fetcher/impl"
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-fetcher/source"
+	"github.com/r3dpixel/toolkit/reqx"
+	"github.com/stretchr/testify/assert"
+)
+
+// forkFetcher serves the card info of several cards (by character ID)
+type forkFetcher struct {
+	fetcher.Fetcher
+	cards map[string]models.CardInfo
+}
+
+// FetchCardInfo returns the card info of the requested card
+func (f *forkFetcher) FetchCardInfo(metadataBinder *fetcher.MetadataBinder) (*models.CardInfo, error) {
+	cardInfo := f.cards[metadataBinder.CharacterID]
+	return &cardInfo, nil
+}
+
+// newForkRouter creates a router serving the given cards (the parent of each card is given by character ID)
+func newForkRouter(parents map[string]string) *Router {
+	siteA := source.ID("site-a")
+	response := &req.Response{}
+	response.SetBodyString(`{}`)
+	mockFetcher := impl.NewMockFetcher(impl.MockConfig{
+		MockSourceID:  siteA,
+		MockDomain:    "site-a.com",
+		MockDirectURL: "site-a.com/",
+	}, impl.MockData{Response: response, CreatorInfo: &models.CreatorInfo{Nickname: "Creator"}})
+
+	cards := map[string]models.CardInfo{}
+	for characterID, parentID := range parents {
+		cardInfo := models.CardInfo{NormalizedURL: "site-a.com/" + characterID, CharacterID: characterID, Title: characterID}
+		if parentID != "" {
+			cardInfo.IsForked = true
+			cardInfo.Fork = &models.ForkInfo{Source: siteA, CharacterID: parentID}
+		}
+		cards[characterID] = cardInfo
+	}
+
+	router := New(reqx.Options{})
+	router.RegisterFetcher(&forkFetcher{Fetcher: mockFetcher, cards: cards})
+	return router
+}
+
+func TestRouter_ForkLineage(t *testing.T) {
+	router := newForkRouter(map[string]string{
+		"original": "",
+		"fork":     "original",
+		"fork2":    "fork",
+		"loop1":    "loop2",
+		"loop2":    "loop1",
+	})
+
+	t.Run("should follow the parents up to the original", func(t *testing.T) {
+		lineage, err := router.ForkLineage("https://site-a.com/fork2", 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"fork2", "fork", "original"}, titles(lineage))
+	})
+
+	t.Run("should stop at the maximum depth", func(t *testing.T) {
+		lineage, err := router.ForkLineage("https://site-a.com/fork2", 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"fork2", "fork"}, titles(lineage))
+	})
+
+	t.Run("should detect cycles", func(t *testing.T) {
+		lineage, err := router.ForkLineage("https://site-a.com/loop1", 0)
+
+		assert.ErrorIs(t, err, ErrForkCycle)
+		assert.Equal(t, []string{"loop1", "loop2"}, titles(lineage))
+	})
+
+	t.Run("should reject unknown URLs", func(t *testing.T) {
+		_, err := router.ForkLineage("https://unknown.com/card", 0)
+		assert.Error(t, err)
+	})
+}
+
+func TestRouter_ForkTree(t *testing.T) {
+	router := newForkRouter(map[string]string{
+		"original": "",
+		"forkA":    "original",
+		"forkB":    "original",
+		"forkA2":   "forkA",
+		"other":    "",
+	})
+
+	roots, err := router.ForkTree("https://site-a.com/original", "https://site-a.com/forkA2", "https://site-a.com/forkB", "https://site-a.com/other")
+
+	assert.NoError(t, err)
+	assert.Len(t, roots, 2)
+	assert.Equal(t, "original", roots[0].Metadata.Title)
+	assert.Equal(t, "other", roots[1].Metadata.Title)
+	if assert.Len(t, roots[0].Children, 2) {
+		assert.Equal(t, "forkA", roots[0].Children[0].Metadata.Title)
+		assert.Equal(t, "forkB", roots[0].Children[1].Metadata.Title)
+		assert.Equal(t, "forkA2", roots[0].Children[0].Children[0].Metadata.Title)
+	}
+}
+
+// titles returns the titles of the cards
+func titles(lineage []*models.Metadata) []string {
+	result := make([]string, len(lineage))
+	for index, metadata := range lineage {
+		result[index] = metadata.Title
+	}
+	return result
+}
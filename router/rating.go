@@ -0,0 +1,28 @@
+package router
+
+import (
+	"errors"
+
+	"github.com/r3dpixel/card-fetcher/fetcher"
+	"github.com/r3dpixel/card-fetcher/task"
+)
+
+// FilterByRating keeps the tasks whose card rating is accepted by the filter (the metadata of each task is fetched)
+// Tasks failing to fetch their metadata are dropped and their errors joined; rejected ratings are not errors
+func FilterByRating(tasks []task.Task, filter *fetcher.RatingFilter) ([]task.Task, error) {
+	var kept []task.Task
+	var errs []error
+	for _, t := range tasks {
+		metadata, err := t.FetchMetadata()
+		switch {
+		case fetcher.GetErrCode(err) == fetcher.RatingErr:
+			// Rejected by the rating filter of the task itself
+			continue
+		case err != nil:
+			errs = append(errs, err)
+		case filter.Accepts(metadata.Rating):
+			kept = append(kept, t)
+		}
+	}
+	return kept, errors.Join(errs...)
+}
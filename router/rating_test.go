@@ -0,0 +1,87 @@
+package router
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/imroc/req/v3"
+	"github.com/r3dpixel/card-fetcher/fetcher"
+	"github.com/r3dpixel/card-fetcher/impl"
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-fetcher/source"
+	"github.com/r3dpixel/card-fetcher/task"
+	"github.com/r3dpixel/toolkit/reqx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ratedFetcher serves the card info of several cards with their ratings (by character ID)
+type ratedFetcher struct {
+	fetcher.Fetcher
+	ratings map[string]models.Rating
+}
+
+// FetchCardInfo returns the card info of the requested card (unknown cards fail)
+func (f *ratedFetcher) FetchCardInfo(metadataBinder *fetcher.MetadataBinder) (*models.CardInfo, error) {
+	rating, ok := f.ratings[metadataBinder.CharacterID]
+	if !ok {
+		return nil, errors.New("card not found")
+	}
+	return &models.CardInfo{CharacterID: metadataBinder.CharacterID, Title: metadataBinder.CharacterID, Rating: rating}, nil
+}
+
+func TestRouter_Rating(t *testing.T) {
+	newRouter := func() *Router {
+		response := &req.Response{}
+		response.SetBodyString(`{}`)
+		mockFetcher := impl.NewMockFetcher(impl.MockConfig{
+			MockSourceID:  source.ID("site-a"),
+			MockDomain:    "site-a.com",
+			MockDirectURL: "site-a.com/",
+		}, impl.MockData{Response: response, CreatorInfo: &models.CreatorInfo{Nickname: "Creator"}})
+
+		router := New(reqx.Options{})
+		router.RegisterFetcher(&ratedFetcher{Fetcher: mockFetcher, ratings: map[string]models.Rating{
+			"sfw":     models.RatingSFW,
+			"nsfw":    models.RatingNSFW,
+			"nsfl":    models.RatingNSFL,
+			"unrated": models.RatingUnknown,
+		}})
+		return router
+	}
+	urls := []string{"https://site-a.com/sfw", "https://site-a.com/nsfw", "https://site-a.com/nsfl", "https://site-a.com/unrated", "https://site-a.com/missing"}
+
+	t.Run("should filter the tasks by rating", func(t *testing.T) {
+		router := newRouter()
+
+		kept, err := FilterByRating(router.TaskSliceOf(urls...).Tasks, &fetcher.RatingFilter{Max: models.RatingNSFW, RejectUnknown: true})
+
+		assert.Error(t, err, "Tasks failing to fetch their metadata are reported")
+		assert.Equal(t, []string{"sfw", "nsfw"}, characterIDs(kept))
+	})
+
+	t.Run("should reject the tasks through the router filter", func(t *testing.T) {
+		router := newRouter()
+		router.SetRatingFilter(&fetcher.RatingFilter{Max: models.RatingSuggestive})
+		require.NotNil(t, router.RatingFilter())
+
+		nsflTask, ok := router.TaskOf("https://site-a.com/nsfl")
+		require.True(t, ok)
+		_, err := nsflTask.FetchMetadata()
+		assert.Equal(t, fetcher.RatingErr, fetcher.GetErrCode(err))
+
+		kept, err := FilterByRating(router.TaskSliceOf(urls[:4]...).Tasks, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"sfw", "unrated"}, characterIDs(kept))
+	})
+}
+
+// characterIDs returns the character IDs of the tasks
+func characterIDs(tasks []task.Task) []string {
+	ids := make([]string, len(tasks))
+	for index, t := range tasks {
+		metadata, _ := t.FetchMetadata()
+		ids[index] = metadata.CharacterID
+	}
+	return ids
+}
@@ -3,22 +3,31 @@ package router
 import (
 	"context"
 	"maps"
+	"reflect"
 	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/r3dpixel/card-fetcher/asset"
+	"github.com/r3dpixel/card-fetcher/avatar"
+	"github.com/r3dpixel/card-fetcher/export"
 	"github.com/r3dpixel/card-fetcher/fetcher"
 	"github.com/r3dpixel/card-fetcher/impl"
+	"github.com/r3dpixel/card-fetcher/lint"
+	"github.com/r3dpixel/card-fetcher/models"
 	"github.com/r3dpixel/card-fetcher/snapshots"
 	"github.com/r3dpixel/card-fetcher/source"
 	"github.com/r3dpixel/card-fetcher/task"
+	"github.com/r3dpixel/card-fetcher/tokenizer"
+	"github.com/r3dpixel/card-fetcher/tombstone"
 	"github.com/r3dpixel/card-parser/png"
 	"github.com/r3dpixel/toolkit/cred"
 	"github.com/r3dpixel/toolkit/iterx"
 	"github.com/r3dpixel/toolkit/lexer"
 	"github.com/r3dpixel/toolkit/reqx"
 	"github.com/r3dpixel/toolkit/scheduler"
+	"github.com/r3dpixel/toolkit/stringsx"
 	"github.com/r3dpixel/toolkit/symbols"
 )
 
@@ -41,11 +50,60 @@ const (
 	IntegrationSuccess       IntegrationStatus = "INTEGRATION SUCCESS"
 )
 
+// Integration validation rules (in addition to the metadata validation rules)
+const (
+	RuleCardIntegrity            models.RuleID = "card.integrity"
+	RuleEmbeddedMetadataMismatch models.RuleID = "card.embedded_metadata.mismatch"
+	RuleSnapshotMismatch         models.RuleID = "card.snapshot.mismatch"
+)
+
+// IntegrationReport represents the detailed outcome of a source integration check
+type IntegrationReport struct {
+	SourceID  source.ID
+	Status    IntegrationStatus
+	Resources []ResourceReport
+	Err       error
+}
+
+// ResourceReport represents the outcome of the integration check of a single resource URL
+type ResourceReport struct {
+	Index      int
+	URL        string
+	Status     IntegrationStatus
+	Validation *models.Report
+	Err        error
+}
+
+// String returns a readable representation of the integration report
+func (r IntegrationReport) String() string {
+	var builder strings.Builder
+	builder.WriteString(string(r.SourceID) + ": " + string(r.Status))
+	if r.Err != nil {
+		builder.WriteString(" (" + r.Err.Error() + ")")
+	}
+	for _, resource := range r.Resources {
+		if resource.Status == IntegrationSuccess {
+			continue
+		}
+		builder.WriteString("\n  " + resource.URL + ": " + string(resource.Status))
+		if resource.Err != nil {
+			builder.WriteString(" (" + resource.Err.Error() + ")")
+		}
+		if resource.Validation != nil {
+			for _, violation := range resource.Validation.Violations {
+				builder.WriteString("\n    " + violation.String())
+			}
+		}
+	}
+	return builder.String()
+}
+
 // TaskBucket represents a collection of tasks for a given set of URLs (indexed by normalized URL)
 type TaskBucket struct {
 	Tasks       map[string]task.Task
 	ValidURLs   []string
 	InvalidURLs []string
+	RemovedURLs []string
 }
 
 // TaskSlice represents a collection of tasks
@@ -53,6 +111,7 @@ type TaskSlice struct {
 	Tasks       []task.Task
 	ValidURLs   []string
 	InvalidURLs []string
+	RemovedURLs []string
 }
 
 // lexResult represents a result from the lexer trie
@@ -66,15 +125,27 @@ type integrationCheckParams struct {
 	sourceID    source.ID
 	index       int
 	resourceURL string
-	resultCh    chan<- IntegrationStatus
+	resultCh    chan<- ResourceReport
 }
 
 // Router routes URLs to fetchers
 type Router struct {
-	client    *reqx.Client
-	lex       *lexer.Lexer[rune, lexResult]
-	fetchers  map[source.ID]fetcher.Fetcher
-	fetcherMu sync.RWMutex
+	client           *reqx.Client
+	lex              *lexer.Lexer[rune, lexResult]
+	fetchers         map[source.ID]fetcher.Fetcher
+	tombstones       *tombstone.Registry
+	linter           *lint.Linter
+	tagPolicy        *fetcher.TagPolicy
+	tokenizer        tokenizer.Tokenizer
+	ratingClassifier *fetcher.RatingClassifier
+	ratingFilter     *fetcher.RatingFilter
+	patcher          fetcher.Patcher
+	htmlConversion   *fetcher.HTMLConversion
+	assetEmbedder    *asset.Embedder
+	avatarOptions    *avatar.Options
+	bookStrategy     fetcher.BookStrategy
+	cardVersion      export.Version
+	fetcherMu        sync.RWMutex
 }
 
 // EnvConfigured creates a new router with default builders configured for environment variables
@@ -161,6 +232,135 @@ func (r *Router) unsafeRegisterFetcher(fetcher fetcher.Fetcher) {
 	}
 }
 
+// EnableTombstones enables the tracking of removed cards, using the given registry (nil creates a default registry)
+// Cards confirmed removed enough times are no longer fetched
+func (r *Router) EnableTombstones(registry *tombstone.Registry) *tombstone.Registry {
+	// Create a default registry if needed
+	if registry == nil {
+		registry = tombstone.New(tombstone.DefaultConfirmations)
+	}
+	// Set the registry
+	r.tombstones = registry
+	// Return the registry
+	return registry
+}
+
+// Tombstones returns the registry tracking removed cards (nil if not enabled)
+func (r *Router) Tombstones() *tombstone.Registry {
+	return r.tombstones
+}
+
+// EnableLinter enables the linting of the patched character cards (available through Task.Lint)
+func (r *Router) EnableLinter(linter *lint.Linter) {
+	r.linter = linter
+}
+
+// Linter returns the linter of the character cards (nil if not enabled)
+func (r *Router) Linter() *lint.Linter {
+	return r.linter
+}
+
+// SetTagPolicy sets the tag policy applied to the tasks (nil keeps all tags)
+func (r *Router) SetTagPolicy(policy *fetcher.TagPolicy) {
+	r.tagPolicy = policy
+}
+
+// TagPolicy returns the tag policy applied to the tasks (nil if not set)
+func (r *Router) TagPolicy() *fetcher.TagPolicy {
+	return r.tagPolicy
+}
+
+// SetRatingClassifier sets the rules rating the cards without a platform rating (nil uses fetcher.DefaultRatingClassifier)
+func (r *Router) SetRatingClassifier(classifier *fetcher.RatingClassifier) {
+	r.ratingClassifier = classifier
+}
+
+// RatingClassifier returns the rules rating the cards without a platform rating (nil if not set)
+func (r *Router) RatingClassifier() *fetcher.RatingClassifier {
+	return r.ratingClassifier
+}
+
+// SetRatingFilter sets the content ratings accepted by the tasks (nil accepts all ratings)
+func (r *Router) SetRatingFilter(filter *fetcher.RatingFilter) {
+	r.ratingFilter = filter
+}
+
+// RatingFilter returns the content ratings accepted by the tasks (nil if not set)
+func (r *Router) RatingFilter() *fetcher.RatingFilter {
+	return r.ratingFilter
+}
+
+// SetTokenizer sets the tokenizer estimating the token counts of the cards (nil uses tokenizer.Default)
+func (r *Router) SetTokenizer(tokenizer tokenizer.Tokenizer) {
+	r.tokenizer = tokenizer
+}
+
+// Tokenizer returns the tokenizer estimating the token counts of the cards (nil if not set)
+func (r *Router) Tokenizer() tokenizer.Tokenizer {
+	return r.tokenizer
+}
+
+// SetPatcher sets the patcher applied to the character cards (nil uses fetcher.DefaultPipeline with the tag policy)
+// A fetcher.Pipeline without its own tag policy filters the tags with the tag policy of the router
+func (r *Router) SetPatcher(patcher fetcher.Patcher) {
+	r.patcher = patcher
+}
+
+// Patcher returns the patcher applied to the character cards (nil if not set)
+func (r *Router) Patcher() fetcher.Patcher {
+	return r.patcher
+}
+
+// SetHTMLConversion sets the conversion of the platform HTML of the taglines and creator notes (nil keeps the HTML)
+func (r *Router) SetHTMLConversion(conversion *fetcher.HTMLConversion) {
+	r.htmlConversion = conversion
+}
+
+// HTMLConversion returns the conversion of the platform HTML of the taglines and creator notes (nil if not set)
+func (r *Router) HTMLConversion() *fetcher.HTMLConversion {
+	return r.htmlConversion
+}
+
+// SetAssetEmbedder sets the embedder of the images referenced in the character cards (nil disables the embedding)
+func (r *Router) SetAssetEmbedder(embedder *asset.Embedder) {
+	r.assetEmbedder = embedder
+}
+
+// AssetEmbedder returns the embedder of the images referenced in the character cards (nil if not set)
+func (r *Router) AssetEmbedder() *asset.Embedder {
+	return r.assetEmbedder
+}
+
+// SetAvatarOptions sets the processing of the avatars (resize, re-encoding and thumbnails, nil disables the processing)
+func (r *Router) SetAvatarOptions(options *avatar.Options) {
+	r.avatarOptions = options
+}
+
+// AvatarOptions returns the processing of the avatars (nil if not set)
+func (r *Router) AvatarOptions() *avatar.Options {
+	return r.avatarOptions
+}
+
+// SetBookStrategy sets the strategy for merging the books of the cards (fetcher.BookStrategyMerge by default)
+func (r *Router) SetBookStrategy(strategy fetcher.BookStrategy) {
+	r.bookStrategy = strategy
+}
+
+// BookStrategy returns the strategy for merging the books of the cards
+func (r *Router) BookStrategy() fetcher.BookStrategy {
+	return r.bookStrategy
+}
+
+// SetCardVersion sets the specification version of the cards exported by the tasks (export.VersionKeep by default)
+func (r *Router) SetCardVersion(version export.Version) {
+	r.cardVersion = version
+}
+
+// CardVersion returns the specification version of the cards exported by the tasks
+func (r *Router) CardVersion() export.Version {
+	return r.cardVersion
+}
+
 // Sources returns the list of sources registered with the router
 func (r *Router) Sources() []source.ID {
 	// Lock fetchers map for reading
@@ -193,6 +393,11 @@ func (r *Router) TaskMapOf(urls ...string) TaskBucket {
 		if fetcherTask, ok := r.TaskOf(url); ok {
 			// Get the normalized URL
 			normalizedURL := fetcherTask.NormalizedURL()
+			// Skip the tasks for cards confirmed removed
+			if r.isBuried(normalizedURL) {
+				container.RemovedURLs = append(container.RemovedURLs, normalizedURL)
+				continue
+			}
 			// Add the task to the map
 			container.Tasks[normalizedURL] = fetcherTask
 			// Add the normalized URL to the list of valid URLs
@@ -214,6 +419,11 @@ func (r *Router) TaskSliceOf(urls ...string) TaskSlice {
 	for _, url := range urls {
 		// Try to create a task for the URL
 		if fetcherTask, ok := r.TaskOf(url); ok {
+			// Skip the tasks for cards confirmed removed
+			if r.isBuried(fetcherTask.NormalizedURL()) {
+				container.RemovedURLs = append(container.RemovedURLs, fetcherTask.NormalizedURL())
+				continue
+			}
 			// Add the task to the slice
 			container.Tasks = append(container.Tasks, fetcherTask)
 			// Add the normalized URL to the list of valid URLs
@@ -271,11 +481,57 @@ func (r *Router) TaskOf(url string) (task.Task, bool) {
 	}
 
 	// Return nil and false if no base URL matched the URL
-	return task.New(match.fetcher, url, url[domainEnd+len(match.baseURL.Path):]), true
+	return task.NewWithOptions(match.fetcher, url, url[domainEnd+len(match.baseURL.Path):], r.taskOptions()), true
 }
 
-// CheckIntegration checks the integration status of a given source
+// TaskOfSource tries to create a task for the given character ID using the fetcher registered for the source
+func (r *Router) TaskOfSource(sourceID source.ID, characterID string) (task.Task, bool) {
+	// Lock fetchers map for reading
+	r.fetcherMu.RLock()
+	f, ok := r.fetchers[sourceID]
+	r.fetcherMu.RUnlock()
+
+	// If no fetcher is registered for the source, or the character ID is blank, return nil and false
+	if !ok || stringsx.IsBlank(characterID) {
+		return nil, false
+	}
+
+	// Return the task for the direct URL of the character
+	return task.NewWithOptions(f, f.DirectURL(characterID), characterID, r.taskOptions()), true
+}
+
+// taskOptions returns the task options configured on the router
+func (r *Router) taskOptions() task.Options {
+	return task.Options{
+		Tombstones:       r.tombstones,
+		Linter:           r.linter,
+		TagPolicy:        r.tagPolicy,
+		Tokenizer:        r.tokenizer,
+		RatingClassifier: r.ratingClassifier,
+		RatingFilter:     r.ratingFilter,
+		Patcher:          r.patcher,
+		HTMLConversion:   r.htmlConversion,
+		Assets:           r.assetEmbedder,
+		Avatar:           r.avatarOptions,
+		BookStrategy:     r.bookStrategy,
+		Version:          r.cardVersion,
+	}
+}
+
+// isBuried checks if the card was confirmed removed enough times to stop fetching it
+func (r *Router) isBuried(normalizedURL string) bool {
+	return r.tombstones != nil && r.tombstones.IsBuried(normalizedURL)
+}
+
+// CheckIntegration checks the integration status of a given source (see CheckIntegrationReport for the details)
 func (r *Router) CheckIntegration(sourceID source.ID) IntegrationStatus {
+	return r.CheckIntegrationReport(sourceID).Status
+}
+
+// CheckIntegrationReport checks the integration of a given source, reporting the outcome of each resource
+func (r *Router) CheckIntegrationReport(sourceID source.ID) IntegrationReport {
+	report := IntegrationReport{SourceID: sourceID}
+
 	// Get fetcher
 	r.fetcherMu.RLock()
 	f, ok := r.fetchers[sourceID]
@@ -283,27 +539,32 @@ func (r *Router) CheckIntegration(sourceID source.ID) IntegrationStatus {
 
 	// If the fetcher is not found, return MISSING FETCHER
 	if !ok {
-		return MissingFetcher
+		report.Status = MissingFetcher
+		return report
 	}
 
 	// Check if the source is up
 	if err := f.IsSourceUp(); err != nil {
+		report.Err = err
 		// Return INVALID CREDENTIALS if there was a credential error
 		if fetcher.GetErrCode(err) == fetcher.InvalidCredentialsErr {
-			return InvalidCredentials
+			report.Status = InvalidCredentials
+			return report
 		}
 		// Otherwise, return SOURCE DOWN
-		return SourceDown
+		report.Status = SourceDown
+		return report
 	}
 
 	// Get resource URLs
 	resourceURLs, ok := snapshots.GetResourceURLs(sourceID)
 	if !ok || len(resourceURLs) == 0 {
-		return MismatchedRemoteResource
+		report.Status = MismatchedRemoteResource
+		return report
 	}
 
 	// Check each resource URL in parallel using scheduler pool
-	resultCh := make(chan IntegrationStatus, len(resourceURLs))
+	resultCh := make(chan ResourceReport, len(resourceURLs))
 
 	// If there is only one resource URL, check it synchronously
 	if len(resourceURLs) == 1 {
@@ -313,100 +574,128 @@ func (r *Router) CheckIntegration(sourceID source.ID) IntegrationStatus {
 			resourceURL: resourceURLs[0],
 			resultCh:    resultCh,
 		})
-		close(resultCh)
-		return <-resultCh
-	}
+	} else {
+		// Set the maximum number of goroutines
+		parallelism := len(resourceURLs)
+		if parallelism > defaultMaxParallelism {
+			parallelism = defaultMaxParallelism
+		}
 
-	// Set the maximum number of goroutines
-	parallelism := len(resourceURLs)
-	if parallelism > defaultMaxParallelism {
-		parallelism = defaultMaxParallelism
-	}
+		// Create a pool to check each resource URL
+		pool := scheduler.NewPool(scheduler.Options[integrationCheckParams]{
+			Handler: func(_ context.Context, p integrationCheckParams) {
+				r.checkResourceIntegration(p)
+			},
+			Parallelism: parallelism,
+		})
 
-	// Create a pool to check each resource URL
-	pool := scheduler.NewPool(scheduler.Options[integrationCheckParams]{
-		Handler: func(_ context.Context, p integrationCheckParams) {
-			r.checkResourceIntegration(p)
-		},
-		Parallelism: parallelism,
-	})
+		// Submit tasks to the pool
+		for index, resourceURL := range resourceURLs {
+			pool.Submit(integrationCheckParams{
+				sourceID:    sourceID,
+				index:       index,
+				resourceURL: resourceURL,
+				resultCh:    resultCh,
+			})
+		}
 
-	// Submit tasks to the pool
-	for index, resourceURL := range resourceURLs {
-		pool.Submit(integrationCheckParams{
-			sourceID:    sourceID,
-			index:       index,
-			resourceURL: resourceURL,
-			resultCh:    resultCh,
-		})
+		// Close the pool and wait for all tasks to finish
+		pool.Close()
 	}
-
-	// Close the pool and wait for all tasks to finish
-	pool.Close()
 	close(resultCh)
 
-	// Return first failure found, or success if all passed
-	for status := range resultCh {
-		if status != IntegrationSuccess {
-			return status
+	// Collect the resource reports (in the order of the resource URLs)
+	report.Resources = make([]ResourceReport, len(resourceURLs))
+	for resourceReport := range resultCh {
+		report.Resources[resourceReport.Index] = resourceReport
+	}
+
+	// The status is the first failure found, or success if all passed
+	report.Status = IntegrationSuccess
+	for _, resourceReport := range report.Resources {
+		if resourceReport.Status != IntegrationSuccess {
+			report.Status = resourceReport.Status
+			break
 		}
 	}
 
-	// Return INTEGRATION SUCCESS
-	return IntegrationSuccess
+	// Return the report
+	return report
 }
 
 // checkResourceIntegration checks the integration of a single resource URL
 func (r *Router) checkResourceIntegration(p integrationCheckParams) {
+	report := ResourceReport{Index: p.index, URL: p.resourceURL}
+
 	// Try to create a task for the resource URL
 	resourceTask, ok := r.TaskOf(p.resourceURL)
 	if !ok || resourceTask.SourceID() != p.sourceID {
-		p.resultCh <- MismatchedRemoteResource
+		report.Status = MismatchedRemoteResource
+		p.resultCh <- report
 		return
 	}
 
 	// Get the local card corresponding to the resource URL
 	localCard, err := snapshots.GetResourceCard(p.sourceID, p.index)
 	if err != nil {
-		p.resultCh <- MissingLocalResource
+		report.Status = MissingLocalResource
+		report.Err = err
+		p.resultCh <- report
 		return
 	}
 
 	// Check the integration of the task and the local card
-	if status := r.checkTaskIntegration(resourceTask, localCard); status != IntegrationSuccess {
-		p.resultCh <- status
-		return
-	}
-
-	// If the integration check passed, return INTEGRATION SUCCESS
-	p.resultCh <- IntegrationSuccess
+	report.Status, report.Validation, report.Err = r.checkTaskIntegration(resourceTask, localCard)
+	p.resultCh <- report
 }
 
 // checkTaskIntegration checks the integration status of a given task and local character card
-func (r *Router) checkTaskIntegration(task task.Task, localCard *png.CharacterCard) IntegrationStatus {
+func (r *Router) checkTaskIntegration(task task.Task, localCard *png.CharacterCard) (IntegrationStatus, *models.Report, error) {
 	// Fetch metadata and character card
 	metadata, characterCard, err := task.FetchAll()
 	if err != nil {
 		// Return INVALID CREDENTIALS if there was a credential error
 		if fetcher.GetErrCode(err) == fetcher.InvalidCredentialsErr {
-			return InvalidCredentials
+			return InvalidCredentials, nil, err
 		}
 		// Otherwise, return MISSING REMOTE RESOURCE
-		return MissingRemoteResource
+		return MissingRemoteResource, nil, err
 	}
 
-	// If the character card is not valid, or not consistent with the metadata, return INTEGRATION FAILURE
-	if !characterCard.Integrity() || !metadata.IsConsistentWith(characterCard.Sheet) {
-		return IntegrationFailure
+	// Validate the character card and its consistency with the metadata
+	validation := metadata.ValidateAgainst(characterCard.Sheet)
+	if !characterCard.Integrity() {
+		validation.Add(RuleCardIntegrity, models.SeverityError, "character card integrity check failed", nil)
+	}
+
+	// Validate the embedded metadata against the fetched metadata (the volatile fields are not embedded)
+	embeddedMetadata, err := models.MetadataFromSheet(characterCard.Sheet)
+	if err != nil || !reflect.DeepEqual(embeddedMetadata, metadata.Stable()) {
+		validation.Add(RuleEmbeddedMetadataMismatch, models.SeverityError, "embedded metadata does not match the fetched metadata", map[string]any{
+			"embedded": embeddedMetadata,
+			"fetched":  metadata,
+			"error":    err,
+		})
+	}
+
+	// If the character card is not valid, return INTEGRATION FAILURE
+	if !validation.Valid() {
+		return IntegrationFailure, validation, nil
 	}
 
 	// Set the local card modification date to the remote card modification date (modification date was already validated above)
 	localCard.Sheet.ModificationDate = characterCard.Sheet.ModificationDate
+	// Set the local card embedded metadata to the remote card embedded metadata (embedded metadata was already validated above)
+	if localCard.Sheet.Extensions == nil {
+		localCard.Sheet.Extensions = make(map[string]any)
+	}
+	localCard.Sheet.Extensions[models.ExtensionKey] = characterCard.Sheet.Extensions[models.ExtensionKey]
 	// If the local card is not equal to the remote card, return INTEGRATION FAILURE
 	if !localCard.Sheet.DeepEquals(characterCard.Sheet) {
-		return IntegrationFailure
+		validation.Add(RuleSnapshotMismatch, models.SeverityError, "fetched card does not match the local snapshot", nil)
+		return IntegrationFailure, validation, nil
 	}
 
 	// Return INTEGRATION SUCCESS
-	return IntegrationSuccess
+	return IntegrationSuccess, validation, nil
 }
@@ -4,8 +4,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/imroc/req/v3"
 	"github.com/r3dpixel/card-fetcher/impl"
+	"github.com/r3dpixel/card-fetcher/models"
 	"github.com/r3dpixel/card-fetcher/source"
+	"github.com/r3dpixel/card-fetcher/tombstone"
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/card-parser/png"
 	"github.com/r3dpixel/toolkit/reqx"
 	"github.com/stretchr/testify/assert"
 )
@@ -161,6 +166,113 @@ func TestRouter_TaskDispatching(t *testing.T) {
 	})
 }
 
+func TestRouter_Tombstones(t *testing.T) {
+	siteA := source.ID("site-a")
+
+	mockFetcher := impl.NewMockFetcher(impl.MockConfig{
+		MockSourceID:  siteA,
+		MockDomain:    "site-a.com",
+		MockDirectURL: "direct.site-a.com/",
+	}, impl.MockData{})
+
+	router := New(reqx.Options{})
+	router.RegisterFetchers(mockFetcher)
+
+	assert.Nil(t, router.Tombstones())
+	registry := router.EnableTombstones(nil)
+	assert.Same(t, registry, router.Tombstones())
+	assert.Equal(t, tombstone.DefaultConfirmations, registry.Confirmations())
+
+	for range registry.Confirmations() {
+		registry.Bury(siteA, "site-a.com/char/1")
+	}
+
+	urls := []string{
+		"https://site-a.com/char/1",
+		"https://site-a.com/char/2",
+	}
+
+	t.Run("TaskMapOf", func(t *testing.T) {
+		bucket := router.TaskMapOf(urls...)
+
+		assert.Len(t, bucket.Tasks, 1)
+		assert.Contains(t, bucket.Tasks, "site-a.com/char/2")
+		assert.Equal(t, []string{"site-a.com/char/1"}, bucket.RemovedURLs)
+	})
+
+	t.Run("TaskSliceOf", func(t *testing.T) {
+		slice := router.TaskSliceOf(urls...)
+
+		assert.Len(t, slice.Tasks, 1)
+		assert.Equal(t, "site-a.com/char/2", slice.Tasks[0].NormalizedURL())
+		assert.Equal(t, []string{"site-a.com/char/1"}, slice.RemovedURLs)
+	})
+}
+
+func TestRouter_CheckIntegrationReport(t *testing.T) {
+	t.Run("Missing fetcher", func(t *testing.T) {
+		report := New(reqx.Options{}).CheckIntegrationReport(source.ID("missing"))
+
+		assert.Equal(t, MissingFetcher, report.Status)
+		assert.Empty(t, report.Resources)
+	})
+
+	t.Run("Validation report", func(t *testing.T) {
+		response := &req.Response{}
+		response.SetBodyString(`{}`)
+		mockFetcher := impl.NewMockFetcher(impl.MockConfig{
+			MockSourceID:  source.ID("site-a"),
+			MockDomain:    "site-a.com",
+			MockDirectURL: "site-a.com",
+		}, impl.MockData{
+			Response:      response,
+			CardInfo:      &models.CardInfo{Name: "Name", Title: "Title", CharacterID: "1"},
+			CreatorInfo:   &models.CreatorInfo{Nickname: "Creator"},
+			CharacterCard: &png.CharacterCard{Sheet: character.DefaultSheet(character.RevisionV2)},
+		})
+		router := New(reqx.Options{})
+		router.RegisterFetchers(mockFetcher)
+		resourceTask, ok := router.TaskOf("https://site-a.com/1")
+		assert.True(t, ok)
+
+		status, validation, err := router.checkTaskIntegration(resourceTask, &png.CharacterCard{Sheet: character.DefaultSheet(character.RevisionV2)})
+
+		assert.NoError(t, err)
+		assert.Equal(t, IntegrationFailure, status)
+		assert.False(t, validation.Valid())
+		assert.True(t, validation.Has(models.RuleCreateTimeMissing))
+		assert.True(t, validation.Has(models.RuleCardPlatformIDBlank))
+		assert.False(t, validation.Has(RuleEmbeddedMetadataMismatch))
+	})
+
+	t.Run("Embedded metadata without the volatile fields", func(t *testing.T) {
+		response := &req.Response{}
+		response.SetBodyString(`{}`)
+		likes := int64(12)
+		mockFetcher := impl.NewMockFetcher(impl.MockConfig{
+			MockSourceID:  source.ID("site-a"),
+			MockDomain:    "site-a.com",
+			MockDirectURL: "site-a.com",
+		}, impl.MockData{
+			Response:       response,
+			CardInfo:       &models.CardInfo{Name: "Name", Title: "Title", CharacterID: "1"},
+			CreatorInfo:    &models.CreatorInfo{Nickname: "Creator"},
+			Stats:          &models.Stats{Likes: &likes},
+			AvatarFallback: models.AvatarFallbackGenerated,
+			CharacterCard:  &png.CharacterCard{Sheet: character.DefaultSheet(character.RevisionV2)},
+		})
+		router := New(reqx.Options{})
+		router.RegisterFetchers(mockFetcher)
+		resourceTask, ok := router.TaskOf("https://site-a.com/1")
+		assert.True(t, ok)
+
+		_, validation, err := router.checkTaskIntegration(resourceTask, &png.CharacterCard{Sheet: character.DefaultSheet(character.RevisionV2)})
+
+		assert.NoError(t, err)
+		assert.False(t, validation.Has(RuleEmbeddedMetadataMismatch))
+	})
+}
+
 func TestRouter_Integrations(t *testing.T) {
 	r := EnvConfigured(nil)
 
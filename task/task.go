@@ -1,14 +1,26 @@
 package task
 
 import (
+	"fmt"
+	"slices"
 	"sync"
+	"time"
 
+	"github.com/r3dpixel/card-fetcher/asset"
+	"github.com/r3dpixel/card-fetcher/avatar"
+	"github.com/r3dpixel/card-fetcher/export"
 	"github.com/r3dpixel/card-fetcher/fetcher"
+	"github.com/r3dpixel/card-fetcher/lint"
 	"github.com/r3dpixel/card-fetcher/models"
 	"github.com/r3dpixel/card-fetcher/source"
+	"github.com/r3dpixel/card-fetcher/tokenizer"
+	"github.com/r3dpixel/card-fetcher/tombstone"
+	"github.com/r3dpixel/card-parser/character"
 	"github.com/r3dpixel/card-parser/png"
 	"github.com/r3dpixel/toolkit/reqx"
+	"github.com/r3dpixel/toolkit/timestamp"
 	"github.com/r3dpixel/toolkit/trace"
+	"github.com/rs/zerolog/log"
 )
 
 // Task represents a single fetcher task
@@ -25,6 +37,46 @@ type Task interface {
 	FetchCharacterCard() (*png.CharacterCard, error)
 	// FetchAll fetches all the data from the source
 	FetchAll() (*models.Metadata, *png.CharacterCard, error)
+	// Lint checks the content of the character card (nil result if no linter is configured)
+	Lint() (*lint.Result, error)
+	// Assets returns the assets embedded from the text of the character card (nil bundle if no embedder is configured)
+	Assets() (*asset.Bundle, error)
+	// Avatar resizes and re-encodes the avatar of the character card (nil result if no avatar options are configured)
+	Avatar() (*avatar.Result, error)
+	// Export encodes the character card as a PNG card in the configured version (V3 cards get the platform fields and the embedded assets)
+	Export() ([]byte, error)
+	// WorldBooks returns the linked books kept out of the character book (fetcher.BookStrategySeparate only)
+	WorldBooks() ([]*character.Book, error)
+}
+
+// Options for configuring a task
+type Options struct {
+	// Tombstones records the cards removed from their source (optional)
+	Tombstones *tombstone.Registry
+	// Linter checks the content of the patched character card (optional)
+	Linter *lint.Linter
+	// TagPolicy filters the tags of the card and rejects blocked cards (optional)
+	TagPolicy *fetcher.TagPolicy
+	// RatingClassifier rates the cards without a platform rating (optional, defaults to fetcher.DefaultRatingClassifier)
+	RatingClassifier *fetcher.RatingClassifier
+	// RatingFilter rejects the cards with unwanted content ratings (optional, the known ratings are checked by the metadata flow,
+	// the final rating completed with the card data by the character card flow)
+	RatingFilter *fetcher.RatingFilter
+	// Tokenizer estimates the token counts of the patched character card (optional, defaults to tokenizer.Default)
+	Tokenizer tokenizer.Tokenizer
+	// Patcher patches the fetched character card (optional, defaults to fetcher.DefaultPipeline with the tag policy)
+	// A fetcher.Pipeline without its own tag policy filters the tags with TagPolicy, other patchers ignore it (except Reject)
+	Patcher fetcher.Patcher
+	// HTMLConversion converts the platform HTML of the taglines and creator notes (optional, nil keeps the HTML)
+	HTMLConversion *fetcher.HTMLConversion
+	// Assets embeds the images referenced in the text of the patched character card (optional)
+	Assets *asset.Embedder
+	// Avatar resizes and re-encodes the avatar of the patched character card, and produces thumbnails (optional)
+	Avatar *avatar.Options
+	// BookStrategy merges the books of the card into its character book (optional, defaults to fetcher.BookStrategyMerge)
+	BookStrategy fetcher.BookStrategy
+	// Version is the specification version of the exported cards (optional, defaults to export.VersionKeep)
+	Version export.Version
 }
 
 // task represents a single fetcher task
@@ -35,6 +87,21 @@ type task struct {
 	// fetchCharacterCard closure (executes the character card flow)
 	fetchCharacterCard func() (*png.CharacterCard, error)
 
+	// lint closure (executes the lint flow)
+	lint func() (*lint.Result, error)
+
+	// assets closure (returns the assets embedded by the character card flow)
+	assets func() (*asset.Bundle, error)
+
+	// avatar closure (executes the avatar flow)
+	avatar func() (*avatar.Result, error)
+
+	// export closure (executes the export flow)
+	export func() ([]byte, error)
+
+	// worldBooks closure (returns the books kept separate by the character card flow)
+	worldBooks func() ([]*character.Book, error)
+
 	sourceID      source.ID
 	originalURL   string
 	normalizedURL string
@@ -43,6 +110,11 @@ type task struct {
 
 // New creates a new Task instance with the given fetcher and appropriate URLs
 func New(f fetcher.Fetcher, url, rawCharacterID string) Task {
+	return NewWithOptions(f, url, rawCharacterID, Options{})
+}
+
+// NewWithOptions creates a new Task instance with the given fetcher, appropriate URLs and options
+func NewWithOptions(f fetcher.Fetcher, url, rawCharacterID string, opts Options) Task {
 	// Extract characterID
 	characterID := f.CharacterID(rawCharacterID)
 	// Extract normalizedURL
@@ -50,23 +122,73 @@ func New(f fetcher.Fetcher, url, rawCharacterID string) Task {
 
 	// Create the binder flow closure (executed once and cached)
 	binderFlow := sync.OnceValues(func() (*fetcher.Binder, error) {
-		return executeBinderFlow(f, characterID)
+		return executeBinderFlow(f, characterID, normalizedURL, opts)
 	})
 
 	// Create the metadata flow closure (executed once and cached)
 	metadataFlow := sync.OnceValues(func() (*models.Metadata, error) {
-		return executeMetadataFlow(f, binderFlow)
+		return executeMetadataFlow(f, binderFlow, opts)
 	})
 
-	// Create the character card flow closure (executed once and cached)
+	// Create the character card flow closure (executed once and cached, embeds the assets of the patched card)
+	var bundle *asset.Bundle
+	var cardFields *export.Fields
+	var worldBooks []*character.Book
 	characterCardFlow := sync.OnceValues(func() (*png.CharacterCard, error) {
-		return executeCharacterCardFlow(f, binderFlow, metadataFlow)
+		card, metadata, err := executeCharacterCardFlow(f, binderFlow, metadataFlow, opts)
+		if err != nil {
+			return nil, err
+		}
+		cardFields = exportFields(card, metadata)
+		worldBooks = card.WorldBooks
+		bundle = executeAssetsFlow(card.CharacterCard, f.SourceID(), opts)
+		return card.CharacterCard, nil
 	})
 
+	// Create the assets closure (the bundle is set once the character card flow completes)
+	assetsFlow := func() (*asset.Bundle, error) {
+		if _, err := characterCardFlow(); err != nil {
+			return nil, err
+		}
+		return bundle, nil
+	}
+
+	// Create the lint flow closure (executed once and cached)
+	lintFlow := sync.OnceValues(func() (*lint.Result, error) {
+		return executeLintFlow(characterCardFlow, opts)
+	})
+
+	// Create the avatar flow closure (executed once and cached)
+	avatarFlow := sync.OnceValues(func() (*avatar.Result, error) {
+		return executeAvatarFlow(characterCardFlow, opts)
+	})
+
+	// Create the export flow closure (executed once and cached)
+	exportFlow := sync.OnceValues(func() ([]byte, error) {
+		characterCard, err := characterCardFlow()
+		if err != nil {
+			return nil, err
+		}
+		return executeExportFlow(characterCard, bundle, cardFields, opts)
+	})
+
+	// Create the world books closure (the books are set once the character card flow completes)
+	worldBooksFlow := func() ([]*character.Book, error) {
+		if _, err := characterCardFlow(); err != nil {
+			return nil, err
+		}
+		return worldBooks, nil
+	}
+
 	// Return the task instance
 	return &task{
 		fetchMetadata:      metadataFlow,
 		fetchCharacterCard: characterCardFlow,
+		lint:               lintFlow,
+		assets:             assetsFlow,
+		avatar:             avatarFlow,
+		export:             exportFlow,
+		worldBooks:         worldBooksFlow,
 
 		sourceID:      f.SourceID(),
 		originalURL:   url,
@@ -116,10 +238,51 @@ func (t *task) FetchAll() (*models.Metadata, *png.CharacterCard, error) {
 	return metadata, characterCard, nil
 }
 
+// Lint checks the content of the character card (nil result if no linter is configured)
+func (t *task) Lint() (*lint.Result, error) {
+	return t.lint()
+}
+
+// Assets returns the assets embedded from the text of the character card (nil bundle if no embedder is configured)
+func (t *task) Assets() (*asset.Bundle, error) {
+	return t.assets()
+}
+
+// Avatar resizes and re-encodes the avatar of the character card (nil result if no avatar options are configured)
+func (t *task) Avatar() (*avatar.Result, error) {
+	return t.avatar()
+}
+
+// Export encodes the character card as a PNG card in the configured version (V3 cards get the platform fields and the embedded assets)
+func (t *task) Export() ([]byte, error) {
+	return t.export()
+}
+
+// WorldBooks returns the linked books kept out of the character book (fetcher.BookStrategySeparate only)
+func (t *task) WorldBooks() ([]*character.Book, error) {
+	return t.worldBooks()
+}
+
 // executeBinderFlow executes the binder flow
-func executeBinderFlow(f fetcher.Fetcher, characterID string) (*fetcher.Binder, error) {
+func executeBinderFlow(f fetcher.Fetcher, characterID, normalizedURL string, opts Options) (*fetcher.Binder, error) {
+	// Skip the cards confirmed removed from the source (no request is sent)
+	if opts.Tombstones != nil && opts.Tombstones.IsBuried(normalizedURL) {
+		return nil, fetcher.NewError(fmt.Errorf("tombstone found for %s", normalizedURL), fetcher.RemovedErr)
+	}
+
 	// Fetch metadata response from source
-	response, err := reqx.String(f.FetchMetadataResponse(characterID))
+	httpResponse, err := f.FetchMetadataResponse(characterID)
+	// Check if the source confirms the card was removed
+	if f.IsRemoved(httpResponse) {
+		// Record the removal confirmation
+		if opts.Tombstones != nil {
+			opts.Tombstones.Bury(f.SourceID(), normalizedURL)
+		}
+		return nil, fetcher.NewError(fmt.Errorf("removal confirmed for %s", normalizedURL), fetcher.RemovedErr)
+	}
+
+	// Read the metadata response
+	response, err := reqx.String(httpResponse, err)
 	if err != nil {
 		// Decorate error if it's not a fetcher error
 		if _, ok := err.(*trace.CodedErr[fetcher.ErrCode]); !ok {
@@ -142,13 +305,14 @@ func executeBinderFlow(f fetcher.Fetcher, characterID string) (*fetcher.Binder,
 	}
 
 	// Return binder
-	return &fetcher.Binder{MetadataBinder: *metadataBinder, BookBinder: *bookBinder}, nil
+	return &fetcher.Binder{MetadataBinder: *metadataBinder, BookBinder: *bookBinder, BookStrategy: opts.BookStrategy}, nil
 }
 
 // executeMetadataFlow executes the metadata flow
 func executeMetadataFlow(
 	f fetcher.Fetcher,
 	binderFlow func() (*fetcher.Binder, error),
+	opts Options,
 ) (*models.Metadata, error) {
 	// Execute binder flow
 	binder, err := binderFlow()
@@ -168,49 +332,201 @@ func executeMetadataFlow(
 		return nil, err
 	}
 
+	// Fetch the engagement statistics (stamped with the fetch time to track the popularity over time)
+	stats, err := f.FetchStats(&binder.MetadataBinder)
+	if err != nil {
+		return nil, err
+	}
+	stats.FetchTime = timestamp.Nano(time.Now().UnixNano())
+
 	// Create metadata
 	metadata := &models.Metadata{
 		Source:         f.SourceID(),
 		CardInfo:       *cardInfo,
 		CreatorInfo:    *creatorInfo,
-		BookUpdateTime: binder.UpdateTime,
+		BookUpdateTime: binder.BookUpdateTime(),
+		Books:          binder.Books,
 		GreetingsCount: -1,
+		Stats:          *stats,
 	}
 
 	// Patch metadata
 	fetcher.PatchMetadata(metadata)
 
+	// Convert the platform HTML of the tagline
+	opts.HTMLConversion.ConvertMetadata(metadata)
+
+	// Rate the card from the metadata (the platform rating is raised by the tags, keywords are the fallback)
+	// and reject the known filtered ratings (the unknown ratings are checked once the card data is rated)
+	metadata.Rating = opts.RatingClassifier.Rate(metadata.Rating, metadata.Tags, metadata.Title, metadata.Tagline)
+	if err := opts.RatingFilter.CheckKnown(metadata.Rating); err != nil {
+		return nil, err
+	}
+
+	// Reject the blocked cards before downloading the card data and avatar
+	// (the tags are filtered once, merged with the card tags when the sheet is patched)
+	if err := opts.TagPolicy.Check(metadata.Tags); err != nil {
+		return nil, err
+	}
+
+	// Record the last known good version of the card
+	if opts.Tombstones != nil {
+		opts.Tombstones.Remember(metadata)
+	}
+
 	// Return metadata
 	return metadata, nil
 }
 
 // executeCharacterCardFlow executes the character card flow
+// (the metadata is completed on a copy, published in a single step once the card is accepted and patched)
 func executeCharacterCardFlow(
 	f fetcher.Fetcher,
 	binderFlow func() (*fetcher.Binder, error),
 	metadataFlow func() (*models.Metadata, error),
-) (*png.CharacterCard, error) {
+	opts Options,
+) (*fetcher.Card, *models.Metadata, error) {
 	// Execute binder flow
 	binder, err := binderFlow()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Execute metadata flow
-	metadata, err := metadataFlow()
+	publishedMetadata, err := metadataFlow()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Fetch character card
-	characterCard, err := f.FetchCharacterCard(binder)
+	card, err := f.FetchCharacterCard(binder)
+	if err != nil {
+		return nil, nil, err
+	}
+	characterCard := card.CharacterCard
+
+	// Reject the cards with blocked tags only present in the card data
+	if err := opts.TagPolicy.Check(resolveTags(characterCard.Sheet.Tags)); err != nil {
+		return nil, nil, err
+	}
+
+	// Complete a copy of the metadata (the metadata flow result is shared by every caller, and left untouched if the card is rejected)
+	metadata := publishedMetadata.Clone()
+
+	// Complete the rating with the card data (tags only present in the card data, content of the cards still unrated)
+	// and check it against the filter (the rating is only raised, so the cards rejected by the metadata flow stay rejected)
+	sheet := characterCard.Sheet
+	metadata.Rating = opts.RatingClassifier.Rate(
+		metadata.Rating, resolveTags(sheet.Tags),
+		string(sheet.Description), string(sheet.Scenario), string(sheet.FirstMessage),
+	)
+	if err := opts.RatingFilter.Check(metadata.Rating); err != nil {
+		return nil, nil, err
+	}
+
+	// Convert the platform HTML of the creator notes (before the tagline is joined to them)
+	opts.HTMLConversion.ConvertSheet(characterCard.Sheet, metadata.Source)
+
+	// Attach a copy of the provenance of the fields merged by the fetcher (the patcher traces its own changes)
+	metadata.Provenance = binder.Provenance.Clone()
+	if metadata.Provenance == nil {
+		metadata.Provenance = models.NewProvenance()
+	}
+
+	// Flag the avatars not fetched from their primary URL (alternate URL, generated or placeholder avatar)
+	metadata.AvatarFallback = card.AvatarFallback
+
+	// Patch sheet in the character card (with the configured patcher, if any)
+	// Custom pipelines filter the tags with the tag policy, other patchers handle the tags themselves
+	switch patcher := opts.Patcher.(type) {
+	case nil:
+		fetcher.PatchSheetWithPolicy(characterCard.Sheet, metadata, opts.TagPolicy)
+	case *fetcher.Pipeline:
+		patcher.WithTagPolicy(opts.TagPolicy).Patch(characterCard.Sheet, metadata)
+	default:
+		patcher.Patch(characterCard.Sheet, metadata)
+	}
+
+	// Estimate the token counts of the patched sheet
+	metadata.Tokens = tokenizer.CountSheet(opts.Tokenizer, characterCard.Sheet)
+
+	// Publish the completed metadata (FetchMetadata returns the completed metadata from now on)
+	*publishedMetadata = *metadata
+
+	// Return character card and completed metadata
+	return card, publishedMetadata, nil
+}
+
+// resolveTags resolves the tags of a sheet
+func resolveTags(stringTags []string) []models.Tag {
+	tags := make([]models.Tag, len(stringTags))
+	for index, stringTag := range stringTags {
+		tags[index] = models.ResolveTag(stringTag)
+	}
+	return tags
+}
+
+// executeLintFlow executes the lint flow (after the character card flow, on the patched sheet)
+func executeLintFlow(characterCardFlow func() (*png.CharacterCard, error), opts Options) (*lint.Result, error) {
+	// Linting is optional
+	if opts.Linter == nil {
+		return nil, nil
+	}
+	// Fetch the patched character card (using the flow, executed once)
+	characterCard, err := characterCardFlow()
 	if err != nil {
 		return nil, err
 	}
+	// Lint the sheet
+	return opts.Linter.Lint(characterCard.Sheet), nil
+}
 
-	// Patch sheet in the character card
-	fetcher.PatchSheet(characterCard.Sheet, metadata)
+// executeAssetsFlow embeds the images referenced in the patched sheet (after the token counts, the references are rewritten)
+func executeAssetsFlow(characterCard *png.CharacterCard, sourceID source.ID, opts Options) *asset.Bundle {
+	// Embedding is optional
+	if opts.Assets == nil {
+		return nil
+	}
+	// Embed the assets (the skipped images keep their references)
+	bundle := opts.Assets.Embed(characterCard.Sheet)
+	for _, skipped := range bundle.Skipped {
+		log.Warn().
+			Err(skipped.Err).
+			Str(trace.SOURCE, string(sourceID)).
+			Str(trace.URL, skipped.URL).
+			Msg("Could not embed asset")
+	}
+	return bundle
+}
 
-	// Return character card
-	return characterCard, nil
+// executeAvatarFlow executes the avatar flow (after the character card flow, the patched sheet is embedded in the avatar)
+func executeAvatarFlow(characterCardFlow func() (*png.CharacterCard, error), opts Options) (*avatar.Result, error) {
+	// Avatar processing is optional
+	if opts.Avatar == nil {
+		return nil, nil
+	}
+	// Fetch the patched character card (using the flow, executed once)
+	characterCard, err := characterCardFlow()
+	if err != nil {
+		return nil, err
+	}
+	// Process the avatar
+	return avatar.ProcessCard(characterCard, *opts.Avatar)
+}
+
+// executeExportFlow executes the export flow (after the character card flow, the embedded assets are listed in the V3 assets)
+func executeExportFlow(characterCard *png.CharacterCard, bundle *asset.Bundle, fields *export.Fields, opts Options) ([]byte, error) {
+	return export.PNG(characterCard, export.Options{Version: opts.Version, Assets: bundle, Fields: fields})
+}
+
+// exportFields returns the V3 fields exposed by the platform (the parent of forks is appended to the origin URLs)
+func exportFields(card *fetcher.Card, metadata *models.Metadata) *export.Fields {
+	fields := &export.Fields{
+		Source:                   slices.Clone(card.Source),
+		CreatorNotesMultilingual: card.CreatorNotesMultilingual,
+	}
+	if metadata.Fork != nil {
+		fields.Source = append(fields.Source, metadata.Fork.URL)
+	}
+	return fields
 }
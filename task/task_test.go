@@ -2,16 +2,27 @@ package task
 
 import (
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/imroc/req/v3"
+	"github.com/r3dpixel/card-fetcher/asset"
+	"github.com/r3dpixel/card-fetcher/avatar"
 	"github.com/r3dpixel/card-fetcher/fetcher"
 	"github.com/r3dpixel/card-fetcher/impl"
+	"github.com/r3dpixel/card-fetcher/lint"
+	"github.com/r3dpixel/card-fetcher/markdown"
 	"github.com/r3dpixel/card-fetcher/models"
 	"github.com/r3dpixel/card-fetcher/source"
+	"github.com/r3dpixel/card-fetcher/tokenizer"
+	"github.com/r3dpixel/card-fetcher/tombstone"
 	"github.com/r3dpixel/card-parser/character"
 	"github.com/r3dpixel/card-parser/png"
+	"github.com/r3dpixel/card-parser/property"
+	"github.com/r3dpixel/toolkit/timestamp"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -455,3 +466,748 @@ func TestTask_MetadataNotCopied(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Same(t, metadata, metadata2, "should return same cached pointer")
 }
+
+func TestTask_Removed(t *testing.T) {
+	mockConfig := impl.MockConfig{
+		MockSourceID: source.ID("test-source"),
+		MockDomain:   "example.com",
+		IsUp:         true,
+	}
+
+	removedResponse := &req.Response{Response: &http.Response{StatusCode: http.StatusNotFound}}
+	removedData := impl.MockData{
+		Response:      removedResponse,
+		ResponseError: nil,
+	}
+
+	t.Run("Removed card returns removed error", func(t *testing.T) {
+		mockF := impl.NewMockFetcher(mockConfig, removedData)
+		taskInstance := New(mockF, "http://example.com/char/123", "char/123")
+
+		meta, err := taskInstance.FetchMetadata()
+
+		assert.Error(t, err)
+		assert.Nil(t, meta)
+		assert.Equal(t, fetcher.RemovedErr, fetcher.GetErrCode(err))
+	})
+
+	t.Run("Network failures are not removals", func(t *testing.T) {
+		mockF := impl.NewMockFetcher(mockConfig, impl.MockData{
+			Response:      nil,
+			ResponseError: errors.New("connection reset"),
+		})
+		registry := tombstone.New(1)
+		taskInstance := NewWithOptions(mockF, "http://example.com/char/123", "char/123", Options{Tombstones: registry})
+
+		_, err := taskInstance.FetchMetadata()
+
+		assert.Equal(t, fetcher.FetchMetadataErr, fetcher.GetErrCode(err))
+		assert.Empty(t, registry.Tombstones())
+	})
+
+	t.Run("Removed card is buried after the configured confirmations", func(t *testing.T) {
+		registry := tombstone.New(2)
+		mockF := impl.NewMockFetcher(mockConfig, removedData)
+
+		for range 2 {
+			_, err := NewWithOptions(mockF, "http://example.com/char/123", "char/123", Options{Tombstones: registry}).FetchMetadata()
+			assert.Equal(t, fetcher.RemovedErr, fetcher.GetErrCode(err))
+		}
+
+		tombstoneRecord, ok := registry.Get("example.com/char/123")
+		assert.True(t, ok)
+		assert.Equal(t, 2, tombstoneRecord.Confirmations)
+		assert.True(t, registry.IsBuried("example.com/char/123"))
+
+		// A buried card is not fetched anymore (the mock would otherwise succeed)
+		response := &req.Response{}
+		response.SetBodyString(`{}`)
+		aliveF := impl.NewMockFetcher(mockConfig, impl.MockData{
+			Response:    response,
+			CardInfo:    &models.CardInfo{Title: "Test Card", CharacterID: "123"},
+			CreatorInfo: &models.CreatorInfo{Nickname: "TestCreator"},
+		})
+		meta, card, err := NewWithOptions(aliveF, "http://example.com/char/123", "char/123", Options{Tombstones: registry}).FetchAll()
+		assert.Equal(t, fetcher.RemovedErr, fetcher.GetErrCode(err))
+		assert.Nil(t, meta)
+		assert.Nil(t, card)
+	})
+
+	t.Run("Successful fetch is remembered", func(t *testing.T) {
+		registry := tombstone.New(1)
+		response := &req.Response{}
+		response.SetBodyString(`{}`)
+		aliveF := impl.NewMockFetcher(mockConfig, impl.MockData{
+			Response:    response,
+			CardInfo:    &models.CardInfo{Title: "Test Card", CharacterID: "123", NormalizedURL: "example.com/char/123"},
+			CreatorInfo: &models.CreatorInfo{Nickname: "TestCreator"},
+		})
+		_, err := NewWithOptions(aliveF, "http://example.com/char/123", "char/123", Options{Tombstones: registry}).FetchMetadata()
+		assert.NoError(t, err)
+
+		removedF := impl.NewMockFetcher(mockConfig, removedData)
+		_, err = NewWithOptions(removedF, "http://example.com/char/123", "char/123", Options{Tombstones: registry}).FetchMetadata()
+		assert.Equal(t, fetcher.RemovedErr, fetcher.GetErrCode(err))
+
+		tombstoneRecord, ok := registry.Get("example.com/char/123")
+		assert.True(t, ok)
+		if assert.NotNil(t, tombstoneRecord.LastKnown) {
+			assert.Equal(t, "Test Card", tombstoneRecord.LastKnown.Title)
+		}
+	})
+}
+
+func TestTask_Lint(t *testing.T) {
+	mockConfig := impl.MockConfig{
+		MockSourceID: source.ID("test-source"),
+		MockDomain:   "example.com",
+		IsUp:         true,
+	}
+	newMockData := func() impl.MockData {
+		response := &req.Response{}
+		response.SetBodyString(`{}`)
+		return impl.MockData{
+			Response:      response,
+			CardInfo:      &models.CardInfo{Title: "Test Card", CharacterID: "123"},
+			CreatorInfo:   &models.CreatorInfo{Nickname: "TestCreator"},
+			CharacterCard: &png.CharacterCard{Sheet: character.DefaultSheet(character.RevisionV2)},
+		}
+	}
+
+	t.Run("No linter configured", func(t *testing.T) {
+		taskInstance := New(impl.NewMockFetcher(mockConfig, newMockData()), "http://example.com/char/123", "char/123")
+
+		result, err := taskInstance.Lint()
+
+		assert.NoError(t, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("Lints the patched card", func(t *testing.T) {
+		linter := lint.New(lint.Config{})
+		mockF := impl.NewMockFetcher(mockConfig, newMockData())
+		taskInstance := NewWithOptions(mockF, "http://example.com/char/123", "char/123", Options{Linter: linter})
+
+		result, err := taskInstance.Lint()
+
+		assert.NoError(t, err)
+		assert.True(t, result.Has(lint.RuleFirstMessageEmpty))
+	})
+
+	t.Run("Card fetch fails", func(t *testing.T) {
+		mockData := newMockData()
+		mockData.CharacterCard = nil
+		mockData.CharacterCardErr = errors.New("card fetch failed")
+		mockF := impl.NewMockFetcher(mockConfig, mockData)
+		taskInstance := NewWithOptions(mockF, "http://example.com/char/123", "char/123", Options{Linter: lint.New(lint.Config{})})
+
+		result, err := taskInstance.Lint()
+
+		assert.Error(t, err)
+		assert.Nil(t, result)
+	})
+}
+
+func TestTask_TagPolicy(t *testing.T) {
+	mockConfig := impl.MockConfig{
+		MockSourceID: source.ID("test-source"),
+		MockDomain:   "example.com",
+		IsUp:         true,
+	}
+	newMockData := func(cardTags ...string) impl.MockData {
+		response := &req.Response{}
+		response.SetBodyString(`{}`)
+		sheet := character.DefaultSheet(character.RevisionV2)
+		sheet.Tags = cardTags
+		return impl.MockData{
+			Response: response,
+			CardInfo: &models.CardInfo{
+				Title:       "Test Card",
+				CharacterID: "123",
+				Tags:        []models.Tag{{Slug: "fantasy", Name: "Fantasy"}, {Slug: "ntrbait", Name: "NTR Bait"}},
+			},
+			CreatorInfo:   &models.CreatorInfo{Nickname: "TestCreator"},
+			CharacterCard: &png.CharacterCard{Sheet: sheet},
+		}
+	}
+
+	t.Run("Blocked metadata tags reject the card before the card data is fetched", func(t *testing.T) {
+		mockData := newMockData()
+		mockData.CharacterCard = nil
+		mockData.CharacterCardErr = errors.New("card data and avatar should not be fetched")
+		policy := &fetcher.TagPolicy{Reject: []string{"ntr"}}
+		taskInstance := NewWithOptions(impl.NewMockFetcher(mockConfig, mockData), "http://example.com/char/123", "char/123", Options{TagPolicy: policy})
+
+		meta, card, err := taskInstance.FetchAll()
+
+		assert.Equal(t, fetcher.BlockedErr, fetcher.GetErrCode(err))
+		assert.Nil(t, meta)
+		assert.Nil(t, card)
+		_, err = taskInstance.FetchCharacterCard()
+		assert.Equal(t, fetcher.BlockedErr, fetcher.GetErrCode(err))
+	})
+
+	t.Run("Blocked card tags reject the card", func(t *testing.T) {
+		policy := &fetcher.TagPolicy{Reject: []string{"nsfl"}}
+		taskInstance := NewWithOptions(impl.NewMockFetcher(mockConfig, newMockData("NSFL")), "http://example.com/char/123", "char/123", Options{TagPolicy: policy})
+
+		_, err := taskInstance.FetchMetadata()
+		assert.NoError(t, err)
+		_, err = taskInstance.FetchCharacterCard()
+		assert.Equal(t, fetcher.BlockedErr, fetcher.GetErrCode(err))
+	})
+
+	t.Run("Tags are filtered in both metadata and card", func(t *testing.T) {
+		policy := &fetcher.TagPolicy{Deny: []string{"ntr"}, SkipSourceTag: true}
+		taskInstance := NewWithOptions(impl.NewMockFetcher(mockConfig, newMockData("Adventure")), "http://example.com/char/123", "char/123", Options{TagPolicy: policy})
+
+		meta, card, err := taskInstance.FetchAll()
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Adventure", "Fantasy"}, models.TagsToNames(meta.Tags))
+		assert.Equal(t, []string{"Adventure", "Fantasy"}, []string(card.Sheet.Tags))
+	})
+
+	t.Run("Custom pipelines filter the tags with the tag policy", func(t *testing.T) {
+		policy := &fetcher.TagPolicy{Deny: []string{"ntr"}, SkipSourceTag: true}
+		pipeline := fetcher.DefaultPipeline(fetcher.PipelineOptions{}).Disable(fetcher.StepCreator)
+		taskInstance := NewWithOptions(impl.NewMockFetcher(mockConfig, newMockData("Adventure")), "http://example.com/char/123", "char/123", Options{TagPolicy: policy, Patcher: pipeline})
+
+		_, card, err := taskInstance.FetchAll()
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Adventure", "Fantasy"}, []string(card.Sheet.Tags))
+		assert.Nil(t, pipeline.TagPolicy())
+	})
+
+	t.Run("Chained renames are applied once to the merged tags", func(t *testing.T) {
+		policy := &fetcher.TagPolicy{Rename: map[string]string{"Fantasy": "Adventure", "Adventure": "Horror"}, Deny: []string{"ntr"}, SkipSourceTag: true}
+		taskInstance := NewWithOptions(impl.NewMockFetcher(mockConfig, newMockData()), "http://example.com/char/123", "char/123", Options{TagPolicy: policy})
+
+		published, err := taskInstance.FetchMetadata()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Fantasy", "NTR Bait"}, models.TagsToNames(published.Tags))
+
+		meta, card, err := taskInstance.FetchAll()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Adventure"}, models.TagsToNames(meta.Tags))
+		assert.Equal(t, []string{"Adventure"}, []string(card.Sheet.Tags))
+	})
+}
+
+func TestTask_Stats(t *testing.T) {
+	mockConfig := impl.MockConfig{
+		MockSourceID: source.ID("test-source"),
+		MockDomain:   "example.com",
+		IsUp:         true,
+	}
+	newMockData := func() impl.MockData {
+		response := &req.Response{}
+		response.SetBodyString(`{}`)
+		return impl.MockData{
+			Response:    response,
+			CardInfo:    &models.CardInfo{Title: "Test Card", CharacterID: "123"},
+			CreatorInfo: &models.CreatorInfo{Nickname: "TestCreator"},
+		}
+	}
+
+	t.Run("Statistics are stamped with the fetch time", func(t *testing.T) {
+		likes := int64(42)
+		mockData := newMockData()
+		mockData.Stats = &models.Stats{Likes: &likes}
+		before := time.Now().UnixNano()
+
+		meta, err := New(impl.NewMockFetcher(mockConfig, mockData), "http://example.com/char/123", "char/123").FetchMetadata()
+
+		assert.NoError(t, err)
+		assert.Equal(t, &likes, meta.Stats.Likes)
+		assert.Nil(t, meta.Stats.Views)
+		assert.GreaterOrEqual(t, int64(meta.Stats.FetchTime), before)
+	})
+
+	t.Run("Platforms without statistics return empty statistics", func(t *testing.T) {
+		meta, err := New(impl.NewMockFetcher(mockConfig, newMockData()), "http://example.com/char/123", "char/123").FetchMetadata()
+
+		assert.NoError(t, err)
+		assert.True(t, meta.Stats.IsEmpty())
+		assert.NotZero(t, meta.Stats.FetchTime)
+	})
+
+	t.Run("Statistics errors fail the metadata", func(t *testing.T) {
+		mockData := newMockData()
+		mockData.Stats = &models.Stats{}
+		mockData.StatsErr = errors.New("stats fetch failed")
+
+		meta, err := New(impl.NewMockFetcher(mockConfig, mockData), "http://example.com/char/123", "char/123").FetchMetadata()
+
+		assert.Error(t, err)
+		assert.Nil(t, meta)
+	})
+
+	t.Run("Statistics errors fail the metadata without mocked statistics", func(t *testing.T) {
+		mockData := newMockData()
+		mockData.StatsErr = errors.New("stats fetch failed")
+
+		meta, err := New(impl.NewMockFetcher(mockConfig, mockData), "http://example.com/char/123", "char/123").FetchMetadata()
+
+		assert.ErrorContains(t, err, "stats fetch failed")
+		assert.Nil(t, meta)
+	})
+}
+
+func TestTask_Tokens(t *testing.T) {
+	mockConfig := impl.MockConfig{
+		MockSourceID: source.ID("test-source"),
+		MockDomain:   "example.com",
+		IsUp:         true,
+	}
+	newMockData := func() impl.MockData {
+		response := &req.Response{}
+		response.SetBodyString(`{}`)
+		sheet := character.DefaultSheet(character.RevisionV2)
+		sheet.Description = "A tall knight."
+		sheet.FirstMessage = "Greetings, traveler."
+		sheet.AlternateGreetings = []string{"Hi."}
+		return impl.MockData{
+			Response:      response,
+			CardInfo:      &models.CardInfo{Title: "Test Card", CharacterID: "123"},
+			CreatorInfo:   &models.CreatorInfo{Nickname: "TestCreator"},
+			CharacterCard: &png.CharacterCard{Sheet: sheet},
+		}
+	}
+
+	t.Run("Token counts are estimated after patching the sheet", func(t *testing.T) {
+		taskInstance := New(impl.NewMockFetcher(mockConfig, newMockData()), "http://example.com/char/123", "char/123")
+
+		meta, err := taskInstance.FetchMetadata()
+		assert.NoError(t, err)
+		assert.Nil(t, meta.Tokens, "Token counts require the card data")
+
+		_, err = taskInstance.FetchCharacterCard()
+		assert.NoError(t, err)
+		assert.NotNil(t, meta.Tokens)
+		assert.Positive(t, meta.Tokens.Description)
+		assert.Len(t, meta.Tokens.Greetings, 1)
+	})
+
+	t.Run("Custom tokenizers are used", func(t *testing.T) {
+		runes := tokenizer.Func(func(text string) int { return len([]rune(text)) })
+		taskInstance := NewWithOptions(impl.NewMockFetcher(mockConfig, newMockData()), "http://example.com/char/123", "char/123", Options{Tokenizer: runes})
+
+		meta, _, err := taskInstance.FetchAll()
+
+		assert.NoError(t, err)
+		assert.Equal(t, len("A tall knight."), meta.Tokens.Description)
+		assert.Equal(t, len("Greetings, traveler.")+len("Hi."), meta.Tokens.FirstMessage+meta.Tokens.Greetings[0])
+	})
+}
+
+func TestTask_Rating(t *testing.T) {
+	mockConfig := impl.MockConfig{
+		MockSourceID: source.ID("test-source"),
+		MockDomain:   "example.com",
+		IsUp:         true,
+	}
+	newMockData := func(rating models.Rating, description string, cardTags ...string) impl.MockData {
+		response := &req.Response{}
+		response.SetBodyString(`{}`)
+		sheet := character.DefaultSheet(character.RevisionV2)
+		sheet.Description = property.String(description)
+		sheet.Tags = cardTags
+		return impl.MockData{
+			Response:      response,
+			CardInfo:      &models.CardInfo{Title: "Test Card", CharacterID: "123", Rating: rating, Tags: []models.Tag{{Slug: "fantasy", Name: "Fantasy"}}},
+			CreatorInfo:   &models.CreatorInfo{Nickname: "TestCreator"},
+			CharacterCard: &png.CharacterCard{Sheet: sheet},
+		}
+	}
+	newTask := func(mockData impl.MockData, opts Options) Task {
+		return NewWithOptions(impl.NewMockFetcher(mockConfig, mockData), "http://example.com/char/123", "char/123", opts)
+	}
+
+	t.Run("Platform ratings are rejected before the card data is fetched", func(t *testing.T) {
+		mockData := newMockData(models.RatingNSFW, "")
+		mockData.CharacterCard = nil
+		mockData.CharacterCardErr = errors.New("card data and avatar should not be fetched")
+		taskInstance := newTask(mockData, Options{RatingFilter: &fetcher.RatingFilter{Max: models.RatingSuggestive}})
+
+		meta, card, err := taskInstance.FetchAll()
+
+		assert.Equal(t, fetcher.RatingErr, fetcher.GetErrCode(err))
+		assert.Nil(t, meta)
+		assert.Nil(t, card)
+	})
+
+	t.Run("Card tags raise the rating", func(t *testing.T) {
+		taskInstance := newTask(newMockData(models.RatingSFW, "", "NSFL"), Options{})
+
+		meta, _, err := taskInstance.FetchAll()
+
+		assert.NoError(t, err)
+		assert.Equal(t, models.RatingNSFL, meta.Rating)
+	})
+
+	t.Run("Unrated cards are classified from their content", func(t *testing.T) {
+		taskInstance := newTask(newMockData(models.RatingUnknown, "An explicit story."), Options{RatingFilter: &fetcher.RatingFilter{Max: models.RatingSFW}})
+
+		meta, err := taskInstance.FetchMetadata()
+		assert.NoError(t, err)
+		assert.Equal(t, models.RatingUnknown, meta.Rating)
+
+		_, err = taskInstance.FetchCharacterCard()
+		assert.Equal(t, fetcher.RatingErr, fetcher.GetErrCode(err))
+		assert.Equal(t, models.RatingUnknown, meta.Rating, "rejected cards should not complete the metadata")
+	})
+
+	t.Run("Unknown ratings are rejected once the card data is rated", func(t *testing.T) {
+		filter := &fetcher.RatingFilter{Max: models.RatingNSFW, RejectUnknown: true}
+		rated := newTask(newMockData(models.RatingUnknown, "An explicit story."), Options{RatingFilter: filter})
+		unrated := newTask(newMockData(models.RatingUnknown, "A quiet story."), Options{RatingFilter: filter})
+
+		_, err := rated.FetchMetadata()
+		assert.NoError(t, err, "the card content may still rate the card")
+		meta, _, err := rated.FetchAll()
+		assert.NoError(t, err)
+		assert.Equal(t, models.RatingNSFW, meta.Rating)
+
+		_, err = unrated.FetchMetadata()
+		assert.NoError(t, err)
+		_, _, err = unrated.FetchAll()
+		assert.Equal(t, fetcher.RatingErr, fetcher.GetErrCode(err))
+	})
+}
+
+func TestTask_Patcher(t *testing.T) {
+	mockConfig := impl.MockConfig{
+		MockSourceID: source.ID("test-source"),
+		MockDomain:   "example.com",
+		IsUp:         true,
+	}
+	newMockData := func() impl.MockData {
+		response := &req.Response{}
+		response.SetBodyString(`{}`)
+		sheet := character.DefaultSheet(character.RevisionV2)
+		sheet.CreatorNotes = "Notes"
+		return impl.MockData{
+			Response:      response,
+			CardInfo:      &models.CardInfo{Title: "Test Card", Tagline: "Tagline", CharacterID: "123"},
+			CreatorInfo:   &models.CreatorInfo{Nickname: "TestCreator"},
+			CharacterCard: &png.CharacterCard{Sheet: sheet},
+		}
+	}
+
+	t.Run("Default pipeline is used without a patcher", func(t *testing.T) {
+		taskInstance := New(impl.NewMockFetcher(mockConfig, newMockData()), "http://example.com/char/123", "char/123")
+
+		card, err := taskInstance.FetchCharacterCard()
+
+		assert.NoError(t, err)
+		assert.Equal(t, "TestCreator", string(card.Sheet.Creator))
+		assert.Contains(t, string(card.Sheet.CreatorNotes), "Tagline")
+	})
+
+	t.Run("Configured patcher replaces the default pipeline", func(t *testing.T) {
+		pipeline := fetcher.DefaultPipeline(fetcher.PipelineOptions{}).Disable(fetcher.StepCreatorNotes, fetcher.StepCreator)
+		taskInstance := NewWithOptions(impl.NewMockFetcher(mockConfig, newMockData()), "http://example.com/char/123", "char/123", Options{Patcher: pipeline})
+
+		card, err := taskInstance.FetchCharacterCard()
+
+		assert.NoError(t, err)
+		assert.NotEqual(t, "TestCreator", string(card.Sheet.Creator))
+		assert.Equal(t, "Notes", string(card.Sheet.CreatorNotes))
+	})
+}
+
+func TestTask_Provenance(t *testing.T) {
+	response := &req.Response{}
+	response.SetBodyString(`{}`)
+	sheet := character.DefaultSheet(character.RevisionV2)
+	sheet.Description = "A tall knight."
+	mockData := impl.MockData{
+		Response:      response,
+		CardInfo:      &models.CardInfo{Title: "Test Card", Tagline: "Tagline", CharacterID: "123"},
+		CreatorInfo:   &models.CreatorInfo{Nickname: "TestCreator"},
+		CharacterCard: &png.CharacterCard{Sheet: sheet},
+	}
+	taskInstance := New(impl.NewMockFetcher(impl.MockConfig{MockSourceID: source.ID("test-source"), MockDomain: "example.com", IsUp: true}, mockData), "http://example.com/char/123", "char/123")
+
+	meta, _, err := taskInstance.FetchAll()
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, meta.Provenance) {
+		assert.Equal(t, models.OriginMetadata, meta.Provenance.Origin(models.FieldCreator))
+		assert.Equal(t, models.OriginPatcher, meta.Provenance.Origin(models.FieldCreatorNotes))
+		assert.False(t, meta.Provenance.HasDrift())
+	}
+
+	t.Run("The provenance of the fetcher is copied", func(t *testing.T) {
+		fetcherProvenance := models.NewProvenance()
+		fetcherProvenance.Set(models.FieldDescription, models.OriginAPI)
+		mockData.Provenance = fetcherProvenance
+		mockData.CharacterCard = &png.CharacterCard{Sheet: character.DefaultSheet(character.RevisionV2)}
+		taskInstance := New(impl.NewMockFetcher(impl.MockConfig{MockSourceID: source.ID("test-source"), MockDomain: "example.com", IsUp: true}, mockData), "http://example.com/char/123", "char/123")
+
+		meta, _, err := taskInstance.FetchAll()
+
+		assert.NoError(t, err)
+		assert.NotSame(t, fetcherProvenance, meta.Provenance)
+		assert.Equal(t, models.OriginAPI, meta.Provenance.Origin(models.FieldDescription))
+		assert.Equal(t, models.OriginPatcher, meta.Provenance.Origin(models.FieldCreatorNotes))
+		assert.Equal(t, map[models.SheetField]models.Origin{models.FieldDescription: models.OriginAPI}, fetcherProvenance.Fields)
+	})
+}
+
+func TestTask_HTMLConversion(t *testing.T) {
+	response := &req.Response{}
+	response.SetBodyString(`{}`)
+	sheet := character.DefaultSheet(character.RevisionV2)
+	sheet.CreatorNotes = "<p>Notes with a <a href=\"https://example.com\">link</a></p>"
+	mockData := impl.MockData{
+		Response:      response,
+		CardInfo:      &models.CardInfo{Title: "Test Card", Tagline: "<b>Tagline</b>", CharacterID: "123"},
+		CreatorInfo:   &models.CreatorInfo{Nickname: "TestCreator"},
+		CharacterCard: &png.CharacterCard{Sheet: sheet},
+	}
+	conversion := &fetcher.HTMLConversion{Sources: map[source.ID]markdown.Format{"test-source": markdown.FormatMarkdown}}
+	taskInstance := NewWithOptions(
+		impl.NewMockFetcher(impl.MockConfig{MockSourceID: source.ID("test-source"), MockDomain: "example.com", IsUp: true}, mockData),
+		"http://example.com/char/123", "char/123", Options{HTMLConversion: conversion},
+	)
+
+	meta, card, err := taskInstance.FetchAll()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "**Tagline**", meta.Tagline)
+	assert.Equal(t, "**Tagline**\n\nNotes with a [link](https://example.com)", string(card.Sheet.CreatorNotes))
+}
+
+func TestTask_Assets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("GIF89a\x01\x00\x01\x00\x00\x00\x00;"))
+	}))
+	defer server.Close()
+
+	newTask := func(embedder *asset.Embedder) Task {
+		response := &req.Response{}
+		response.SetBodyString(`{}`)
+		sheet := character.DefaultSheet(character.RevisionV3)
+		sheet.FirstMessage = property.String("![gallery](" + server.URL + "/gallery.gif)")
+		mockData := impl.MockData{
+			Response:      response,
+			CardInfo:      &models.CardInfo{Title: "Test Card", CharacterID: "123"},
+			CreatorInfo:   &models.CreatorInfo{Nickname: "TestCreator"},
+			CharacterCard: &png.CharacterCard{Sheet: sheet},
+		}
+		return NewWithOptions(
+			impl.NewMockFetcher(impl.MockConfig{MockSourceID: source.ID("test-source"), MockDomain: "example.com", IsUp: true}, mockData),
+			"http://example.com/char/123", "char/123", Options{Assets: embedder},
+		)
+	}
+
+	t.Run("should embed the assets of the character card", func(t *testing.T) {
+		embedder := asset.NewEmbedder(asset.Policy{})
+		embedder.Client = server.Client()
+		taskInstance := newTask(embedder)
+
+		bundle, err := taskInstance.Assets()
+		assert.NoError(t, err)
+		card, err := taskInstance.FetchCharacterCard()
+		assert.NoError(t, err)
+
+		assert.Len(t, bundle.Assets, 1)
+		assert.Equal(t, "gif", bundle.Assets[0].Ext)
+		assert.Equal(t, "![gallery]("+server.URL+"/gallery.gif)", string(card.Sheet.FirstMessage), "the PNG card keeps the source URLs")
+	})
+
+	t.Run("should reference the assets of the character card with data URIs", func(t *testing.T) {
+		embedder := asset.NewEmbedder(asset.Policy{})
+		embedder.Client = server.Client()
+		embedder.Storage = asset.StorageDataURI
+		taskInstance := newTask(embedder)
+
+		bundle, err := taskInstance.Assets()
+		assert.NoError(t, err)
+		card, err := taskInstance.FetchCharacterCard()
+		assert.NoError(t, err)
+
+		assert.Len(t, bundle.Assets, 1)
+		assert.Equal(t, "![gallery]("+bundle.Assets[0].URI+")", string(card.Sheet.FirstMessage))
+	})
+
+	t.Run("should return no bundle without an embedder", func(t *testing.T) {
+		taskInstance := newTask(nil)
+
+		bundle, err := taskInstance.Assets()
+		assert.NoError(t, err)
+		card, err := taskInstance.FetchCharacterCard()
+		assert.NoError(t, err)
+
+		assert.Nil(t, bundle)
+		assert.Equal(t, "![gallery]("+server.URL+"/gallery.gif)", string(card.Sheet.FirstMessage))
+	})
+}
+
+func TestTask_Avatar(t *testing.T) {
+	mockConfig := impl.MockConfig{MockSourceID: source.ID("test-source"), MockDomain: "example.com", IsUp: true}
+	newMockData := func() impl.MockData {
+		response := &req.Response{}
+		response.SetBodyString(`{}`)
+		return impl.MockData{
+			Response:      response,
+			CardInfo:      &models.CardInfo{Title: "Test Card", CharacterID: "123"},
+			CreatorInfo:   &models.CreatorInfo{Nickname: "TestCreator"},
+			CharacterCard: &png.CharacterCard{Sheet: character.DefaultSheet(character.RevisionV2)},
+		}
+	}
+
+	t.Run("should return no avatar without options", func(t *testing.T) {
+		taskInstance := New(impl.NewMockFetcher(mockConfig, newMockData()), "http://example.com/char/123", "char/123")
+
+		result, err := taskInstance.Avatar()
+
+		assert.NoError(t, err)
+		assert.Nil(t, result)
+	})
+
+	t.Run("should return the error of the character card flow", func(t *testing.T) {
+		mockData := newMockData()
+		mockData.CharacterCardErr = errors.New("avatar download failed")
+		taskInstance := NewWithOptions(
+			impl.NewMockFetcher(mockConfig, mockData), "http://example.com/char/123", "char/123",
+			Options{Avatar: &avatar.Options{MaxSize: 512}},
+		)
+
+		result, err := taskInstance.Avatar()
+
+		assert.ErrorContains(t, err, "avatar download failed")
+		assert.Nil(t, result)
+	})
+}
+
+func TestTask_Export(t *testing.T) {
+	response := &req.Response{}
+	response.SetBodyString(`{}`)
+	mockData := impl.MockData{
+		Response:         response,
+		CardInfo:         &models.CardInfo{Title: "Test Card", CharacterID: "123"},
+		CreatorInfo:      &models.CreatorInfo{Nickname: "TestCreator"},
+		CharacterCardErr: fetcher.NewError(errors.New("card data unavailable"), fetcher.FetchCardDataErr),
+	}
+	taskInstance := New(impl.NewMockFetcher(impl.MockConfig{MockSourceID: source.ID("test-source"), MockDomain: "example.com", IsUp: true}, mockData), "http://example.com/char/123", "char/123")
+
+	data, err := taskInstance.Export()
+
+	assert.Equal(t, fetcher.FetchCardDataErr, fetcher.GetErrCode(err))
+	assert.Nil(t, data)
+}
+
+func TestExportFields(t *testing.T) {
+	card := &fetcher.Card{
+		Source:                   []string{"https://example.com/char/123"},
+		CreatorNotesMultilingual: map[string]string{"en": "Notes", "fr": "Notes en français"},
+	}
+	metadata := &models.Metadata{CardInfo: models.CardInfo{IsForked: true, Fork: &models.ForkInfo{URL: "example.com/char/parent"}}}
+
+	fields := exportFields(card, metadata)
+
+	assert.Equal(t, []string{"https://example.com/char/123", "example.com/char/parent"}, fields.Source)
+	assert.Equal(t, card.CreatorNotesMultilingual, fields.CreatorNotesMultilingual)
+	assert.Len(t, card.Source, 1, "the fetched card should not be modified")
+}
+
+func TestTask_AvatarFallback(t *testing.T) {
+	response := &req.Response{}
+	response.SetBodyString(`{}`)
+	mockData := impl.MockData{
+		Response:       response,
+		CardInfo:       &models.CardInfo{Title: "Test Card", CharacterID: "123"},
+		CreatorInfo:    &models.CreatorInfo{Nickname: "TestCreator"},
+		CharacterCard:  &png.CharacterCard{Sheet: character.DefaultSheet(character.RevisionV2)},
+		AvatarFallback: models.AvatarFallbackGenerated,
+	}
+	taskInstance := New(impl.NewMockFetcher(impl.MockConfig{MockSourceID: source.ID("test-source"), MockDomain: "example.com", IsUp: true}, mockData), "http://example.com/char/123", "char/123")
+
+	meta, _, err := taskInstance.FetchAll()
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.AvatarFallbackGenerated, meta.AvatarFallback)
+	assert.True(t, meta.AvatarFallback.IsSynthetic())
+}
+
+func TestTask_WorldBooks(t *testing.T) {
+	mockConfig := impl.MockConfig{MockSourceID: source.ID("test-source"), MockDomain: "example.com", IsUp: true}
+	newMockData := func(linked *character.Book) impl.MockData {
+		response := &req.Response{}
+		response.SetBodyString(`{}`)
+		return impl.MockData{
+			Response:      response,
+			CardInfo:      &models.CardInfo{Title: "Test Card", CharacterID: "123"},
+			CreatorInfo:   &models.CreatorInfo{Nickname: "TestCreator"},
+			CharacterCard: &png.CharacterCard{Sheet: character.DefaultSheet(character.RevisionV2)},
+			LinkedBooks:   []*character.Book{linked},
+		}
+	}
+
+	t.Run("should merge the linked books by default", func(t *testing.T) {
+		taskInstance := New(impl.NewMockFetcher(mockConfig, newMockData(character.DefaultBook())), "http://example.com/char/123", "char/123")
+
+		books, err := taskInstance.WorldBooks()
+
+		assert.NoError(t, err)
+		assert.Empty(t, books)
+	})
+
+	t.Run("should keep the linked books separate", func(t *testing.T) {
+		linked := character.DefaultBook()
+		taskInstance := NewWithOptions(
+			impl.NewMockFetcher(mockConfig, newMockData(linked)), "http://example.com/char/123", "char/123",
+			Options{BookStrategy: fetcher.BookStrategySeparate},
+		)
+
+		books, err := taskInstance.WorldBooks()
+
+		assert.NoError(t, err)
+		assert.Equal(t, []*character.Book{linked}, books)
+	})
+
+	t.Run("should not date the character book with the separate books", func(t *testing.T) {
+		mockData := newMockData(character.DefaultBook())
+		mockData.Books = []models.BookInfo{{Origin: models.BookOriginLinked, PlatformID: "42", UpdateTime: 1000}}
+		taskInstance := NewWithOptions(
+			impl.NewMockFetcher(mockConfig, mockData), "http://example.com/char/123", "char/123",
+			Options{BookStrategy: fetcher.BookStrategySeparate},
+		)
+
+		meta, card, err := taskInstance.FetchAll()
+
+		assert.NoError(t, err)
+		assert.Nil(t, card.Sheet.CharacterBook)
+		assert.Zero(t, meta.BookUpdateTime)
+		assert.Equal(t, timestamp.Nano(1000), meta.Books[0].UpdateTime)
+		report := meta.ValidateAgainst(card.Sheet)
+		assert.False(t, report.Has(models.RuleBookUpdateTimeMismatch))
+		assert.False(t, report.Has(models.RuleHasBookMismatch))
+	})
+}
+
+func TestTask_Books(t *testing.T) {
+	response := &req.Response{}
+	response.SetBodyString(`{}`)
+	books := []models.BookInfo{
+		{Origin: models.BookOriginEmbedded, EntryCount: 2},
+		{Origin: models.BookOriginLinked, PlatformID: "42", Name: "World", EntryCount: 5, UpdateTime: 1000},
+	}
+	mockData := impl.MockData{
+		Response:      response,
+		CardInfo:      &models.CardInfo{Title: "Test Card", CharacterID: "123"},
+		CreatorInfo:   &models.CreatorInfo{Nickname: "TestCreator"},
+		CharacterCard: &png.CharacterCard{Sheet: character.DefaultSheet(character.RevisionV2)},
+		Books:         books,
+	}
+	taskInstance := New(impl.NewMockFetcher(impl.MockConfig{MockSourceID: source.ID("test-source"), MockDomain: "example.com", IsUp: true}, mockData), "http://example.com/char/123", "char/123")
+
+	meta, err := taskInstance.FetchMetadata()
+
+	assert.NoError(t, err)
+	assert.Equal(t, books, meta.Books)
+}
@@ -0,0 +1,86 @@
+package tokenizer
+
+import (
+	"unicode"
+)
+
+// Approximate is an offline tokenizer estimating the token count of BPE tokenizers from the shape of the text
+// Common words count as one token, long words as one token per 5 letters, numbers as one token per 3 digits,
+// punctuation runs as one token and CJK characters as one token each
+type Approximate struct{}
+
+// character classes of the approximation
+const (
+	classSpace = iota
+	classNewline
+	classLatin
+	classOtherLetter
+	classDigit
+	classSymbol
+	classIdeograph
+)
+
+// Count estimates the tokens of the text
+func (Approximate) Count(text string) int {
+	tokens := 0
+	runLength := 0
+	runClass := classSpace
+	var runRune rune
+
+	// flush counts the tokens of the current run
+	flush := func() {
+		switch runClass {
+		case classLatin:
+			tokens += (runLength + 4) / 5
+		case classOtherLetter:
+			tokens += (runLength + 1) / 2
+		case classDigit:
+			tokens += (runLength + 2) / 3
+		case classSymbol, classNewline:
+			tokens++
+		}
+		runLength = 0
+	}
+
+	for _, r := range text {
+		class := classify(r)
+		switch {
+		case class == classIdeograph:
+			// Every ideograph is a token
+			flush()
+			tokens++
+			runClass = classSpace
+			continue
+		case class == runClass && (class != classSymbol || r == runRune):
+			// Extend the run (symbol runs only repeat the same symbol, e.g. "..." or "**")
+			runLength++
+			continue
+		}
+		flush()
+		runClass, runRune, runLength = class, r, 1
+	}
+	flush()
+
+	// Return the estimate
+	return tokens
+}
+
+// classify returns the class of the rune
+func classify(r rune) int {
+	switch {
+	case r == '\n' || r == '\r':
+		return classNewline
+	case unicode.IsSpace(r):
+		return classSpace
+	case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r):
+		return classIdeograph
+	case r < unicode.MaxLatin1 && unicode.IsLetter(r), unicode.Is(unicode.Latin, r):
+		return classLatin
+	case unicode.IsLetter(r) || unicode.Is(unicode.Mn, r):
+		return classOtherLetter
+	case unicode.IsDigit(r):
+		return classDigit
+	default:
+		return classSymbol
+	}
+}
@@ -0,0 +1,136 @@
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// ErrInvalidVocab is returned when a BPE vocabulary file is malformed
+var ErrInvalidVocab = errors.New("invalid BPE vocabulary")
+
+// bpePattern splits the text into pieces before merging (the cl100k pattern, without the trailing whitespace lookahead)
+var bpePattern = regexp.MustCompile(`(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+`)
+
+// maxCachedPieces is the number of pieces whose token counts are cached
+const maxCachedPieces = 1 << 16
+
+// BPE is a byte-level BPE tokenizer loaded from a vocabulary file (tiktoken format)
+type BPE struct {
+	ranks map[string]int
+	cache map[string]int
+	mu    sync.RWMutex
+}
+
+// LoadBPE loads a BPE tokenizer from a tiktoken vocabulary ("<base64 token> <rank>" per line, e.g. cl100k_base.tiktoken)
+func LoadBPE(reader io.Reader) (*BPE, error) {
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		// Skip blank lines
+		fields := bytes.Fields(scanner.Bytes())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%w: line %d: expected a token and a rank", ErrInvalidVocab, line)
+		}
+		// Decode the token and its rank
+		token, err := base64.StdEncoding.DecodeString(string(fields[0]))
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidVocab, line, err)
+		}
+		rank, err := strconv.Atoi(string(fields[1]))
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidVocab, line, err)
+		}
+		ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("%w: empty vocabulary", ErrInvalidVocab)
+	}
+	return &BPE{ranks: ranks, cache: make(map[string]int)}, nil
+}
+
+// LoadBPEFile loads a BPE tokenizer from a tiktoken vocabulary file
+func LoadBPEFile(path string) (*BPE, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return LoadBPE(file)
+}
+
+// Count counts the tokens of the text
+func (b *BPE) Count(text string) int {
+	tokens := 0
+	for _, piece := range bpePattern.FindAllString(text, -1) {
+		tokens += b.countPiece(piece)
+	}
+	return tokens
+}
+
+// countPiece counts the tokens of a piece (cached, pieces are mostly words)
+func (b *BPE) countPiece(piece string) int {
+	// Whole pieces in the vocabulary are a single token
+	if _, ok := b.ranks[piece]; ok {
+		return 1
+	}
+	b.mu.RLock()
+	count, ok := b.cache[piece]
+	b.mu.RUnlock()
+	if ok {
+		return count
+	}
+
+	// Merge the bytes of the piece
+	count = b.merge([]byte(piece))
+
+	// Cache the count (bounded, the cache is reset once full)
+	b.mu.Lock()
+	if len(b.cache) >= maxCachedPieces {
+		clear(b.cache)
+	}
+	b.cache[piece] = count
+	b.mu.Unlock()
+	return count
+}
+
+// merge applies the BPE merges to the bytes and returns the number of tokens (the lowest ranked adjacent pair is merged first)
+func (b *BPE) merge(piece []byte) int {
+	// Start from single bytes (bounds are the start offsets of the parts, followed by the end of the piece)
+	bounds := make([]int, len(piece)+1)
+	for index := range bounds {
+		bounds[index] = index
+	}
+
+	for len(bounds) > 2 {
+		// Find the lowest ranked pair
+		best, bestRank := -1, math.MaxInt
+		for index := 0; index+2 < len(bounds); index++ {
+			if rank, ok := b.ranks[string(piece[bounds[index]:bounds[index+2]])]; ok && rank < bestRank {
+				best, bestRank = index, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		// Merge the pair (drop the bound between the two parts)
+		bounds = append(bounds[:best+1], bounds[best+2:]...)
+	}
+
+	// Return the number of parts
+	return len(bounds) - 1
+}
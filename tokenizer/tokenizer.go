@@ -0,0 +1,67 @@
+package tokenizer
+
+import (
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-parser/character"
+)
+
+// Tokenizer counts the tokens of a text
+// Count can be plugged into lint.Config.TokenCounter (the linter counts with Default otherwise)
+type Tokenizer interface {
+	Count(text string) int
+}
+
+// Func adapts a counting function to the Tokenizer interface
+type Func func(text string) int
+
+// Count counts the tokens of the text
+func (f Func) Count(text string) int {
+	return f(text)
+}
+
+// Default returns the built-in offline tokenizer (an approximation, see Approximate)
+func Default() Tokenizer {
+	return Approximate{}
+}
+
+// CountSheet estimates the tokens of each prompt field of the sheet (nil tokenizer uses Default)
+// Disabled lorebook entries are skipped; constant entries count as permanent, the others as keyed
+func CountSheet(tokenizer Tokenizer, sheet *character.Sheet) *models.TokenCounts {
+	if tokenizer == nil {
+		tokenizer = Default()
+	}
+	if sheet == nil {
+		return &models.TokenCounts{}
+	}
+
+	// Count the prompt fields
+	counts := &models.TokenCounts{
+		Description:  tokenizer.Count(string(sheet.Description)),
+		Personality:  tokenizer.Count(string(sheet.Personality)),
+		Scenario:     tokenizer.Count(string(sheet.Scenario)),
+		SystemPrompt: tokenizer.Count(string(sheet.SystemPrompt)) + tokenizer.Count(string(sheet.PostHistoryInstructions)),
+		Examples:     tokenizer.Count(string(sheet.MessageExamples)),
+		FirstMessage: tokenizer.Count(string(sheet.FirstMessage)),
+		Greetings:    make([]int, len(sheet.AlternateGreetings)),
+	}
+	for index, greeting := range sheet.AlternateGreetings {
+		counts.Greetings[index] = tokenizer.Count(greeting)
+	}
+
+	// Count the lorebook entries
+	if sheet.CharacterBook != nil {
+		for _, entry := range sheet.CharacterBook.Entries {
+			if entry == nil || !bool(entry.Enabled) {
+				continue
+			}
+			if bool(entry.Constant) {
+				counts.BookConstant += tokenizer.Count(string(entry.Content))
+			} else {
+				counts.BookKeyed += tokenizer.Count(string(entry.Content))
+			}
+		}
+	}
+
+	// Return the counts
+	return counts
+}
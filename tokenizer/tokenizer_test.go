@@ -0,0 +1,124 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-parser/character"
+	"github.com/r3dpixel/card-parser/property"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testVocab builds a tiktoken vocabulary with all single bytes and the given merged tokens (ranked in order)
+func testVocab(tokens ...string) string {
+	var vocab strings.Builder
+	for b := 0; b < 256; b++ {
+		fmt.Fprintf(&vocab, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(b)}), b)
+	}
+	for index, token := range tokens {
+		fmt.Fprintf(&vocab, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(token)), 256+index)
+	}
+	return vocab.String()
+}
+
+func TestApproximate_Count(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		expected int
+	}{
+		{name: "empty", text: "", expected: 0},
+		{name: "whitespace", text: "  \t ", expected: 0},
+		{name: "short words", text: "the cat sat", expected: 3},
+		{name: "long word", text: "extraordinarily", expected: 3},
+		{name: "punctuation", text: "Hello, world!", expected: 4},
+		{name: "repeated symbols", text: "Wait...", expected: 2},
+		{name: "numbers", text: "1234567", expected: 3},
+		{name: "newlines", text: "one\n\ntwo", expected: 3},
+		{name: "CJK", text: "你好世界", expected: 4},
+		{name: "Cyrillic", text: "привет", expected: 3},
+		{name: "macro", text: "{{char}}", expected: 3},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Approximate{}.Count(tc.text))
+		})
+	}
+}
+
+func TestLoadBPE(t *testing.T) {
+	t.Run("should merge the lowest ranked pairs first", func(t *testing.T) {
+		bpe, err := LoadBPE(strings.NewReader(testVocab("he", "ll", "hell", "hello", " w", "or", " wor")))
+		require.NoError(t, err)
+
+		assert.Equal(t, 0, bpe.Count(""))
+		assert.Equal(t, 1, bpe.Count("hello"))
+		assert.Equal(t, 4, bpe.Count("hello world"), "hello + ' wor' + l + d")
+		assert.Equal(t, 3, bpe.Count("help"), "he + l + p")
+		assert.Equal(t, 3, bpe.Count("help"), "cached pieces count the same")
+	})
+
+	t.Run("should count unknown bytes as single tokens", func(t *testing.T) {
+		bpe, err := LoadBPE(strings.NewReader(testVocab()))
+		require.NoError(t, err)
+
+		assert.Equal(t, len("héllo"), bpe.Count("héllo"))
+	})
+
+	t.Run("should reject malformed vocabularies", func(t *testing.T) {
+		for _, vocab := range []string{"", "aGk=", "aGk= one", "not-base64! 1"} {
+			_, err := LoadBPE(strings.NewReader(vocab))
+			assert.ErrorIs(t, err, ErrInvalidVocab, vocab)
+		}
+	})
+
+	t.Run("should fail for missing files", func(t *testing.T) {
+		_, err := LoadBPEFile(t.TempDir() + "/missing.tiktoken")
+		assert.Error(t, err)
+	})
+}
+
+func TestCountSheet(t *testing.T) {
+	words := Func(func(text string) int {
+		return len(strings.Fields(text))
+	})
+
+	sheet := character.DefaultSheet(character.RevisionV3)
+	sheet.Description = "a tall knight"
+	sheet.Personality = "brave"
+	sheet.Scenario = "a castle under siege"
+	sheet.SystemPrompt = "stay in character"
+	sheet.PostHistoryInstructions = "be brief"
+	sheet.MessageExamples = "<START> hello"
+	sheet.FirstMessage = "Greetings, traveler."
+	sheet.AlternateGreetings = property.StringArray{"Hi.", "Who goes there?"}
+	constant := character.DefaultBookEntry()
+	constant.Content, constant.Constant, constant.Enabled = "the kingdom is at war", true, true
+	keyed := character.DefaultBookEntry()
+	keyed.Content, keyed.Enabled = "the king is old", true
+	disabled := character.DefaultBookEntry()
+	disabled.Content = "ignored entry"
+	sheet.CharacterBook = &character.Book{Entries: []*character.BookEntry{constant, keyed, disabled, nil}}
+
+	counts := CountSheet(words, sheet)
+
+	assert.Equal(t, &models.TokenCounts{
+		Description:  3,
+		Personality:  1,
+		Scenario:     4,
+		SystemPrompt: 5,
+		Examples:     2,
+		FirstMessage: 2,
+		Greetings:    []int{1, 3},
+		BookConstant: 5,
+		BookKeyed:    4,
+	}, counts)
+	assert.Equal(t, 18, counts.Permanent())
+	assert.Equal(t, 30, counts.Total())
+	assert.Equal(t, &models.TokenCounts{}, CountSheet(nil, nil))
+	assert.Positive(t, CountSheet(nil, sheet).Total(), "nil tokenizer uses the default")
+}
@@ -0,0 +1,195 @@
+package tombstone
+
+import (
+	"container/list"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-fetcher/source"
+	"github.com/r3dpixel/toolkit/timestamp"
+)
+
+// DefaultConfirmations is the default number of removal confirmations after which a card is no longer fetched
+const DefaultConfirmations = 3
+
+// LastKnownLimit is the number of last known good versions kept by the registry (the least recently fetched are evicted)
+const LastKnownLimit = 1024
+
+// Registry keeps track of the cards removed from their source (safe for concurrent use)
+type Registry struct {
+	confirmations int
+	lastKnown     map[string]*list.Element
+	lastKnownLRU  *list.List
+	tombstones    map[string]*models.Tombstone
+	mu            sync.RWMutex
+}
+
+// New creates a new registry which stops fetching a card after the given number of removal confirmations
+func New(confirmations int) *Registry {
+	// Use the default number of confirmations if the given one is not valid
+	if confirmations <= 0 {
+		confirmations = DefaultConfirmations
+	}
+	return &Registry{
+		confirmations: confirmations,
+		lastKnown:     make(map[string]*list.Element),
+		lastKnownLRU:  list.New(),
+		tombstones:    make(map[string]*models.Tombstone),
+	}
+}
+
+// Confirmations returns the number of removal confirmations after which a card is no longer fetched
+func (r *Registry) Confirmations() int {
+	return r.confirmations
+}
+
+// Remember records the last known good version of a card (the card is alive, any tombstone is removed)
+func (r *Registry) Remember(metadata *models.Metadata) {
+	// Return if there is nothing to remember
+	if metadata == nil {
+		return
+	}
+
+	// Lock the registry
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Save the last known version and remove the tombstone
+	key := normalizeKey(metadata.NormalizedURL)
+	r.remember(key, metadata.Clone())
+	delete(r.tombstones, key)
+}
+
+// remember saves the last known version as the most recent one, evicting the least recent beyond LastKnownLimit
+func (r *Registry) remember(key string, metadata *models.Metadata) {
+	// Update the version if the card is already known
+	if element, ok := r.lastKnown[key]; ok {
+		element.Value.(*lastKnownEntry).metadata = metadata
+		r.lastKnownLRU.MoveToFront(element)
+		return
+	}
+
+	// Save the version, then evict the least recent ones
+	r.lastKnown[key] = r.lastKnownLRU.PushFront(&lastKnownEntry{key: key, metadata: metadata})
+	for r.lastKnownLRU.Len() > LastKnownLimit {
+		r.forget(r.lastKnownLRU.Back())
+	}
+}
+
+// forget removes the last known version held by the element
+func (r *Registry) forget(element *list.Element) {
+	r.lastKnownLRU.Remove(element)
+	delete(r.lastKnown, element.Value.(*lastKnownEntry).key)
+}
+
+// lastKnownEntry is the last known good version of a card in the LRU list
+type lastKnownEntry struct {
+	key      string
+	metadata *models.Metadata
+}
+
+// Bury records a removal confirmation for the given card and returns the updated tombstone
+func (r *Registry) Bury(sourceID source.ID, normalizedURL string) models.Tombstone {
+	// Lock the registry
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Current time of the confirmation
+	now := timestamp.Nano(time.Now().UnixNano())
+
+	// Retrieve the tombstone, or create it on the first confirmation (the tombstone takes over the last known version)
+	key := normalizeKey(normalizedURL)
+	tombstone, ok := r.tombstones[key]
+	if !ok {
+		tombstone = &models.Tombstone{
+			Source:        sourceID,
+			NormalizedURL: normalizedURL,
+			RemovedTime:   now,
+		}
+		if element, known := r.lastKnown[key]; known {
+			tombstone.LastKnown = element.Value.(*lastKnownEntry).metadata
+			r.forget(element)
+		}
+		r.tombstones[key] = tombstone
+	}
+
+	// Record the confirmation
+	tombstone.Confirmations++
+	tombstone.LastCheckTime = now
+
+	// Return a copy of the tombstone
+	return *tombstone.Clone()
+}
+
+// IsBuried checks if the card was confirmed removed enough times to stop fetching it
+func (r *Registry) IsBuried(normalizedURL string) bool {
+	// Lock the registry for reading
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Check the tombstone
+	tombstone, ok := r.tombstones[normalizeKey(normalizedURL)]
+	return ok && tombstone.IsConfirmed(r.confirmations)
+}
+
+// Get returns the tombstone of the given card
+func (r *Registry) Get(normalizedURL string) (models.Tombstone, bool) {
+	// Lock the registry for reading
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Return a copy of the tombstone
+	tombstone, ok := r.tombstones[normalizeKey(normalizedURL)]
+	if !ok {
+		return models.Tombstone{}, false
+	}
+	return *tombstone.Clone(), true
+}
+
+// Tombstones returns all the tombstones in the registry, sorted by normalized URL
+func (r *Registry) Tombstones() []models.Tombstone {
+	// Lock the registry for reading
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Copy the tombstones in a stable order
+	keys := slices.Sorted(maps.Keys(r.tombstones))
+	tombstones := make([]models.Tombstone, len(keys))
+	for index, key := range keys {
+		tombstones[index] = *r.tombstones[key].Clone()
+	}
+
+	// Return the tombstones
+	return tombstones
+}
+
+// Restore loads previously saved tombstones into the registry (overwriting existing ones)
+func (r *Registry) Restore(tombstones ...models.Tombstone) {
+	// Lock the registry
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Save a copy of each tombstone
+	for index := range tombstones {
+		r.tombstones[normalizeKey(tombstones[index].NormalizedURL)] = tombstones[index].Clone()
+	}
+}
+
+// Exhume removes the tombstone of the given card (the card will be fetched again)
+func (r *Registry) Exhume(normalizedURL string) {
+	// Lock the registry
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Remove the tombstone
+	delete(r.tombstones, normalizeKey(normalizedURL))
+}
+
+// normalizeKey normalizes the registry key (trailing slashes are ignored)
+func normalizeKey(normalizedURL string) string {
+	return strings.TrimSuffix(normalizedURL, "/")
+}
@@ -0,0 +1,117 @@
+package tombstone
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/r3dpixel/card-fetcher/models"
+	"github.com/r3dpixel/card-fetcher/source"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("should use the given confirmations", func(t *testing.T) {
+		assert.Equal(t, 5, New(5).Confirmations())
+	})
+
+	t.Run("should fallback to the default confirmations", func(t *testing.T) {
+		assert.Equal(t, DefaultConfirmations, New(0).Confirmations())
+		assert.Equal(t, DefaultConfirmations, New(-1).Confirmations())
+	})
+}
+
+func TestRegistry_Bury(t *testing.T) {
+	t.Run("should bury after the configured confirmations", func(t *testing.T) {
+		registry := New(2)
+
+		first := registry.Bury(source.ChubAI, "chub.ai/characters/a/b")
+		assert.Equal(t, 1, first.Confirmations)
+		assert.False(t, registry.IsBuried("chub.ai/characters/a/b"))
+
+		second := registry.Bury(source.ChubAI, "chub.ai/characters/a/b")
+		assert.Equal(t, 2, second.Confirmations)
+		assert.Equal(t, first.RemovedTime, second.RemovedTime)
+		assert.GreaterOrEqual(t, second.LastCheckTime, first.LastCheckTime)
+		assert.True(t, registry.IsBuried("chub.ai/characters/a/b"))
+		assert.True(t, registry.IsBuried("chub.ai/characters/a/b/"), "trailing slashes should be ignored")
+	})
+
+	t.Run("should keep the last known version", func(t *testing.T) {
+		registry := New(1)
+		metadata := &models.Metadata{
+			Source: source.ChubAI,
+			CardInfo: models.CardInfo{
+				NormalizedURL: "chub.ai/characters/a/b",
+				Name:          "Name",
+				Tags:          []models.Tag{{Slug: "tag", Name: "Tag"}},
+			},
+		}
+		registry.Remember(metadata)
+
+		// Mutating the original should not alter the registry
+		metadata.Name = "Changed"
+		metadata.Tags[0].Name = "Changed"
+
+		tombstone := registry.Bury(source.ChubAI, "chub.ai/characters/a/b")
+		if assert.NotNil(t, tombstone.LastKnown) {
+			assert.Equal(t, "Name", tombstone.LastKnown.Name)
+			assert.Equal(t, "Tag", tombstone.LastKnown.Tags[0].Name)
+		}
+	})
+}
+
+func TestRegistry_Remember(t *testing.T) {
+	registry := New(1)
+	registry.Bury(source.ChubAI, "chub.ai/characters/a/b")
+	assert.True(t, registry.IsBuried("chub.ai/characters/a/b"))
+
+	registry.Remember(&models.Metadata{CardInfo: models.CardInfo{NormalizedURL: "chub.ai/characters/a/b"}})
+	assert.False(t, registry.IsBuried("chub.ai/characters/a/b"), "a successful fetch should remove the tombstone")
+
+	_, ok := registry.Get("chub.ai/characters/a/b")
+	assert.False(t, ok)
+
+	assert.NotPanics(t, func() { registry.Remember(nil) })
+}
+
+func TestRegistry_LastKnownLimit(t *testing.T) {
+	registry := New(1)
+	remember := func(index int) {
+		registry.Remember(&models.Metadata{CardInfo: models.CardInfo{NormalizedURL: fmt.Sprintf("chub.ai/characters/a/%d", index), Name: "Name"}})
+	}
+
+	// The first card is fetched again, so the second one is the least recent when the limit is exceeded
+	for index := range LastKnownLimit {
+		remember(index)
+	}
+	remember(0)
+	remember(LastKnownLimit)
+	assert.Len(t, registry.lastKnown, LastKnownLimit)
+
+	assert.NotNil(t, registry.Bury(source.ChubAI, "chub.ai/characters/a/0").LastKnown)
+	assert.Nil(t, registry.Bury(source.ChubAI, "chub.ai/characters/a/1").LastKnown, "the least recent version should be evicted")
+	assert.NotNil(t, registry.Bury(source.ChubAI, fmt.Sprintf("chub.ai/characters/a/%d", LastKnownLimit)).LastKnown)
+
+	// Buried cards are held by their tombstone only
+	assert.Len(t, registry.lastKnown, LastKnownLimit-2)
+}
+
+func TestRegistry_RestoreAndExhume(t *testing.T) {
+	registry := New(2)
+	registry.Restore(
+		models.Tombstone{Source: source.WyvernChat, NormalizedURL: "wyvern.chat/characters/b", Confirmations: 2},
+		models.Tombstone{Source: source.ChubAI, NormalizedURL: "chub.ai/characters/a", Confirmations: 1},
+	)
+
+	tombstones := registry.Tombstones()
+	if assert.Len(t, tombstones, 2) {
+		assert.Equal(t, "chub.ai/characters/a", tombstones[0].NormalizedURL)
+		assert.Equal(t, "wyvern.chat/characters/b", tombstones[1].NormalizedURL)
+	}
+	assert.False(t, registry.IsBuried("chub.ai/characters/a"))
+	assert.True(t, registry.IsBuried("wyvern.chat/characters/b"))
+
+	registry.Exhume("wyvern.chat/characters/b")
+	assert.False(t, registry.IsBuried("wyvern.chat/characters/b"))
+	assert.Len(t, registry.Tombstones(), 1)
+}
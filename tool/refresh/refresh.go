@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/r3dpixel/card-fetcher/export"
+	"github.com/r3dpixel/card-fetcher/refresh"
+	"github.com/r3dpixel/card-fetcher/router"
+	"github.com/rs/zerolog/log"
+)
+
+// main is the entry point of the refresh command
+func main() {
+	// Parse the flags
+	var opts refresh.Options
+	flag.BoolVar(&opts.Recursive, "recursive", false, "scan the subdirectories too")
+	flag.BoolVar(&opts.DryRun, "dry-run", false, "only report the outdated cards")
+	flag.BoolVar(&opts.Force, "force", false, "overwrite the cards even if they are up to date")
+	flag.BoolVar(&opts.DisableBackup, "no-backup", false, "do not back up the overwritten cards")
+	flag.StringVar(&opts.BackupDir, "backup-dir", "", "directory for the backup files, keeping the relative paths of the cards (defaults to the card directory)")
+	version := flag.String("version", "", "version of the written cards: v2 or v3 (defaults to the version of the fetched card)")
+	flag.Parse()
+	opts.Version = export.Version(*version)
+
+	// The directory is the only positional argument
+	if flag.NArg() != 1 || (opts.Version != export.VersionKeep && opts.Version != export.VersionV2 && opts.Version != export.VersionV3) {
+		fmt.Fprintln(os.Stderr, "usage: refresh [flags] <characters directory>")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	// Refresh the directory
+	reports, err := refresh.New(router.EnvConfigured(nil), opts).Dir(flag.Arg(0))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to scan " + flag.Arg(0))
+	}
+
+	// Print the reports
+	for _, report := range reports {
+		if report.Err != nil {
+			log.Warn().Err(report.Err).Str("status", string(report.Status)).Msg(report.Path)
+			continue
+		}
+		log.Info().Str("status", string(report.Status)).Str("url", report.NormalizedURL).Msg(report.Path)
+	}
+}